@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"sync"
 	"syscall"
 	"time"
@@ -15,6 +16,8 @@ import (
 	"github.com/stefanbeyeler/loxone2velux/internal/api"
 	"github.com/stefanbeyeler/loxone2velux/internal/config"
 	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+	"github.com/stefanbeyeler/loxone2velux/internal/scheduler"
+	"github.com/stefanbeyeler/loxone2velux/internal/storage"
 )
 
 var version = "dev"
@@ -24,16 +27,18 @@ type ConfigManager struct {
 	cfg        *config.Config
 	configPath string
 	gateway    *gateway.Service
+	scheduler  *scheduler.Scheduler
 	mu         sync.RWMutex
 	logger     zerolog.Logger
 }
 
 // NewConfigManager creates a new ConfigManager
-func NewConfigManager(cfg *config.Config, configPath string, gw *gateway.Service, logger zerolog.Logger) *ConfigManager {
+func NewConfigManager(cfg *config.Config, configPath string, gw *gateway.Service, sched *scheduler.Scheduler, logger zerolog.Logger) *ConfigManager {
 	return &ConfigManager{
 		cfg:        cfg,
 		configPath: configPath,
 		gateway:    gw,
+		scheduler:  sched,
 		logger:     logger,
 	}
 }
@@ -60,18 +65,31 @@ func (m *ConfigManager) UpdateConfig(cfg *config.Config) error {
 		return err
 	}
 
-	// Save to file
+	// Save before applying, callers must know whether the change survives a restart
+	if cfg.KLF200.Host != m.cfg.KLF200.Host {
+		if err := cfg.KLF200.SaveHostFile(); err != nil {
+			m.logger.Error().Err(err).Msg("Failed to save KLF-200 host file")
+			return err
+		}
+	}
+	if cfg.KLF200.Password != m.cfg.KLF200.Password {
+		if err := cfg.KLF200.SavePasswordFile(); err != nil {
+			m.logger.Error().Err(err).Msg("Failed to save KLF-200 password file")
+			return err
+		}
+	}
 	if err := cfg.Save(m.configPath); err != nil {
 		m.logger.Error().Err(err).Msg("Failed to save config file")
-		// Continue anyway - config is updated in memory
-	} else {
-		m.logger.Info().Str("path", m.configPath).Msg("Configuration saved")
+		return err
 	}
+	m.logger.Info().Str("path", m.configPath).Msg("Configuration saved")
 
 	// Update gateway config if KLF-200 settings changed
 	if m.cfg.KLF200.Host != cfg.KLF200.Host ||
 		m.cfg.KLF200.Port != cfg.KLF200.Port ||
-		m.cfg.KLF200.Password != cfg.KLF200.Password {
+		m.cfg.KLF200.Password != cfg.KLF200.Password ||
+		m.cfg.KLF200.StatusPollInterval != cfg.KLF200.StatusPollInterval ||
+		m.cfg.KLF200.StatusPollMovingInterval != cfg.KLF200.StatusPollMovingInterval {
 		m.gateway.UpdateConfig(&cfg.KLF200)
 	}
 
@@ -84,11 +102,30 @@ func (m *ConfigManager) UpdateConfig(cfg *config.Config) error {
 	if mappingMgr := m.gateway.GetMappingManager(); mappingMgr != nil {
 		mappingMgr.Load(cfg.Loxone.Mappings)
 	}
+	if m.scheduler != nil {
+		m.scheduler.Load(cfg)
+	}
+	m.gateway.SetProtectionConfig(cfg.Protection)
+	m.gateway.SetInterlockConfig(cfg.Interlocks)
+	m.gateway.SetPresets(cfg.Presets)
+	m.gateway.SetCommandQueueConfig(cfg.CommandQueue)
+	m.gateway.SetDebounceConfig(cfg.Debounce)
 
 	m.cfg = cfg
 	return nil
 }
 
+// PasswordChanged saves a password that another client set on the KLF-200
+func (m *ConfigManager) PasswordChanged(password string) {
+	cfg := *m.GetConfig()
+	cfg.KLF200.Password = password
+	if err := m.UpdateConfig(&cfg); err != nil {
+		m.logger.Error().Err(err).Msg("KLF-200 password changed by another client, but the configuration could not be saved")
+		return
+	}
+	m.logger.Info().Msg("KLF-200 password changed by another client saved")
+}
+
 func main() {
 	// Parse command line flags
 	configPath := flag.String("config", "config.yaml", "Path to configuration file")
@@ -137,6 +174,26 @@ func main() {
 
 	// Create gateway service
 	gw := gateway.NewService(&cfg.KLF200, &cfg.Loxone, logger)
+	gw.SetProtectionConfig(cfg.Protection)
+	gw.SetInterlockConfig(cfg.Interlocks)
+	gw.SetPresets(cfg.Presets)
+	gw.SetCommandQueueConfig(cfg.CommandQueue)
+	gw.SetDebounceConfig(cfg.Debounce)
+
+	// Open history storage (optional - the gateway works without it)
+	var store *storage.Store
+	if cfg.Storage.Enabled {
+		storageCfg := cfg.Storage
+		if !filepath.IsAbs(storageCfg.Path) {
+			storageCfg.Path = filepath.Join(filepath.Dir(*configPath), storageCfg.Path)
+		}
+		store, err = storage.Open(storageCfg, logger)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to open storage, node state and history will not be persisted")
+		} else {
+			gw.SetStore(store)
+		}
+	}
 
 	// Only start gateway if KLF200 is configured
 	if cfg.IsKLF200Configured() {
@@ -149,11 +206,17 @@ func main() {
 		logger.Warn().Msg("KLF-200 not configured (host or password missing) - gateway not started. Configure via web UI or add-on settings.")
 	}
 
+	// Create and start scheduler
+	sched := scheduler.New(gw, logger)
+	sched.Load(cfg)
+	sched.Start()
+
 	// Create config manager
-	configMgr := NewConfigManager(cfg, *configPath, gw, logger)
+	configMgr := NewConfigManager(cfg, *configPath, gw, sched, logger)
+	gw.SetPasswordChangeHandler(configMgr.PasswordChanged)
 
 	// Create and start API server
-	server := api.NewServer(&cfg.Server, gw, logger, configMgr, version)
+	server := api.NewServer(&cfg.Server, gw, sched, logger, configMgr, version)
 
 	// Start server in goroutine
 	go func() {
@@ -177,10 +240,18 @@ func main() {
 		logger.Error().Err(err).Msg("Server shutdown error")
 	}
 
+	sched.Stop()
+
 	if err := gw.Stop(); err != nil {
 		logger.Error().Err(err).Msg("Gateway shutdown error")
 	}
 
+	if store != nil {
+		if err := store.Close(); err != nil {
+			logger.Error().Err(err).Msg("Storage close error")
+		}
+	}
+
 	logger.Info().Msg("Goodbye!")
 }
 
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// GetActivationLog returns the KLF-200 activation log: who moved which node and which errors occurred
+// Query parameters: from, to (RFC 3339, default: last 24 hours), node (optional node ID)
+func (h *Handlers) GetActivationLog(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid time range", err.Error())
+		return
+	}
+
+	var nodeID *uint8
+	if v := r.URL.Query().Get("node"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 8)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid node ID", err.Error())
+			return
+		}
+		n := uint8(id)
+		nodeID = &n
+	}
+
+	entries, err := h.gateway.GetActivationLog(r.Context(), from, to, nodeID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read activation log", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from":    from,
+		"to":      to,
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
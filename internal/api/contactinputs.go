@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// ContactInputRequest is the request body for linking a contact input of the KLF-200
+type ContactInputRequest struct {
+	Type          string  `json:"type"`               // "node" or "scene"
+	NodeID        *uint8  `json:"node_id,omitempty"`  // For type node
+	SceneID       *uint8  `json:"scene_id,omitempty"` // For type scene
+	Position      float64 `json:"position"`           // Target for type node (0 = open, 100 = closed)
+	Velocity      string  `json:"velocity,omitempty"` // default, silent or fast
+	SuccessOutput uint8   `json:"success_output"`     // Output closed on success, 0 = none
+	ErrorOutput   uint8   `json:"error_output"`       // Output closed on error, 0 = none
+}
+
+// ListContactInputs returns the actions linked to the contact inputs of the KLF-200
+func (h *Handlers) ListContactInputs(w http.ResponseWriter, r *http.Request) {
+	links, err := h.gateway.GetContactInputLinks(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read contact inputs", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"links": links,
+		"count": len(links),
+	})
+}
+
+// SetContactInput links a contact input to a node or scene
+func (h *Handlers) SetContactInput(w http.ResponseWriter, r *http.Request) {
+	inputID, err := parseContactInputID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid input ID", err.Error())
+		return
+	}
+
+	var req ContactInputRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	link := klf200.ContactInputLink{
+		InputID:         inputID,
+		Priority:        klf200.PriorityUserLevel2,
+		Velocity:        klf200.VelocityDefault,
+		SuccessOutputID: req.SuccessOutput,
+		ErrorOutputID:   req.ErrorOutput,
+	}
+
+	switch strings.ToLower(req.Type) {
+	case "node":
+		if req.NodeID == nil {
+			writeError(w, http.StatusBadRequest, "Missing node_id", "")
+			return
+		}
+		link.Assignment = klf200.ContactInputNode
+		link.ActionID = *req.NodeID
+		link.PositionPercent = req.Position
+	case "scene":
+		if req.SceneID == nil {
+			writeError(w, http.StatusBadRequest, "Missing scene_id", "")
+			return
+		}
+		link.Assignment = klf200.ContactInputScene
+		link.ActionID = *req.SceneID
+	default:
+		writeError(w, http.StatusBadRequest, "Invalid type", "type must be node or scene")
+		return
+	}
+
+	if req.Velocity != "" {
+		velocity, ok := parseVelocity(req.Velocity)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "Invalid velocity", "velocity must be default, silent or fast")
+			return
+		}
+		link.Velocity = velocity
+	}
+
+	result, err := h.gateway.SetContactInputLink(r.Context(), link)
+	if err != nil {
+		if errors.Is(err, klf200.ErrNodeNotFound) || errors.Is(err, klf200.ErrSceneNotFound) {
+			writeError(w, http.StatusNotFound, "Link target not found", err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, "Failed to link contact input", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// RemoveContactInput removes the link of a contact input
+func (h *Handlers) RemoveContactInput(w http.ResponseWriter, r *http.Request) {
+	inputID, err := parseContactInputID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid input ID", err.Error())
+		return
+	}
+
+	if err := h.gateway.RemoveContactInputLink(r.Context(), inputID); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to remove contact input link", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// parseContactInputID extracts the contact input ID from the URL
+func parseContactInputID(r *http.Request) (uint8, error) {
+	inputID, err := strconv.ParseUint(chi.URLParam(r, "inputID"), 10, 8)
+	if err != nil {
+		return 0, err
+	}
+	if inputID < klf200.MinContactInputID || inputID > klf200.MaxContactInputID {
+		return 0, errors.New("input ID must be 1-5")
+	}
+	return uint8(inputID), nil
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// eventWriteTimeout is the time allowed to write a single message to a websocket client
+	eventWriteTimeout = 10 * time.Second
+	// eventPongTimeout is the time allowed between pongs before a client is considered gone
+	eventPongTimeout = 60 * time.Second
+	// eventPingInterval must be shorter than eventPongTimeout
+	eventPingInterval = 30 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// The web frontend is served from the same host, Home Assistant Ingress proxies it
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// ListEvents returns the recent gateway events, optionally only those after ?since=<id>
+func (h *Handlers) ListEvents(w http.ResponseWriter, r *http.Request) {
+	var since uint64
+	if s := r.URL.Query().Get("since"); s != "" {
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid since parameter", err.Error())
+			return
+		}
+		since = v
+	}
+
+	events := h.gateway.Events().Recent(since)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// StreamEvents streams gateway events to a websocket client.
+// With ?since=<id> the kept events after that ID are sent first.
+func (h *Handlers) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	var since uint64
+	if s := r.URL.Query().Get("since"); s != "" {
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid since parameter", err.Error())
+			return
+		}
+		since = v
+	}
+
+	// Subscribe before upgrading so no event is lost between backlog and stream
+	hub := h.gateway.Events()
+	ch, unsubscribe := hub.Subscribe()
+	defer unsubscribe()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.logger.Warn().Err(err).Msg("Websocket upgrade failed")
+		return
+	}
+	defer conn.Close()
+
+	// Read pump: handles pongs and detects closed connections
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(eventPongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(eventPongTimeout))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	var last uint64
+	if since > 0 {
+		for _, e := range hub.Recent(since) {
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+			last = e.ID
+		}
+	}
+
+	ping := time.NewTicker(eventPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			if e.ID <= last {
+				continue // Already sent with the backlog
+			}
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -3,9 +3,12 @@ package api
 import (
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
@@ -13,6 +16,7 @@ import (
 	"github.com/stefanbeyeler/loxone2velux/internal/config"
 	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
 	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+	"github.com/stefanbeyeler/loxone2velux/internal/scheduler"
 )
 
 // ConfigManager interface for configuration operations
@@ -25,15 +29,17 @@ type ConfigManager interface {
 // Handlers holds all HTTP handlers
 type Handlers struct {
 	gateway    *gateway.Service
+	scheduler  *scheduler.Scheduler
 	logger     zerolog.Logger
 	configMgr  ConfigManager
 	version    string
 }
 
 // NewHandlers creates new handlers
-func NewHandlers(gw *gateway.Service, logger zerolog.Logger, configMgr ConfigManager, version string) *Handlers {
+func NewHandlers(gw *gateway.Service, sched *scheduler.Scheduler, logger zerolog.Logger, configMgr ConfigManager, version string) *Handlers {
 	return &Handlers{
 		gateway:   gw,
+		scheduler: sched,
 		logger:    logger.With().Str("component", "handlers").Logger(),
 		configMgr: configMgr,
 		version:   version,
@@ -46,6 +52,11 @@ type HealthResponse struct {
 	Connected bool   `json:"connected"`
 	NodeCount int    `json:"node_count"`
 	Version   string `json:"version"`
+
+	// KLF-200 information, known after the first connect
+	KLF200Firmware string `json:"klf200_firmware,omitempty"`
+	KLF200Protocol string `json:"klf200_protocol,omitempty"`
+	KLF200State    string `json:"klf200_state,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -82,6 +93,15 @@ func (h *Handlers) Health(w http.ResponseWriter, r *http.Request) {
 		resp.Status = "degraded"
 	}
 
+	info := h.gateway.GetSystemInfo()
+	if info.Version != nil {
+		resp.KLF200Firmware = info.Version.Software
+	}
+	resp.KLF200Protocol = info.Protocol
+	if info.State != nil {
+		resp.KLF200State = info.State.StateStr
+	}
+
 	writeJSON(w, http.StatusOK, resp)
 }
 
@@ -89,6 +109,14 @@ func (h *Handlers) Health(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) ListNodes(w http.ResponseWriter, r *http.Request) {
 	nodes := h.gateway.GetNodes()
 
+	// Same order as the product list of the KLF-200
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Order != nodes[j].Order {
+			return nodes[i].Order < nodes[j].Order
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+
 	resp := NodesResponse{
 		Nodes: nodes,
 		Count: len(nodes),
@@ -133,8 +161,13 @@ func (h *Handlers) SetPosition(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.gateway.SetPosition(r.Context(), nodeID, req.Position); err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to set position", err.Error())
+	err = h.gateway.SetPosition(r.Context(), nodeID, req.Position)
+	if errors.Is(err, gateway.ErrCommandQueued) {
+		writeQueued(w, nodeID)
+		return
+	}
+	if err != nil {
+		writeError(w, commandErrorStatus(err), "Failed to set position", err.Error())
 		return
 	}
 
@@ -153,8 +186,13 @@ func (h *Handlers) OpenNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.gateway.Open(r.Context(), nodeID); err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to open node", err.Error())
+	err = h.gateway.Open(r.Context(), nodeID)
+	if errors.Is(err, gateway.ErrCommandQueued) {
+		writeQueued(w, nodeID)
+		return
+	}
+	if err != nil {
+		writeError(w, commandErrorStatus(err), "Failed to open node", err.Error())
 		return
 	}
 
@@ -173,8 +211,13 @@ func (h *Handlers) CloseNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.gateway.Close(r.Context(), nodeID); err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to close node", err.Error())
+	err = h.gateway.Close(r.Context(), nodeID)
+	if errors.Is(err, gateway.ErrCommandQueued) {
+		writeQueued(w, nodeID)
+		return
+	}
+	if err != nil {
+		writeError(w, commandErrorStatus(err), "Failed to close node", err.Error())
 		return
 	}
 
@@ -193,8 +236,9 @@ func (h *Handlers) StopNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.gateway.StopNode(r.Context(), nodeID); err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to stop node", err.Error())
+	err = h.gateway.StopNode(r.Context(), nodeID)
+	if err != nil {
+		writeError(w, commandErrorStatus(err), "Failed to stop node", err.Error())
 		return
 	}
 
@@ -205,6 +249,74 @@ func (h *Handlers) StopNode(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// History endpoints
+
+// defaultHistoryWindow is the time range returned when no "from" parameter is given
+const defaultHistoryWindow = 24 * time.Hour
+
+// GetNodeHistory returns the recorded position and state changes of a node
+// Query parameters: from, to (RFC 3339, default: last 24 hours)
+func (h *Handlers) GetNodeHistory(w http.ResponseWriter, r *http.Request) {
+	if !h.gateway.IsHistoryEnabled() {
+		writeError(w, http.StatusServiceUnavailable, "History storage not enabled", "")
+		return
+	}
+
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID", err.Error())
+		return
+	}
+
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid time range", err.Error())
+		return
+	}
+
+	entries, err := h.gateway.GetNodeHistory(nodeID, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read history", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"node_id": nodeID,
+		"from":    from,
+		"to":      to,
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+// GetSensorHistory returns the recorded rain and wind sensor changes
+// Query parameters: from, to (RFC 3339, default: last 24 hours)
+func (h *Handlers) GetSensorHistory(w http.ResponseWriter, r *http.Request) {
+	if !h.gateway.IsHistoryEnabled() {
+		writeError(w, http.StatusServiceUnavailable, "History storage not enabled", "")
+		return
+	}
+
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid time range", err.Error())
+		return
+	}
+
+	entries, err := h.gateway.GetSensorHistory(from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read history", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from":    from,
+		"to":      to,
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
 // Loxone-friendly endpoints (GET requests with URL parameters)
 
 // LoxoneSetPosition handles Loxone position requests via URL
@@ -224,9 +336,14 @@ func (h *Handlers) LoxoneSetPosition(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.gateway.SetPosition(r.Context(), nodeID, position); err != nil {
+	err = h.gateway.SetPosition(r.Context(), nodeID, position)
+	if errors.Is(err, gateway.ErrCommandQueued) {
+		writeLoxoneQueued(w)
+		return
+	}
+	if err != nil {
 		h.logger.Error().Err(err).Uint8("node", nodeID).Float64("pos", position).Msg("Failed to set position")
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(commandErrorStatus(err))
 		w.Write([]byte("ERROR"))
 		return
 	}
@@ -244,9 +361,14 @@ func (h *Handlers) LoxoneOpen(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.gateway.Open(r.Context(), nodeID); err != nil {
+	err = h.gateway.Open(r.Context(), nodeID)
+	if errors.Is(err, gateway.ErrCommandQueued) {
+		writeLoxoneQueued(w)
+		return
+	}
+	if err != nil {
 		h.logger.Error().Err(err).Uint8("node", nodeID).Msg("Failed to open")
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(commandErrorStatus(err))
 		w.Write([]byte("ERROR"))
 		return
 	}
@@ -264,9 +386,14 @@ func (h *Handlers) LoxoneClose(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.gateway.Close(r.Context(), nodeID); err != nil {
+	err = h.gateway.Close(r.Context(), nodeID)
+	if errors.Is(err, gateway.ErrCommandQueued) {
+		writeLoxoneQueued(w)
+		return
+	}
+	if err != nil {
 		h.logger.Error().Err(err).Uint8("node", nodeID).Msg("Failed to close")
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(commandErrorStatus(err))
 		w.Write([]byte("ERROR"))
 		return
 	}
@@ -304,9 +431,10 @@ func (h *Handlers) LoxoneStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.gateway.StopNode(r.Context(), nodeID); err != nil {
+	err = h.gateway.StopNode(r.Context(), nodeID)
+	if err != nil {
 		h.logger.Error().Err(err).Uint8("node", nodeID).Msg("Failed to stop")
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(commandErrorStatus(err))
 		w.Write([]byte("ERROR"))
 		return
 	}
@@ -453,8 +581,9 @@ func (h *Handlers) UpdateConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get current config and update it
-	cfg := h.configMgr.GetConfig()
+	// Copy the current config, so the config manager notices the changes
+	newCfg := *h.configMgr.GetConfig()
+	cfg := &newCfg
 
 	// Update KLF200 settings
 	if req.KLF200.Host != "" {
@@ -514,6 +643,33 @@ func parseNodeID(r *http.Request) (uint8, error) {
 	return uint8(nodeID), nil
 }
 
+// parseTimeRange reads the optional "from" and "to" query parameters (RFC 3339)
+func parseTimeRange(r *http.Request) (time.Time, time.Time, error) {
+	to := time.Now()
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultHistoryWindow)
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+		from = t
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+
+	return from, to, nil
+}
+
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -542,9 +698,17 @@ func generateUUID() string {
 // ListMappings returns all node-to-Loxone mappings
 func (h *Handlers) ListMappings(w http.ResponseWriter, r *http.Request) {
 	mappings := h.gateway.GetMappingManager().GetAll()
+
+	// Mappings pointing to node IDs the KLF-200 no longer knows
+	orphaned := make([]string, 0)
+	for _, mapping := range h.gateway.OrphanedMappings() {
+		orphaned = append(orphaned, mapping.ID)
+	}
+
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"mappings": mappings,
 		"count":    len(mappings),
+		"orphaned": orphaned,
 	})
 }
 
@@ -561,12 +725,18 @@ func (h *Handlers) CreateMapping(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := mapping.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mapping", err.Error())
+		return
+	}
+
 	mapping.ID = generateUUID()
 	mapping.Enabled = true
 
-	cfg := h.configMgr.GetConfig()
-	cfg.Loxone.Mappings = append(cfg.Loxone.Mappings, mapping)
-	if err := h.configMgr.UpdateConfig(cfg); err != nil {
+	// Copy the config and its mappings, a failed update must not change the live config
+	cfg := *h.configMgr.GetConfig()
+	cfg.Loxone.Mappings = append(append([]config.NodeMapping(nil), cfg.Loxone.Mappings...), mapping)
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to save mapping", err.Error())
 		return
 	}
@@ -584,7 +754,13 @@ func (h *Handlers) UpdateMapping(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cfg := h.configMgr.GetConfig()
+	if err := update.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid mapping", err.Error())
+		return
+	}
+
+	cfg := *h.configMgr.GetConfig()
+	cfg.Loxone.Mappings = append([]config.NodeMapping(nil), cfg.Loxone.Mappings...)
 	found := false
 	for i, m := range cfg.Loxone.Mappings {
 		if m.ID == mappingID {
@@ -600,7 +776,7 @@ func (h *Handlers) UpdateMapping(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.configMgr.UpdateConfig(cfg); err != nil {
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to save mapping", err.Error())
 		return
 	}
@@ -612,7 +788,7 @@ func (h *Handlers) UpdateMapping(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) DeleteMapping(w http.ResponseWriter, r *http.Request) {
 	mappingID := chi.URLParam(r, "mappingID")
 
-	cfg := h.configMgr.GetConfig()
+	cfg := *h.configMgr.GetConfig()
 	newMappings := make([]config.NodeMapping, 0, len(cfg.Loxone.Mappings))
 	found := false
 
@@ -630,7 +806,7 @@ func (h *Handlers) DeleteMapping(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cfg.Loxone.Mappings = newMappings
-	if err := h.configMgr.UpdateConfig(cfg); err != nil {
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to delete mapping", err.Error())
 		return
 	}
@@ -657,10 +833,10 @@ func (h *Handlers) UpdateLoxoneUDPConfig(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	cfg := h.configMgr.GetConfig()
+	cfg := *h.configMgr.GetConfig()
 	cfg.Loxone.UDPFeedback = req
 
-	if err := h.configMgr.UpdateConfig(cfg); err != nil {
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to save config", err.Error())
 		return
 	}
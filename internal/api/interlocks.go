@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+)
+
+// commandErrorStatus maps a node command error to an HTTP status code
+func commandErrorStatus(err error) int {
+	var interlockErr *gateway.InterlockError
+	if errors.As(err, &interlockErr) || errors.Is(err, gateway.ErrNodeLocked) || errors.Is(err, gateway.ErrMaintenance) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// ListInterlocks returns the interlock rules and the commands waiting for a sequence
+func (h *Handlers) ListInterlocks(w http.ResponseWriter, r *http.Request) {
+	status := h.gateway.GetInterlockStatus()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"rules":            status.Config.Rules,
+		"count":            len(status.Config.Rules),
+		"sequence_timeout": status.Config.SequenceTimeout.String(),
+		"tolerance":        status.Config.Tolerance,
+		"sequences":        status.Sequences,
+	})
+}
+
+// CreateInterlock creates a new interlock rule
+func (h *Handlers) CreateInterlock(w http.ResponseWriter, r *http.Request) {
+	var rule config.InterlockRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := rule.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid interlock rule", err.Error())
+		return
+	}
+
+	rule.ID = generateUUID()
+
+	// Work on a copy, the config is only replaced once it is saved
+	cfg := *h.configMgr.GetConfig()
+	cfg.Interlocks.Rules = append(append([]config.InterlockRule(nil), cfg.Interlocks.Rules...), rule)
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to save interlock rule", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+// UpdateInterlock replaces an existing interlock rule
+func (h *Handlers) UpdateInterlock(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "ruleID")
+
+	var update config.InterlockRule
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := update.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid interlock rule", err.Error())
+		return
+	}
+
+	cfg := *h.configMgr.GetConfig()
+	cfg.Interlocks.Rules = append([]config.InterlockRule(nil), cfg.Interlocks.Rules...)
+	found := false
+	for i, rule := range cfg.Interlocks.Rules {
+		if rule.ID == ruleID {
+			update.ID = ruleID
+			cfg.Interlocks.Rules[i] = update
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		writeError(w, http.StatusNotFound, "Interlock rule not found", "")
+		return
+	}
+
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to save interlock rule", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, update)
+}
+
+// DeleteInterlock deletes an interlock rule
+func (h *Handlers) DeleteInterlock(w http.ResponseWriter, r *http.Request) {
+	ruleID := chi.URLParam(r, "ruleID")
+
+	cfg := *h.configMgr.GetConfig()
+	newRules := make([]config.InterlockRule, 0, len(cfg.Interlocks.Rules))
+	found := false
+
+	for _, rule := range cfg.Interlocks.Rules {
+		if rule.ID == ruleID {
+			found = true
+			continue
+		}
+		newRules = append(newRules, rule)
+	}
+
+	if !found {
+		writeError(w, http.StatusNotFound, "Interlock rule not found", "")
+		return
+	}
+
+	cfg.Interlocks.Rules = newRules
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to delete interlock rule", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
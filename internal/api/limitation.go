@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// LimitationRequest is the body of POST /api/nodes/{nodeID}/limitation
+type LimitationRequest struct {
+	MinPosition *float64 `json:"min_position"`         // 0 = open, 100 = closed
+	MaxPosition *float64 `json:"max_position"`         // 0 = open, 100 = closed
+	Duration    string   `json:"duration,omitempty"`   // e.g. "30m", empty = until cleared
+	Originator  *uint8   `json:"originator,omitempty"` // Default: 1 (user), other originators need the admin token
+	Priority    *uint8   `json:"priority,omitempty"`   // Default: 3 (user level 2), 2-3 without the admin token
+}
+
+// GetLimitation returns the limitation state of a node (origin, min/max, expiry)
+func (h *Handlers) GetLimitation(w http.ResponseWriter, r *http.Request) {
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID", err.Error())
+		return
+	}
+
+	node, ok := h.gateway.GetNode(nodeID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Node not found", "")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"node_id":    nodeID,
+		"active":     node.Limitation.Active(time.Now()),
+		"limitation": node.Limitation,
+	})
+}
+
+// SetLimitation sets a limitation on the KLF-200 that restricts the travel range of a node
+func (h *Handlers) SetLimitation(w http.ResponseWriter, r *http.Request) {
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID", err.Error())
+		return
+	}
+
+	var req LimitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if req.MinPosition == nil && req.MaxPosition == nil {
+		writeError(w, http.StatusBadRequest, "min_position or max_position is required", "")
+		return
+	}
+	for _, p := range []*float64{req.MinPosition, req.MaxPosition} {
+		if p != nil && (*p < 0 || *p > 100) {
+			writeError(w, http.StatusBadRequest, "Positions must be between 0 and 100", "")
+			return
+		}
+	}
+	if req.MinPosition != nil && req.MaxPosition != nil && *req.MinPosition > *req.MaxPosition {
+		writeError(w, http.StatusBadRequest, "min_position must not be greater than max_position", "")
+		return
+	}
+
+	limitation := gateway.LimitationRequest{
+		MinPosition: req.MinPosition,
+		MaxPosition: req.MaxPosition,
+		Originator:  klf200.OriginatorUser,
+		Priority:    klf200.PriorityUserLevel2,
+	}
+	if req.Duration != "" {
+		limitation.Duration, err = time.ParseDuration(req.Duration)
+		if err != nil || limitation.Duration < 0 {
+			writeError(w, http.StatusBadRequest, "Invalid duration", req.Duration)
+			return
+		}
+		if limitation.Duration > klf200.MaxLimitationDuration {
+			writeError(w, http.StatusBadRequest, "Duration too long",
+				"maximum is "+klf200.MaxLimitationDuration.String()+", omit the duration for an unlimited limitation")
+			return
+		}
+	}
+	// Protection priorities and other originators would outrank the gateway's own protection,
+	// locks and maintenance, only admins may use them
+	admin := gateway.SourceFrom(r.Context()) == gateway.SourceManual
+	if req.Originator != nil {
+		if !admin && klf200.Originator(*req.Originator) != klf200.OriginatorUser {
+			writeError(w, http.StatusForbidden, "Only the user originator (1) is allowed", "use the admin token for other originators")
+			return
+		}
+		limitation.Originator = klf200.Originator(*req.Originator)
+	}
+	if req.Priority != nil {
+		if *req.Priority > uint8(klf200.PriorityComfortLevel4) {
+			writeError(w, http.StatusBadRequest, "Priority must be between 0 and 7", "")
+			return
+		}
+		priority := klf200.Priority(*req.Priority)
+		if !admin && priority != klf200.PriorityUserLevel1 && priority != klf200.PriorityUserLevel2 {
+			writeError(w, http.StatusForbidden, "Only the user levels (priority 2-3) are allowed", "use the admin token for other priorities")
+			return
+		}
+		limitation.Priority = priority
+	}
+
+	err = h.gateway.SetLimitation(r.Context(), nodeID, limitation)
+	if errors.Is(err, klf200.ErrNodeNotFound) {
+		writeError(w, http.StatusNotFound, "Node not found", "")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to set limitation", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, CommandResponse{
+		Success: true,
+		Message: "Limitation set",
+		NodeID:  nodeID,
+	})
+}
+
+// LoxoneNodeSensorStatus returns the limitation of a node in Loxone-friendly format.
+// Format: rain;wind;origin;min;max (e.g. "1;0;1;0;100")
+func (h *Handlers) LoxoneNodeSensorStatus(w http.ResponseWriter, r *http.Request) {
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("ERROR"))
+		return
+	}
+
+	node, ok := h.gateway.GetNode(nodeID)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("ERROR"))
+		return
+	}
+
+	rain, wind, origin := 0, 0, 0
+	minPercent, maxPercent := 0, 100
+	if l := node.Limitation; l.Active(time.Now()) {
+		origin = int(l.Origin)
+		minPercent = int(l.MinPercent)
+		maxPercent = int(l.MaxPercent)
+		switch l.Origin {
+		case klf200.LimitationTypeRain:
+			rain = 1
+		case klf200.LimitationTypeWind:
+			wind = 1
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(strconv.Itoa(rain) + ";" + strconv.Itoa(wind) + ";" + strconv.Itoa(origin) + ";" +
+		strconv.Itoa(minPercent) + ";" + strconv.Itoa(maxPercent)))
+}
+
+// ClearLimitation removes the limitations of an originator from a node
+// Query parameters: originator (default 1 = user, others need the admin token)
+func (h *Handlers) ClearLimitation(w http.ResponseWriter, r *http.Request) {
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID", err.Error())
+		return
+	}
+
+	originator := klf200.OriginatorUser
+	if v := r.URL.Query().Get("originator"); v != "" {
+		o, err := strconv.ParseUint(v, 10, 8)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid originator", err.Error())
+			return
+		}
+		originator = klf200.Originator(o)
+	}
+	if originator != klf200.OriginatorUser && gateway.SourceFrom(r.Context()) != gateway.SourceManual {
+		writeError(w, http.StatusForbidden, "Only the user originator (1) is allowed", "use the admin token for other originators")
+		return
+	}
+
+	err = h.gateway.ClearLimitation(r.Context(), nodeID, originator, klf200.PriorityUserLevel2)
+	if errors.Is(err, klf200.ErrNodeNotFound) {
+		writeError(w, http.StatusNotFound, "Node not found", "")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to clear limitation", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, CommandResponse{
+		Success: true,
+		Message: "Limitation cleared",
+		NodeID:  nodeID,
+	})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+)
+
+func newTestHandlers() *Handlers {
+	gw := gateway.NewService(&config.KLF200Config{}, nil, zerolog.Nop())
+	return NewHandlers(gw, nil, zerolog.Nop(), nil, "test")
+}
+
+func TestSetLimitation(t *testing.T) {
+	tests := []struct {
+		name string
+		node string
+		body string
+		want int
+	}{
+		{name: "invalid node", node: "x", body: `{"max_position": 50}`, want: http.StatusBadRequest},
+		{name: "no positions", node: "1", body: `{}`, want: http.StatusBadRequest},
+		{name: "position out of range", node: "1", body: `{"max_position": 101}`, want: http.StatusBadRequest},
+		{name: "min above max", node: "1", body: `{"min_position": 60, "max_position": 40}`, want: http.StatusBadRequest},
+		{name: "invalid duration", node: "1", body: `{"max_position": 50, "duration": "soon"}`, want: http.StatusBadRequest},
+		{name: "duration too long", node: "1", body: `{"max_position": 50, "duration": "3h"}`, want: http.StatusBadRequest},
+		{name: "other originator", node: "1", body: `{"max_position": 50, "originator": 2}`, want: http.StatusForbidden},
+		{name: "protection priority", node: "1", body: `{"max_position": 50, "priority": 0}`, want: http.StatusForbidden},
+		{name: "comfort priority", node: "1", body: `{"max_position": 50, "priority": 4}`, want: http.StatusForbidden},
+		{name: "invalid priority", node: "1", body: `{"max_position": 50, "priority": 8}`, want: http.StatusBadRequest},
+		{name: "unknown node", node: "1", body: `{"max_position": 50, "priority": 2}`, want: http.StatusNotFound},
+	}
+
+	r := chi.NewRouter()
+	r.Post("/api/nodes/{nodeID}/limitation", newTestHandlers().SetLimitation)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/nodes/"+tt.node+"/limitation", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestClearLimitation(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{name: "invalid originator", query: "?originator=x", want: http.StatusBadRequest},
+		{name: "other originator", query: "?originator=2", want: http.StatusForbidden},
+		{name: "unknown node", query: "", want: http.StatusNotFound},
+	}
+
+	r := chi.NewRouter()
+	r.Delete("/api/nodes/{nodeID}/limitation", newTestHandlers().ClearLimitation)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/api/nodes/1/limitation"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// LockRequest is the body of POST /api/nodes/{nodeID}/lock
+type LockRequest struct {
+	Duration string   `json:"duration,omitempty"` // e.g. "30m", empty = until unlocked
+	Position *float64 `json:"position,omitempty"` // Move here before locking (0 = open, 100 = closed)
+}
+
+// LockNode locks a node at user priority, so timers and other controllers cannot move it
+func (h *Handlers) LockNode(w http.ResponseWriter, r *http.Request) {
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID", err.Error())
+		return
+	}
+
+	var req LockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		duration, err = time.ParseDuration(req.Duration)
+		if err != nil || duration < 0 {
+			writeError(w, http.StatusBadRequest, "Invalid duration", req.Duration)
+			return
+		}
+		if duration > klf200.MaxLockDuration {
+			writeError(w, http.StatusBadRequest, "Duration too long",
+				"maximum is "+klf200.MaxLockDuration.String()+", omit the duration to lock until unlocked")
+			return
+		}
+	}
+	if req.Position != nil && (*req.Position < 0 || *req.Position > 100) {
+		writeError(w, http.StatusBadRequest, "Position must be between 0 and 100", "")
+		return
+	}
+
+	lock, err := h.gateway.LockNode(r.Context(), nodeID, duration, req.Position)
+	if errors.Is(err, klf200.ErrNodeNotFound) {
+		writeError(w, http.StatusNotFound, "Node not found", "")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to lock node", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"node_id": nodeID,
+		"lock":    lock,
+	})
+}
+
+// UnlockNode releases the lock of a node
+func (h *Handlers) UnlockNode(w http.ResponseWriter, r *http.Request) {
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID", err.Error())
+		return
+	}
+
+	err = h.gateway.UnlockNode(r.Context(), nodeID)
+	if errors.Is(err, klf200.ErrNodeNotFound) {
+		writeError(w, http.StatusNotFound, "Node not found", "")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to unlock node", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, CommandResponse{
+		Success: true,
+		Message: "Node unlocked",
+		NodeID:  nodeID,
+	})
+}
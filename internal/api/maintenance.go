@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// MaintenanceRequest is the body for starting maintenance
+type MaintenanceRequest struct {
+	Duration string `json:"duration,omitempty"` // e.g. "3h", empty = 2h
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ListMaintenance returns the active maintenance windows
+func (h *Handlers) ListMaintenance(w http.ResponseWriter, r *http.Request) {
+	windows := h.gateway.GetMaintenance()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"maintenance": windows,
+		"count":       len(windows),
+	})
+}
+
+// StartMaintenance starts maintenance for all nodes or the node in the URL.
+// Loxone, schedules and rules cannot move the nodes until it expires or is ended.
+func (h *Handlers) StartMaintenance(w http.ResponseWriter, r *http.Request) {
+	nodeID, ok := maintenanceNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	var req MaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		var err error
+		duration, err = time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			writeError(w, http.StatusBadRequest, "Invalid duration", req.Duration)
+			return
+		}
+	}
+
+	window, err := h.gateway.StartMaintenance(nodeID, duration, req.Reason)
+	if errors.Is(err, klf200.ErrNodeNotFound) {
+		writeError(w, http.StatusNotFound, "Node not found", "")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to start maintenance", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, window)
+}
+
+// EndMaintenance ends the maintenance for all nodes or the node in the URL
+func (h *Handlers) EndMaintenance(w http.ResponseWriter, r *http.Request) {
+	nodeID, ok := maintenanceNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.gateway.EndMaintenance(nodeID); err != nil {
+		if errors.Is(err, gateway.ErrNoMaintenance) {
+			writeError(w, http.StatusNotFound, "No maintenance active", "")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to end maintenance", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ended"})
+}
+
+// maintenanceNodeID returns the node ID from the URL, nil for all nodes.
+// Writes an error and returns false if it is invalid.
+func maintenanceNodeID(w http.ResponseWriter, r *http.Request) (*uint8, bool) {
+	if chi.URLParam(r, "nodeID") == "" {
+		return nil, true
+	}
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID", err.Error())
+		return nil, false
+	}
+	return &nodeID, true
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
 )
 
 // LoggingMiddleware creates a logging middleware
@@ -36,11 +38,27 @@ func NewLoggingMiddleware(logger zerolog.Logger) func(http.Handler) http.Handler
 	}
 }
 
+// NewTimeoutMiddleware cancels requests after timeout, except for the given stream paths
+func NewTimeoutMiddleware(timeout time.Duration, streamPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withTimeout := middleware.Timeout(timeout)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, path := range streamPaths {
+				if r.URL.Path == path {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			withTimeout.ServeHTTP(w, r)
+		})
+	}
+}
+
 // CORSMiddleware adds CORS headers
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")
 
 		if r.Method == "OPTIONS" {
@@ -56,24 +74,27 @@ func CORSMiddleware(next http.Handler) http.Handler {
 // Token can be provided via:
 // - Header: Authorization: Bearer <token>
 // - Query parameter: ?token=<token>
-func NewTokenAuthMiddleware(token string, logger zerolog.Logger) func(http.Handler) http.Handler {
+// Additional tokens (e.g. the admin token) are accepted as well; empty ones are ignored.
+func NewTokenAuthMiddleware(token string, logger zerolog.Logger, additional ...string) func(http.Handler) http.Handler {
+	tokens := []string{token}
+	for _, t := range additional {
+		if t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			providedToken := ""
-
-			// Check Authorization header first
-			authHeader := r.Header.Get("Authorization")
-			if strings.HasPrefix(authHeader, "Bearer ") {
-				providedToken = strings.TrimPrefix(authHeader, "Bearer ")
-			}
-
-			// Fall back to query parameter (easier for Loxone)
-			if providedToken == "" {
-				providedToken = r.URL.Query().Get("token")
-			}
+			providedToken := requestToken(r)
 
 			// Validate token using constant-time comparison
-			if subtle.ConstantTimeCompare([]byte(providedToken), []byte(token)) != 1 {
+			valid := false
+			for _, t := range tokens {
+				if subtle.ConstantTimeCompare([]byte(providedToken), []byte(t)) == 1 {
+					valid = true
+				}
+			}
+			if !valid {
 				logger.Warn().
 					Str("remote", r.RemoteAddr).
 					Str("path", r.URL.Path).
@@ -86,3 +107,51 @@ func NewTokenAuthMiddleware(token string, logger zerolog.Logger) func(http.Handl
 		})
 	}
 }
+
+// NewAdminAuthMiddleware protects the admin endpoints. Without a token they are disabled,
+// as they can change the installation (pairing, network, reboot).
+func NewAdminAuthMiddleware(token string, logger zerolog.Logger) func(http.Handler) http.Handler {
+	if token != "" {
+		return NewTokenAuthMiddleware(token, logger)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusForbidden, "Admin endpoints are disabled",
+				"configure server.admin_token to enable them")
+		})
+	}
+}
+
+// NewCommandSourceMiddleware tags the node commands of the requests with their source,
+// so maintenance mode can tell automation from manual commands
+func NewCommandSourceMiddleware(source gateway.CommandSource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(gateway.WithSource(r.Context(), source)))
+		})
+	}
+}
+
+// NewManualSourceMiddleware tags the node commands of the requests as manual when they carry
+// the admin token, as API commands otherwise. Only manual commands may move nodes that are
+// in maintenance or locked; without an admin token there are none.
+func NewManualSourceMiddleware(adminToken string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			source := gateway.SourceAPI
+			if adminToken != "" && subtle.ConstantTimeCompare([]byte(requestToken(r)), []byte(adminToken)) == 1 {
+				source = gateway.SourceManual
+			}
+			next.ServeHTTP(w, r.WithContext(gateway.WithSource(r.Context(), source)))
+		})
+	}
+}
+
+// requestToken returns the token of a request, from the Authorization header
+// or else the query parameter (easier for Loxone)
+func requestToken(r *http.Request) string {
+	if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	return r.URL.Query().Get("token")
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	const adminToken = "admin-token-0123456789"
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		query  string
+		want   int
+	}{
+		{name: "disabled without token", want: http.StatusForbidden},
+		{name: "disabled ignores any token", header: "Bearer " + adminToken, want: http.StatusForbidden},
+		{name: "missing token", token: adminToken, want: http.StatusUnauthorized},
+		{name: "wrong token", token: adminToken, header: "Bearer api-token-0123456789", want: http.StatusUnauthorized},
+		{name: "header", token: adminToken, header: "Bearer " + adminToken, want: http.StatusOK},
+		{name: "query", token: adminToken, query: "?token=" + adminToken, want: http.StatusOK},
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/pairing"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			NewAdminAuthMiddleware(tt.token, zerolog.Nop())(ok).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestManualSourceMiddleware(t *testing.T) {
+	const adminToken = "admin-token-0123456789"
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		query  string
+		want   gateway.CommandSource
+	}{
+		{name: "no admin token configured", header: "Bearer api-token-0123456789", want: gateway.SourceAPI},
+		{name: "no admin token configured, empty request token", want: gateway.SourceAPI},
+		{name: "api token", token: adminToken, header: "Bearer api-token-0123456789", want: gateway.SourceAPI},
+		{name: "admin token header", token: adminToken, header: "Bearer " + adminToken, want: gateway.SourceManual},
+		{name: "admin token query", token: adminToken, query: "?token=" + adminToken, want: gateway.SourceManual},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got gateway.CommandSource
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = gateway.SourceFrom(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/nodes/1/stop"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			NewManualSourceMiddleware(tt.token)(next).ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("source = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// NetworkSetupRequest is the request body for changing the KLF-200 LAN configuration
+type NetworkSetupRequest struct {
+	IP      string `json:"ip"`
+	Mask    string `json:"mask"`
+	Gateway string `json:"gateway"`
+	DHCP    bool   `json:"dhcp"`
+	Reboot  bool   `json:"reboot"` // Reboot afterwards to activate the new setup
+	Confirm bool   `json:"confirm"`
+}
+
+// RebootRequest is the request body for rebooting the KLF-200
+type RebootRequest struct {
+	Confirm bool `json:"confirm"`
+}
+
+// GetNetworkSetup returns the LAN configuration of the KLF-200
+func (h *Handlers) GetNetworkSetup(w http.ResponseWriter, r *http.Request) {
+	setup, err := h.gateway.GetNetworkSetup(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read network setup", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, setup)
+}
+
+// SetNetworkSetup changes the LAN configuration of the KLF-200.
+// For a static address the configured KLF-200 host is updated, so the gateway
+// reconnects to the new address after the reboot.
+func (h *Handlers) SetNetworkSetup(w http.ResponseWriter, r *http.Request) {
+	var req NetworkSetupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	setup := klf200.NetworkSetup{DHCP: req.DHCP}
+	if !req.DHCP {
+		setup.IP = net.ParseIP(req.IP)
+		setup.Mask = net.ParseIP(req.Mask)
+		setup.Gateway = net.ParseIP(req.Gateway)
+		if setup.IP.To4() == nil || setup.Mask.To4() == nil || setup.Gateway.To4() == nil {
+			writeError(w, http.StatusBadRequest, "Invalid network setup",
+				"ip, mask and gateway must be IPv4 addresses unless dhcp is enabled")
+			return
+		}
+	}
+	if !req.Confirm {
+		writeError(w, http.StatusBadRequest, "Confirmation required",
+			"a wrong setup makes the KLF-200 unreachable, set confirm to true")
+		return
+	}
+
+	if err := h.gateway.SetNetworkSetup(r.Context(), setup); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to set network setup", err.Error())
+		return
+	}
+
+	host := h.configMgr.GetConfig().KLF200.Host
+	if !req.DHCP && host != setup.IP.String() {
+		// Copy the config, so the config manager notices the changed host.
+		// The config manager also writes it to klf200.host_file, if configured.
+		newCfg := *h.configMgr.GetConfig()
+		newCfg.KLF200.Host = setup.IP.String()
+		if err := h.configMgr.UpdateConfig(&newCfg); err != nil {
+			writeError(w, http.StatusInternalServerError, "Network setup changed, but failed to update host", err.Error())
+			return
+		}
+		host = newCfg.KLF200.Host
+		h.logger.Info().Str("host", host).Msg("KLF-200 host updated")
+	}
+
+	message := "Network setup saved, it becomes active after a reboot"
+	if req.Reboot {
+		if err := h.gateway.RebootGateway(r.Context()); err != nil {
+			writeError(w, http.StatusInternalServerError, "Network setup changed, but failed to reboot", err.Error())
+			return
+		}
+		message = "Network setup saved, KLF-200 is rebooting"
+	}
+	if req.DHCP {
+		message += "; with DHCP the address may change, update klf200.host if needed"
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"host":     host,
+		"rebooted": req.Reboot,
+		"message":  message,
+	})
+}
+
+// RebootKLF200 restarts the KLF-200; the gateway reconnects automatically
+func (h *Handlers) RebootKLF200(w http.ResponseWriter, r *http.Request) {
+	var req RebootRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if !req.Confirm {
+		writeError(w, http.StatusBadRequest, "Confirmation required",
+			"all products are unavailable until the KLF-200 is back, set confirm to true")
+		return
+	}
+
+	if err := h.gateway.RebootGateway(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to reboot KLF-200", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"success": true,
+		"message": "KLF-200 is rebooting, the connection is re-established automatically",
+	})
+}
+
+// ChangePasswordRequest is the request body for changing the KLF-200 password
+type ChangePasswordRequest struct {
+	NewPassword string `json:"new_password"`
+	Confirm     bool   `json:"confirm"`
+}
+
+// ChangeKLF200Password changes the KLF-200 password and stores it in the configuration
+func (h *Handlers) ChangeKLF200Password(w http.ResponseWriter, r *http.Request) {
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if req.NewPassword == "" || len(req.NewPassword) > klf200.MaxPasswordLength {
+		writeError(w, http.StatusBadRequest, "Invalid password",
+			fmt.Sprintf("new_password must be 1-%d bytes", klf200.MaxPasswordLength))
+		return
+	}
+	if !req.Confirm {
+		writeError(w, http.StatusBadRequest, "Confirmation required",
+			"other clients of the KLF-200 need the new password too, set confirm to true")
+		return
+	}
+
+	if err := h.gateway.ChangePassword(r.Context(), req.NewPassword); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to change password", err.Error())
+		return
+	}
+
+	// Copy the config, so the config manager notices the changed password
+	newCfg := *h.configMgr.GetConfig()
+	newCfg.KLF200.Password = req.NewPassword
+	if err := h.configMgr.UpdateConfig(&newCfg); err != nil {
+		// Keep the running gateway able to reconnect with the new password
+		h.gateway.UpdateConfig(&newCfg.KLF200)
+		h.logger.Error().Err(err).Msg("KLF-200 password changed, but the configuration could not be saved")
+		writeError(w, http.StatusInternalServerError, "Password changed, but failed to save configuration",
+			fmt.Sprintf("%v; set the KLF-200 password manually before the next restart", err))
+		return
+	}
+	h.logger.Info().Msg("KLF-200 password changed and saved")
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Password changed",
+	})
+}
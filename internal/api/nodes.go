@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// RefreshNode re-reads a single node from the KLF-200
+func (h *Handlers) RefreshNode(w http.ResponseWriter, r *http.Request) {
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID", err.Error())
+		return
+	}
+
+	node, err := h.gateway.RefreshNode(r.Context(), nodeID)
+	if errors.Is(err, klf200.ErrNodeNotFound) {
+		writeError(w, http.StatusNotFound, "Node not found", "")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to refresh node", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, node)
+}
+
+// UpdateNodeRequest is the request body for changing node settings in the KLF-200
+type UpdateNodeRequest struct {
+	Name      *string `json:"name"`
+	Order     *uint16 `json:"order"`
+	Placement *uint8  `json:"placement"`
+	Velocity  *string `json:"velocity"` // default, silent or fast
+}
+
+// UpdateNode renames a node or changes its order, placement or velocity
+func (h *Handlers) UpdateNode(w http.ResponseWriter, r *http.Request) {
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID", err.Error())
+		return
+	}
+
+	var req UpdateNodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	settings := gateway.NodeSettings{
+		Order:     req.Order,
+		Placement: req.Placement,
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > klf200.MaxNodeNameLength {
+			writeError(w, http.StatusBadRequest, "Invalid name",
+				fmt.Sprintf("name must be 1-%d bytes", klf200.MaxNodeNameLength))
+			return
+		}
+		settings.Name = &name
+	}
+	if req.Velocity != nil {
+		velocity, ok := parseVelocity(*req.Velocity)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "Invalid velocity", "velocity must be default, silent or fast")
+			return
+		}
+		settings.Velocity = &velocity
+	}
+
+	node, err := h.gateway.ConfigureNode(r.Context(), nodeID, settings)
+	if errors.Is(err, klf200.ErrNodeNotFound) {
+		writeError(w, http.StatusNotFound, "Node not found", "")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update node", err.Error())
+		return
+	}
+
+	// Keep the names of the Loxone mappings in sync
+	if settings.Name != nil {
+		// Copy the config and its mappings, so the config manager notices the change
+		newCfg := *h.configMgr.GetConfig()
+		newCfg.Loxone.Mappings = append([]config.NodeMapping(nil), newCfg.Loxone.Mappings...)
+		renamed := 0
+		for i, m := range newCfg.Loxone.Mappings {
+			if m.NodeID == nodeID && m.Name != *settings.Name {
+				newCfg.Loxone.Mappings[i].Name = *settings.Name
+				renamed++
+			}
+		}
+		if renamed > 0 {
+			if err := h.configMgr.UpdateConfig(&newCfg); err != nil {
+				writeError(w, http.StatusInternalServerError, "Node updated, but failed to rename mappings", err.Error())
+				return
+			}
+			h.logger.Info().Uint8("node", nodeID).Int("mappings", renamed).Msg("Mappings renamed")
+		}
+	}
+
+	writeJSON(w, http.StatusOK, node)
+}
+
+func parseVelocity(s string) (klf200.Velocity, bool) {
+	switch strings.ToLower(s) {
+	case "default":
+		return klf200.VelocityDefault, true
+	case "silent":
+		return klf200.VelocitySilent, true
+	case "fast":
+		return klf200.VelocityFast, true
+	}
+	return 0, false
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+func TestParseVelocity(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   klf200.Velocity
+		wantOK bool
+	}{
+		{in: "default", want: klf200.VelocityDefault, wantOK: true},
+		{in: "Silent", want: klf200.VelocitySilent, wantOK: true},
+		{in: "FAST", want: klf200.VelocityFast, wantOK: true},
+		{in: "slow"},
+		{in: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := parseVelocity(tt.in)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseVelocity(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestUpdateNodeValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "invalid body", body: `{`},
+		{name: "empty name", body: `{"name": "  "}`},
+		{name: "name too long", body: `{"name": "` + strings.Repeat("a", klf200.MaxNodeNameLength+1) + `"}`},
+		{name: "invalid velocity", body: `{"velocity": "slow"}`},
+	}
+
+	r := chi.NewRouter()
+	r.Patch("/api/nodes/{nodeID}", newTestHandlers().UpdateNode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/nodes/1", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, http.StatusBadRequest, rec.Body.String())
+			}
+		})
+	}
+}
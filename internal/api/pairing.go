@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// DiscoverRequest is the request body for starting a node discovery
+type DiscoverRequest struct {
+	NodeType uint8 `json:"node_type"` // Actuator type to search for, 0 = all
+}
+
+// RemoveNodesRequest is the request body for removing products from the KLF-200
+type RemoveNodesRequest struct {
+	NodeIDs []uint8 `json:"node_ids"`
+	Confirm bool    `json:"confirm"`
+}
+
+// ControllerCopyRequest is the request body for copying the configuration between controllers
+type ControllerCopyRequest struct {
+	Mode    string `json:"mode"` // send or receive
+	Confirm bool   `json:"confirm"`
+}
+
+// GetPairingStatus returns the current or last pairing operation
+func (h *Handlers) GetPairingStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"job": h.gateway.GetPairingStatus(),
+	})
+}
+
+// GetSystemTable returns the products paired with the KLF-200
+func (h *Handlers) GetSystemTable(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.gateway.GetSystemTable(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read system table", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+// StartDiscovery searches for new products in pairing mode. Progress is reported as events.
+func (h *Handlers) StartDiscovery(w http.ResponseWriter, r *http.Request) {
+	var req DiscoverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	job, err := h.gateway.StartDiscovery(r.Context(), req.NodeType)
+	if err != nil {
+		writePairingError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// RemoveNodes removes products from the KLF-200
+func (h *Handlers) RemoveNodes(w http.ResponseWriter, r *http.Request) {
+	var req RemoveNodesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if len(req.NodeIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "node_ids is required", "")
+		return
+	}
+	for _, id := range req.NodeIDs {
+		if int(id) >= klf200.NodeBitArraySize*8 {
+			writeError(w, http.StatusBadRequest, "Invalid node ID", "")
+			return
+		}
+	}
+	if !req.Confirm {
+		writeError(w, http.StatusBadRequest, "Confirmation required",
+			"removed products must be paired again, set confirm to true")
+		return
+	}
+
+	if err := h.gateway.RemoveNodes(r.Context(), req.NodeIDs); err != nil {
+		writePairingError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"removed": req.NodeIDs,
+	})
+}
+
+// StartControllerCopy copies the system key and products from or to another controller
+func (h *Handlers) StartControllerCopy(w http.ResponseWriter, r *http.Request) {
+	var req ControllerCopyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	var mode klf200.ControllerCopyMode
+	switch req.Mode {
+	case "send":
+		mode = klf200.ControllerCopyTransmit
+	case "receive":
+		mode = klf200.ControllerCopyReceive
+	default:
+		writeError(w, http.StatusBadRequest, "Invalid mode", "mode must be send or receive")
+		return
+	}
+	if !req.Confirm {
+		writeError(w, http.StatusBadRequest, "Confirmation required",
+			"receiving replaces the system key of the KLF-200, set confirm to true")
+		return
+	}
+
+	job, err := h.gateway.StartControllerCopy(r.Context(), mode)
+	if err != nil {
+		writePairingError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func writePairingError(w http.ResponseWriter, err error) {
+	if errors.Is(err, gateway.ErrPairingBusy) {
+		writeError(w, http.StatusConflict, "Pairing busy", err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "Pairing failed", err.Error())
+}
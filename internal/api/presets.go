@@ -0,0 +1,214 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+)
+
+// CapturePresetRequest is the request body for saving the current positions as a preset
+type CapturePresetRequest struct {
+	Name    string  `json:"name"`
+	NodeIDs []uint8 `json:"node_ids,omitempty"` // Empty = all nodes
+}
+
+// ListPresets returns the gateway-defined presets
+func (h *Handlers) ListPresets(w http.ResponseWriter, r *http.Request) {
+	presets := h.configMgr.GetConfig().Presets
+	if presets == nil {
+		presets = []config.Preset{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"presets": presets,
+		"count":   len(presets),
+	})
+}
+
+// GetPreset returns a single preset
+func (h *Handlers) GetPreset(w http.ResponseWriter, r *http.Request) {
+	preset, ok := h.gateway.GetPreset(presetName(r))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Preset not found", "")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, preset)
+}
+
+// CreatePreset creates a new preset
+func (h *Handlers) CreatePreset(w http.ResponseWriter, r *http.Request) {
+	var preset config.Preset
+	if err := json.NewDecoder(r.Body).Decode(&preset); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	h.savePreset(w, preset, http.StatusCreated)
+}
+
+// CapturePreset saves the current positions of the given nodes (default: all) as a new preset
+func (h *Handlers) CapturePreset(w http.ResponseWriter, r *http.Request) {
+	var req CapturePresetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	preset, err := h.gateway.CapturePreset(r.Context(), req.Name, req.NodeIDs)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to capture preset", err.Error())
+		return
+	}
+
+	h.savePreset(w, preset, http.StatusCreated)
+}
+
+// savePreset validates a new preset and appends it to the configuration
+func (h *Handlers) savePreset(w http.ResponseWriter, preset config.Preset, status int) {
+	if err := preset.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid preset", err.Error())
+		return
+	}
+
+	// Copy the config and its presets, a failed update must not change the live config
+	cfg := *h.configMgr.GetConfig()
+	for _, p := range cfg.Presets {
+		if p.Name == preset.Name {
+			writeError(w, http.StatusConflict, "Preset already exists", "")
+			return
+		}
+	}
+
+	cfg.Presets = append(append([]config.Preset(nil), cfg.Presets...), preset)
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to save preset", err.Error())
+		return
+	}
+
+	writeJSON(w, status, preset)
+}
+
+// UpdatePreset replaces an existing preset (the name may change)
+func (h *Handlers) UpdatePreset(w http.ResponseWriter, r *http.Request) {
+	name := presetName(r)
+
+	var update config.Preset
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if update.Name == "" {
+		update.Name = name
+	}
+
+	if err := update.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid preset", err.Error())
+		return
+	}
+
+	cfg := *h.configMgr.GetConfig()
+	cfg.Presets = append([]config.Preset(nil), cfg.Presets...)
+	index := -1
+	for i, p := range cfg.Presets {
+		if p.Name == name {
+			index = i
+		} else if p.Name == update.Name {
+			writeError(w, http.StatusConflict, "Preset already exists", "")
+			return
+		}
+	}
+
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "Preset not found", "")
+		return
+	}
+
+	cfg.Presets[index] = update
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to save preset", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, update)
+}
+
+// DeletePreset deletes a preset
+func (h *Handlers) DeletePreset(w http.ResponseWriter, r *http.Request) {
+	name := presetName(r)
+
+	cfg := *h.configMgr.GetConfig()
+	newPresets := make([]config.Preset, 0, len(cfg.Presets))
+	found := false
+
+	for _, p := range cfg.Presets {
+		if p.Name == name {
+			found = true
+			continue
+		}
+		newPresets = append(newPresets, p)
+	}
+
+	if !found {
+		writeError(w, http.StatusNotFound, "Preset not found", "")
+		return
+	}
+
+	cfg.Presets = newPresets
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to delete preset", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ActivatePreset moves the nodes of a preset
+func (h *Handlers) ActivatePreset(w http.ResponseWriter, r *http.Request) {
+	result, err := h.gateway.ActivatePreset(r.Context(), presetName(r))
+	if err != nil {
+		if errors.Is(err, gateway.ErrPresetNotFound) {
+			writeError(w, http.StatusNotFound, "Preset not found", "")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to activate preset", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// LoxoneActivatePreset activates a preset (Loxone-friendly)
+// GET /loxone/preset/{name}
+func (h *Handlers) LoxoneActivatePreset(w http.ResponseWriter, r *http.Request) {
+	name := presetName(r)
+
+	result, err := h.gateway.ActivatePreset(r.Context(), name)
+	if err == nil && len(result.Errors) > 0 {
+		err = errors.New("some nodes could not be moved")
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Str("preset", name).Msg("Failed to activate preset")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("ERROR"))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
+// presetName extracts the preset name from the URL
+func presetName(r *http.Request) string {
+	name := chi.URLParam(r, "name")
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
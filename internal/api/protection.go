@@ -0,0 +1,8 @@
+package api
+
+import "net/http"
+
+// GetProtectionStatus returns the rain/wind protection state and its recent actions
+func (h *Handlers) GetProtectionStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.gateway.GetProtectionStatus())
+}
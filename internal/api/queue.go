@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+)
+
+// ListCommandQueue returns the node commands waiting for the connection to the KLF-200
+func (h *Handlers) ListCommandQueue(w http.ResponseWriter, r *http.Request) {
+	commands := h.gateway.GetCommandQueue()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"commands":  commands,
+		"count":     len(commands),
+		"connected": h.gateway.IsConnected(),
+	})
+}
+
+// ClearCommandQueue drops the queued node commands
+func (h *Handlers) ClearCommandQueue(w http.ResponseWriter, r *http.Request) {
+	n := h.gateway.ClearCommandQueue()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "cleared",
+		"dropped": n,
+	})
+}
+
+// writeQueued answers a node command that was queued while the KLF-200 is disconnected
+func writeQueued(w http.ResponseWriter, nodeID uint8) {
+	writeJSON(w, http.StatusAccepted, CommandResponse{
+		Success: true,
+		Message: "Accepted, queued until the KLF-200 is connected",
+		NodeID:  nodeID,
+	})
+}
+
+// writeLoxoneQueued answers a Loxone command that was queued while the KLF-200 is disconnected
+func writeLoxoneQueued(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("QUEUED"))
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// SceneRequest is the request body for recording or renaming a scene
+type SceneRequest struct {
+	Name string `json:"name"`
+}
+
+// ListScenes returns the scenes stored in the KLF-200
+func (h *Handlers) ListScenes(w http.ResponseWriter, r *http.Request) {
+	scenes, err := h.gateway.GetScenes(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read scenes", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"scenes":    scenes,
+		"count":     len(scenes),
+		"recording": h.gateway.GetSceneRecording(),
+	})
+}
+
+// StartSceneRecording starts the guided recording of a new scene:
+// move the devices into position once the recording state is reached, then save.
+func (h *Handlers) StartSceneRecording(w http.ResponseWriter, r *http.Request) {
+	var req SceneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if len(req.Name) > klf200.MaxSceneNameLength {
+		writeError(w, http.StatusBadRequest, "Name too long",
+			fmt.Sprintf("name must be at most %d bytes", klf200.MaxSceneNameLength))
+		return
+	}
+
+	job, err := h.gateway.StartSceneRecording(r.Context(), req.Name)
+	if err != nil {
+		writeSceneError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// GetSceneRecording returns the current or last scene recording
+func (h *Handlers) GetSceneRecording(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"recording": h.gateway.GetSceneRecording(),
+	})
+}
+
+// SaveSceneRecording stores the devices moved since the start as a new scene.
+// The scene ID is reported with the scene_recording_completed event.
+func (h *Handlers) SaveSceneRecording(w http.ResponseWriter, r *http.Request) {
+	var req SceneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if len(req.Name) > klf200.MaxSceneNameLength {
+		writeError(w, http.StatusBadRequest, "Name too long",
+			fmt.Sprintf("name must be at most %d bytes", klf200.MaxSceneNameLength))
+		return
+	}
+
+	job, err := h.gateway.SaveSceneRecording(r.Context(), req.Name)
+	if err != nil {
+		writeSceneError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// CancelSceneRecording cancels the open scene recording
+func (h *Handlers) CancelSceneRecording(w http.ResponseWriter, r *http.Request) {
+	if err := h.gateway.CancelSceneRecording(r.Context()); err != nil {
+		writeSceneError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Scene recording cancelled",
+	})
+}
+
+// RenameScene changes the name of a scene
+func (h *Handlers) RenameScene(w http.ResponseWriter, r *http.Request) {
+	sceneID, err := parseSceneID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid scene ID", err.Error())
+		return
+	}
+
+	var req SceneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if req.Name == "" || len(req.Name) > klf200.MaxSceneNameLength {
+		writeError(w, http.StatusBadRequest, "Invalid name",
+			fmt.Sprintf("name must be 1-%d bytes", klf200.MaxSceneNameLength))
+		return
+	}
+
+	if err := h.gateway.RenameScene(r.Context(), sceneID, req.Name); err != nil {
+		writeSceneError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, klf200.Scene{ID: sceneID, Name: req.Name})
+}
+
+// DeleteScene deletes a scene from the KLF-200
+func (h *Handlers) DeleteScene(w http.ResponseWriter, r *http.Request) {
+	sceneID, err := parseSceneID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid scene ID", err.Error())
+		return
+	}
+
+	if err := h.gateway.DeleteScene(r.Context(), sceneID); err != nil {
+		writeSceneError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"scene_id": sceneID,
+	})
+}
+
+// writeSceneError maps scene errors to HTTP status codes
+func writeSceneError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, klf200.ErrSceneNotFound):
+		writeError(w, http.StatusNotFound, "Scene not found", "")
+	case errors.Is(err, gateway.ErrSceneRecordingBusy), errors.Is(err, gateway.ErrNoSceneRecording):
+		writeError(w, http.StatusConflict, "Scene recording", err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "Scene operation failed", err.Error())
+	}
+}
+
+// parseSceneID extracts the scene ID from the URL
+func parseSceneID(r *http.Request) (uint8, error) {
+	sceneID, err := strconv.ParseUint(chi.URLParam(r, "sceneID"), 10, 8)
+	if err != nil {
+		return 0, err
+	}
+	return uint8(sceneID), nil
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/scheduler"
+)
+
+// ScheduleResponse is a schedule with its computed next execution time
+type ScheduleResponse struct {
+	config.Schedule
+	NextRun *time.Time `json:"next_run"`
+}
+
+func (h *Handlers) scheduleResponse(schedule config.Schedule) ScheduleResponse {
+	resp := ScheduleResponse{Schedule: schedule}
+	if next, ok := h.scheduler.NextRun(schedule.ID); ok {
+		resp.NextRun = &next
+	}
+	return resp
+}
+
+// ListSchedules returns all schedules with their next execution time
+func (h *Handlers) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	cfg := h.configMgr.GetConfig()
+
+	schedules := make([]ScheduleResponse, 0, len(cfg.Schedules))
+	for _, schedule := range cfg.Schedules {
+		schedules = append(schedules, h.scheduleResponse(schedule))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"schedules": schedules,
+		"count":     len(schedules),
+	})
+}
+
+// GetSchedule returns a single schedule
+func (h *Handlers) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	scheduleID := chi.URLParam(r, "scheduleID")
+
+	for _, schedule := range h.configMgr.GetConfig().Schedules {
+		if schedule.ID == scheduleID {
+			writeJSON(w, http.StatusOK, h.scheduleResponse(schedule))
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "Schedule not found", "")
+}
+
+// CreateSchedule creates a new schedule
+func (h *Handlers) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var schedule config.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	// Work on a copy, the config is only replaced once it is saved
+	cfg := *h.configMgr.GetConfig()
+	if err := scheduler.Validate(schedule, cfg.Location); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid schedule", err.Error())
+		return
+	}
+
+	schedule.ID = generateUUID()
+
+	cfg.Schedules = append(append([]config.Schedule(nil), cfg.Schedules...), schedule)
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to save schedule", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, h.scheduleResponse(schedule))
+}
+
+// UpdateSchedule replaces an existing schedule
+func (h *Handlers) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	scheduleID := chi.URLParam(r, "scheduleID")
+
+	var update config.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	cfg := *h.configMgr.GetConfig()
+	if err := scheduler.Validate(update, cfg.Location); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid schedule", err.Error())
+		return
+	}
+
+	cfg.Schedules = append([]config.Schedule(nil), cfg.Schedules...)
+
+	found := false
+	for i, s := range cfg.Schedules {
+		if s.ID == scheduleID {
+			update.ID = scheduleID
+			cfg.Schedules[i] = update
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		writeError(w, http.StatusNotFound, "Schedule not found", "")
+		return
+	}
+
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to save schedule", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.scheduleResponse(update))
+}
+
+// DeleteSchedule deletes a schedule
+func (h *Handlers) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	scheduleID := chi.URLParam(r, "scheduleID")
+
+	cfg := *h.configMgr.GetConfig()
+	newSchedules := make([]config.Schedule, 0, len(cfg.Schedules))
+	found := false
+
+	for _, s := range cfg.Schedules {
+		if s.ID == scheduleID {
+			found = true
+			continue
+		}
+		newSchedules = append(newSchedules, s)
+	}
+
+	if !found {
+		writeError(w, http.StatusNotFound, "Schedule not found", "")
+		return
+	}
+
+	cfg.Schedules = newSchedules
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to delete schedule", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// EnableSchedule enables a schedule
+func (h *Handlers) EnableSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setScheduleEnabled(w, r, true)
+}
+
+// DisableSchedule disables a schedule
+func (h *Handlers) DisableSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setScheduleEnabled(w, r, false)
+}
+
+func (h *Handlers) setScheduleEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	scheduleID := chi.URLParam(r, "scheduleID")
+
+	cfg := *h.configMgr.GetConfig()
+	cfg.Schedules = append([]config.Schedule(nil), cfg.Schedules...)
+	var schedule *config.Schedule
+	for i := range cfg.Schedules {
+		if cfg.Schedules[i].ID == scheduleID {
+			schedule = &cfg.Schedules[i]
+			break
+		}
+	}
+
+	if schedule == nil {
+		writeError(w, http.StatusNotFound, "Schedule not found", "")
+		return
+	}
+
+	schedule.Enabled = enabled
+	if err := h.configMgr.UpdateConfig(&cfg); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to save schedule", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.scheduleResponse(*schedule))
+}
+
+// GetScheduleLog returns the most recent schedule executions
+func (h *Handlers) GetScheduleLog(w http.ResponseWriter, r *http.Request) {
+	log := h.scheduler.RunLog()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"entries": log,
+		"count":   len(log),
+	})
+}
+
+// GetSunInfo returns the current sun position and today's sunrise/sunset times
+func (h *Handlers) GetSunInfo(w http.ResponseWriter, r *http.Request) {
+	info, ok := h.scheduler.SunInfo()
+	if !ok {
+		writeError(w, http.StatusServiceUnavailable, "Location not configured", "Set location.latitude and location.longitude")
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
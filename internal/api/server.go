@@ -16,12 +16,14 @@ import (
 
 	"github.com/stefanbeyeler/loxone2velux/internal/config"
 	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+	"github.com/stefanbeyeler/loxone2velux/internal/scheduler"
 )
 
 // Server represents the HTTP API server
 type Server struct {
 	cfg       *config.ServerConfig
 	gateway   *gateway.Service
+	scheduler *scheduler.Scheduler
 	logger    zerolog.Logger
 	server    *http.Server
 	configMgr ConfigManager
@@ -29,10 +31,11 @@ type Server struct {
 }
 
 // NewServer creates a new API server
-func NewServer(cfg *config.ServerConfig, gw *gateway.Service, logger zerolog.Logger, configMgr ConfigManager, version string) *Server {
+func NewServer(cfg *config.ServerConfig, gw *gateway.Service, sched *scheduler.Scheduler, logger zerolog.Logger, configMgr ConfigManager, version string) *Server {
 	return &Server{
 		cfg:       cfg,
 		gateway:   gw,
+		scheduler: sched,
 		logger:    logger.With().Str("component", "api").Logger(),
 		configMgr: configMgr,
 		version:   version,
@@ -91,10 +94,11 @@ func (s *Server) setupRoutes() *chi.Mux {
 	})
 	r.Use(NewLoggingMiddleware(s.logger))
 	r.Use(chimiddleware.Recoverer)
-	r.Use(chimiddleware.Timeout(30 * time.Second))
+	// Long-lived streams must not be cut off by the request timeout
+	r.Use(NewTimeoutMiddleware(30*time.Second, "/api/events/ws"))
 
 	// Handlers
-	h := NewHandlers(s.gateway, s.logger, s.configMgr, s.version)
+	h := NewHandlers(s.gateway, s.scheduler, s.logger, s.configMgr, s.version)
 
 	// Public routes (no auth required)
 	r.Get("/health", h.Health)
@@ -109,22 +113,51 @@ func (s *Server) setupRoutes() *chi.Mux {
 		}
 	})
 
-	// API routes - protected only if token is configured
+	// Admin routes - always protected by admin_token, disabled without it
+	adminToken := s.cfg.AdminToken
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Use(NewAdminAuthMiddleware(adminToken, s.logger))
+		// Pairing of io-homecontrol products (configuration service)
+		r.Route("/pairing", func(r chi.Router) {
+			r.Get("/", h.GetPairingStatus)
+			r.Get("/system-table", h.GetSystemTable)
+			r.Post("/discover", h.StartDiscovery)
+			r.Post("/remove", h.RemoveNodes)
+			r.Post("/controller-copy", h.StartControllerCopy)
+		})
+	})
+
+	// API routes - protected only if token is configured (the admin token is accepted too)
 	r.Route("/api", func(r chi.Router) {
 		if s.cfg.APIToken != "" {
-			r.Use(NewTokenAuthMiddleware(s.cfg.APIToken, s.logger))
+			r.Use(NewTokenAuthMiddleware(s.cfg.APIToken, s.logger, s.cfg.AdminToken))
 		}
+		r.Use(NewManualSourceMiddleware(adminToken))
 		r.Route("/nodes", func(r chi.Router) {
 			r.Get("/", h.ListNodes)
 			r.Get("/{nodeID}", h.GetNode)
+			r.Patch("/{nodeID}", h.UpdateNode)
+			r.Get("/{nodeID}/history", h.GetNodeHistory)
 			r.Post("/{nodeID}/position", h.SetPosition)
 			r.Post("/{nodeID}/open", h.OpenNode)
 			r.Post("/{nodeID}/close", h.CloseNode)
 			r.Post("/{nodeID}/stop", h.StopNode)
+			r.Post("/{nodeID}/refresh", h.RefreshNode)
+			r.Post("/{nodeID}/wink", h.WinkNode)
+			r.Get("/{nodeID}/limitation", h.GetLimitation)
+			r.Post("/{nodeID}/limitation", h.SetLimitation)
+			r.Delete("/{nodeID}/limitation", h.ClearLimitation)
+			// Locks keep everyone but admins from moving a node
+			r.Group(func(r chi.Router) {
+				r.Use(NewAdminAuthMiddleware(adminToken, s.logger))
+				r.Post("/{nodeID}/lock", h.LockNode)
+				r.Delete("/{nodeID}/lock", h.UnlockNode)
+			})
 		})
 		r.Route("/sensors", func(r chi.Router) {
 			r.Get("/", h.GetSensorStatus)
 			r.Post("/refresh", h.RefreshSensorStatus)
+			r.Get("/history", h.GetSensorHistory)
 		})
 		// Mapping endpoints
 		r.Route("/mappings", func(r chi.Router) {
@@ -133,12 +166,94 @@ func (s *Server) setupRoutes() *chi.Mux {
 			r.Put("/{mappingID}", h.UpdateMapping)
 			r.Delete("/{mappingID}", h.DeleteMapping)
 		})
+		// Schedule endpoints
+		r.Route("/schedules", func(r chi.Router) {
+			r.Get("/", h.ListSchedules)
+			r.Post("/", h.CreateSchedule)
+			r.Get("/log", h.GetScheduleLog)
+			r.Get("/{scheduleID}", h.GetSchedule)
+			r.Put("/{scheduleID}", h.UpdateSchedule)
+			r.Delete("/{scheduleID}", h.DeleteSchedule)
+			r.Post("/{scheduleID}/enable", h.EnableSchedule)
+			r.Post("/{scheduleID}/disable", h.DisableSchedule)
+		})
+		// Scenes stored in the KLF-200 and the guided recording of new ones
+		r.Route("/scenes", func(r chi.Router) {
+			r.Get("/", h.ListScenes)
+			r.Get("/recording", h.GetSceneRecording)
+			// Changing the scenes of the KLF-200 - admin only
+			r.Group(func(r chi.Router) {
+				r.Use(NewAdminAuthMiddleware(adminToken, s.logger))
+				r.Post("/", h.StartSceneRecording)
+				r.Post("/recording", h.SaveSceneRecording)
+				r.Delete("/recording", h.CancelSceneRecording)
+				r.Patch("/{sceneID}", h.RenameScene)
+				r.Delete("/{sceneID}", h.DeleteScene)
+			})
+		})
+		// Gateway-defined presets (named node positions)
+		r.Route("/presets", func(r chi.Router) {
+			r.Get("/", h.ListPresets)
+			r.Post("/", h.CreatePreset)
+			r.Post("/capture", h.CapturePreset)
+			r.Get("/{name}", h.GetPreset)
+			r.Put("/{name}", h.UpdatePreset)
+			r.Delete("/{name}", h.DeletePreset)
+			r.Post("/{name}/activate", h.ActivatePreset)
+		})
+		// Maintenance mode: only manual commands of an admin move the nodes
+		r.Route("/maintenance", func(r chi.Router) {
+			r.Get("/", h.ListMaintenance)
+			r.Group(func(r chi.Router) {
+				r.Use(NewAdminAuthMiddleware(adminToken, s.logger))
+				r.Put("/", h.StartMaintenance)
+				r.Delete("/", h.EndMaintenance)
+				r.Put("/{nodeID}", h.StartMaintenance)
+				r.Delete("/{nodeID}", h.EndMaintenance)
+			})
+		})
+		// Commands queued while the KLF-200 is disconnected
+		r.Get("/queue", h.ListCommandQueue)
+		r.Delete("/queue", h.ClearCommandQueue)
+		// Gateway events (node changes, ...)
+		r.Get("/events", h.ListEvents)
+		r.Get("/events/ws", h.StreamEvents)
+		r.Get("/sun", h.GetSunInfo)
+		r.Get("/protection", h.GetProtectionStatus)
+		// Interlock rules between nodes
+		r.Route("/interlocks", func(r chi.Router) {
+			r.Get("/", h.ListInterlocks)
+			r.Post("/", h.CreateInterlock)
+			r.Put("/{ruleID}", h.UpdateInterlock)
+			r.Delete("/{ruleID}", h.DeleteInterlock)
+		})
 		// Loxone integration config
 		r.Route("/loxone", func(r chi.Router) {
 			r.Get("/config", h.GetLoxoneConfig)
 			r.Put("/config/udp", h.UpdateLoxoneUDPConfig)
 			r.Post("/config/udp/test", h.TestUDP)
 		})
+		// KLF-200 device data
+		r.Route("/klf200", func(r chi.Router) {
+			r.Get("/activation-log", h.GetActivationLog)
+			// Actions triggered by the contact inputs of the KLF-200
+			r.Get("/contact-inputs", h.ListContactInputs)
+			// Contact input links, network setup, reboot and password - admin only
+			r.Group(func(r chi.Router) {
+				r.Use(NewAdminAuthMiddleware(adminToken, s.logger))
+				r.Put("/contact-inputs/{inputID}", h.SetContactInput)
+				r.Delete("/contact-inputs/{inputID}", h.RemoveContactInput)
+				r.Get("/network", h.GetNetworkSetup)
+				r.Put("/network", h.SetNetworkSetup)
+				r.Post("/reboot", h.RebootKLF200)
+				r.Post("/password", h.ChangeKLF200Password)
+			})
+		})
+		// KLF-200 system information
+		r.Route("/system", func(r chi.Router) {
+			r.Get("/klf200", h.GetKLF200System)
+			r.Post("/klf200/clock", h.SyncKLF200Clock)
+		})
 		// Configuration endpoints
 		r.Get("/config", h.GetConfig)
 		r.Post("/config", h.UpdateConfig)
@@ -151,14 +266,18 @@ func (s *Server) setupRoutes() *chi.Mux {
 		if s.cfg.APIToken != "" {
 			r.Use(NewTokenAuthMiddleware(s.cfg.APIToken, s.logger))
 		}
+		r.Use(NewCommandSourceMiddleware(gateway.SourceLoxone))
 		r.Get("/node/{nodeID}/position", h.LoxoneGetPosition)
 		r.Get("/node/{nodeID}/set/{position}", h.LoxoneSetPosition)
 		r.Get("/node/{nodeID}/open", h.LoxoneOpen)
 		r.Get("/node/{nodeID}/close", h.LoxoneClose)
 		r.Get("/node/{nodeID}/stop", h.LoxoneStop)
+		r.Get("/node/{nodeID}/wink", h.LoxoneWink)
+		r.Get("/preset/{name}", h.LoxoneActivatePreset)
 		r.Get("/sensors", h.LoxoneSensorStatus)
 		r.Get("/sensors/rain", h.LoxoneRainStatus)
 		r.Get("/sensors/wind", h.LoxoneWindStatus)
+		r.Get("/sensors/node/{nodeID}", h.LoxoneNodeSensorStatus)
 	})
 
 	// Static files for web frontend
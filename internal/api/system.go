@@ -0,0 +1,32 @@
+package api
+
+import "net/http"
+
+// GetKLF200System returns version, state and clock information of the KLF-200.
+// The information is read again if connected, otherwise the last known values are returned.
+func (h *Handlers) GetKLF200System(w http.ResponseWriter, r *http.Request) {
+	info := h.gateway.GetSystemInfo()
+	if h.gateway.IsConnected() {
+		refreshed, err := h.gateway.RefreshSystemInfo(r.Context())
+		if err != nil {
+			h.logger.Warn().Err(err).Msg("Failed to refresh KLF-200 system information")
+		} else {
+			info = refreshed
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"connected": h.gateway.IsConnected(),
+		"klf200":    info,
+	})
+}
+
+// SyncKLF200Clock sets the KLF-200 clock to the current time
+func (h *Handlers) SyncKLF200Clock(w http.ResponseWriter, r *http.Request) {
+	if err := h.gateway.SyncClock(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to sync clock", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.gateway.GetSystemInfo())
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// WinkRequest is the optional request body for a wink
+type WinkRequest struct {
+	Seconds uint8 `json:"seconds"` // 1-253, 0 or omitted uses the manufacturer default
+}
+
+// WinkNode makes a node give its identification signal. The result is reported as event.
+func (h *Handlers) WinkNode(w http.ResponseWriter, r *http.Request) {
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid node ID", err.Error())
+		return
+	}
+
+	var req WinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if req.Seconds > klf200.WinkTimeMaxSeconds {
+		writeError(w, http.StatusBadRequest, "Invalid wink time", "seconds must be 1-253")
+		return
+	}
+
+	if err := h.gateway.Wink(r.Context(), nodeID, req.Seconds); err != nil {
+		if errors.Is(err, klf200.ErrNodeNotFound) {
+			writeError(w, http.StatusNotFound, "Node not found", "")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to wink node", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, CommandResponse{
+		Success: true,
+		Message: "Wink started",
+		NodeID:  nodeID,
+	})
+}
+
+// LoxoneWink makes a node give its identification signal (Loxone-friendly)
+func (h *Handlers) LoxoneWink(w http.ResponseWriter, r *http.Request) {
+	nodeID, err := parseNodeID(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("ERROR"))
+		return
+	}
+
+	if err := h.gateway.Wink(r.Context(), nodeID, 0); err != nil {
+		h.logger.Error().Err(err).Uint8("node", nodeID).Msg("Failed to wink")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("ERROR"))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
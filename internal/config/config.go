@@ -1,8 +1,10 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -10,10 +12,19 @@ import (
 
 // Config holds the application configuration
 type Config struct {
-	KLF200  KLF200Config  `yaml:"klf200"`
-	Server  ServerConfig  `yaml:"server"`
-	Loxone  LoxoneConfig  `yaml:"loxone"`
-	Logging LoggingConfig `yaml:"logging"`
+	KLF200        KLF200Config        `yaml:"klf200"`
+	Server        ServerConfig        `yaml:"server"`
+	Loxone        LoxoneConfig        `yaml:"loxone"`
+	Storage       StorageConfig       `yaml:"storage"`
+	Location      LocationConfig      `yaml:"location"`
+	Schedules     []Schedule          `yaml:"schedules"`
+	SunProtection SunProtectionConfig `yaml:"sun_protection"`
+	Protection    ProtectionConfig    `yaml:"protection"`
+	Interlocks    InterlockConfig     `yaml:"interlocks"`
+	Presets       []Preset            `yaml:"presets"`
+	CommandQueue  CommandQueueConfig  `yaml:"command_queue"`
+	Debounce      DebounceConfig      `yaml:"command_debounce"`
+	Logging       LoggingConfig       `yaml:"logging"`
 }
 
 // KLF200Config holds KLF-200 connection settings
@@ -23,6 +34,21 @@ type KLF200Config struct {
 	Password          string        `yaml:"password"`
 	ReconnectInterval time.Duration `yaml:"reconnect_interval"`
 	RefreshInterval   time.Duration `yaml:"refresh_interval"`
+
+	// Optional file holding the host. It overrides host when present and receives a static
+	// address set through the network setup API, for setups that regenerate this config file.
+	HostFile string `yaml:"host_file"`
+
+	// Optional file holding the password. It overrides password when present and receives
+	// a password changed through the API, for setups that regenerate this config file.
+	PasswordFile string `yaml:"password_file"`
+
+	// Status polling for drift correction (0 = disabled)
+	StatusPollInterval       time.Duration `yaml:"status_poll_interval"`
+	StatusPollMovingInterval time.Duration `yaml:"status_poll_moving_interval"` // Faster polling for moving nodes
+
+	// Time zone of the KLF-200 clock in KLF-200 format, set after each connect (empty = UTC only)
+	TimeZone string `yaml:"time_zone"`
 }
 
 // ServerConfig holds HTTP server settings
@@ -32,6 +58,14 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
 	APIToken     string        `yaml:"api_token"`
+	AdminToken   string        `yaml:"admin_token"` // Required for /api/admin, admin endpoints are disabled without it
+}
+
+// StorageConfig holds settings for the persisted node state and history database
+type StorageConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Path      string        `yaml:"path"`      // Relative to the directory of the config file
+	Retention time.Duration `yaml:"retention"` // History older than this is pruned
 }
 
 // LoggingConfig holds logging settings
@@ -60,6 +94,299 @@ type NodeMapping struct {
 	NodeID   uint8  `yaml:"node_id" json:"node_id"`
 	LoxoneID string `yaml:"loxone_id" json:"loxone_id"`
 	Enabled  bool   `yaml:"enabled" json:"enabled"`
+
+	// Facade orientation in degrees clockwise from north (e.g. 180 = south), used by sun protection
+	FacadeAzimuth *float64 `yaml:"facade_azimuth,omitempty" json:"facade_azimuth,omitempty"`
+
+	// Position limits (0 = open, 100 = closed), position requests are clamped to this range
+	MinPosition *float64 `yaml:"min_position,omitempty" json:"min_position,omitempty"`
+	MaxPosition *float64 `yaml:"max_position,omitempty" json:"max_position,omitempty"`
+
+	// Calibration curve between displayed and device positions.
+	// Missing end points default to 0 -> 0 and 100 -> 100.
+	Calibration []CalibrationPoint `yaml:"calibration,omitempty" json:"calibration,omitempty"`
+}
+
+// CalibrationPoint maps a displayed position to the corresponding device position
+type CalibrationPoint struct {
+	Position float64 `yaml:"position" json:"position"` // Position shown in the API and sent to Loxone
+	Device   float64 `yaml:"device" json:"device"`     // Position reported/driven by the KLF-200
+}
+
+// Validate checks the position limits and calibration of a mapping
+func (m *NodeMapping) Validate() error {
+	if m.MinPosition != nil && (*m.MinPosition < 0 || *m.MinPosition > 100) {
+		return fmt.Errorf("min_position must be between 0 and 100")
+	}
+	if m.MaxPosition != nil && (*m.MaxPosition < 0 || *m.MaxPosition > 100) {
+		return fmt.Errorf("max_position must be between 0 and 100")
+	}
+	if m.MinPosition != nil && m.MaxPosition != nil && *m.MinPosition > *m.MaxPosition {
+		return fmt.Errorf("min_position must not be greater than max_position")
+	}
+	for i, p := range m.Calibration {
+		if p.Position < 0 || p.Position > 100 || p.Device < 0 || p.Device > 100 {
+			return fmt.Errorf("calibration[%d]: position and device must be between 0 and 100", i)
+		}
+		if i > 0 && (p.Position <= m.Calibration[i-1].Position || p.Device <= m.Calibration[i-1].Device) {
+			return fmt.Errorf("calibration[%d]: points must be strictly increasing", i)
+		}
+	}
+	return nil
+}
+
+// LocationConfig holds the installation location used for sunrise/sunset calculation
+type LocationConfig struct {
+	Latitude  float64 `yaml:"latitude" json:"latitude"`
+	Longitude float64 `yaml:"longitude" json:"longitude"`
+}
+
+// IsSet returns true if a location has been configured
+func (l LocationConfig) IsSet() bool {
+	return l.Latitude != 0 || l.Longitude != 0
+}
+
+// SunProtectionConfig closes blinds while the sun shines on their facade.
+// Applies to mappings with a facade_azimuth.
+type SunProtectionConfig struct {
+	Enabled          bool     `yaml:"enabled" json:"enabled"`
+	MinElevation     float64  `yaml:"min_elevation" json:"min_elevation"`         // Degrees above the horizon
+	AzimuthTolerance float64  `yaml:"azimuth_tolerance" json:"azimuth_tolerance"` // Max. degrees between sun and facade direction
+	Position         float64  `yaml:"position" json:"position"`                   // Position while the sun is on the facade
+	ReleasePosition  *float64 `yaml:"release_position,omitempty" json:"release_position,omitempty"`
+	MappingIDs       []string `yaml:"mapping_ids,omitempty" json:"mapping_ids,omitempty"` // Empty = all mappings with a facade
+}
+
+// Duration is a time.Duration written as a string ("30m0s") in YAML and JSON.
+// JSON accepts a string or a number of nanoseconds.
+type Duration time.Duration
+
+// String formats the duration like time.Duration
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var v time.Duration
+	if err := value.Decode(&v); err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		*d = Duration(n)
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ProtectionConfig holds the automatic rain and wind protection policy
+type ProtectionConfig struct {
+	Rain ProtectionRuleConfig `yaml:"rain" json:"rain"`
+	Wind ProtectionRuleConfig `yaml:"wind" json:"wind"`
+}
+
+// ProtectionRuleConfig defines what happens when a sensor condition is detected
+type ProtectionRuleConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Nodes to move. Empty = all window openers (rain) or all awnings (wind).
+	NodeIDs  []uint8 `yaml:"node_ids,omitempty" json:"node_ids,omitempty"`
+	Position float64 `yaml:"position" json:"position"` // Protective position (0 = open, 100 = closed)
+	// Restore the previous positions once the condition has been clear for RestoreAfter
+	Restore      bool     `yaml:"restore" json:"restore"`
+	RestoreAfter Duration `yaml:"restore_after" json:"restore_after"`
+}
+
+// Interlock directions
+const (
+	InterlockDirectionOpen  = "open"
+	InterlockDirectionClose = "close"
+	InterlockDirectionAny   = "any"
+)
+
+// InterlockConfig holds the interlock rules between nodes
+type InterlockConfig struct {
+	Rules []InterlockRule `yaml:"rules" json:"rules"`
+	// Max. time to wait for the required nodes when sequencing
+	SequenceTimeout Duration `yaml:"sequence_timeout" json:"sequence_timeout"`
+	// Position tolerance in percent when checking the required node
+	Tolerance float64 `yaml:"tolerance" json:"tolerance"`
+}
+
+// CommandQueueConfig holds the queue for node commands issued while the KLF-200 is disconnected
+type CommandQueueConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Max. number of queued commands (one per node, the last command wins)
+	Size int `yaml:"size" json:"size"`
+	// Commands older than this are dropped instead of replayed
+	TTL Duration `yaml:"ttl" json:"ttl"`
+}
+
+// DebounceConfig holds the coalescing of rapid position commands per node, e.g. from Loxone sliders
+type DebounceConfig struct {
+	// Quiet time after the last command before the target is sent (0 = send immediately)
+	Window Duration `yaml:"window" json:"window"`
+	// Max. delay of the first command of a burst, so continuous updates still move the node (0 = no limit)
+	MaxDelay Duration `yaml:"max_delay" json:"max_delay"`
+}
+
+// InterlockRule restricts the movement of a node depending on the position of another node,
+// e.g. "node 4 may only open while node 5 is at most 10% closed".
+type InterlockRule struct {
+	ID        string `yaml:"id" json:"id"`
+	Name      string `yaml:"name" json:"name"`
+	Enabled   bool   `yaml:"enabled" json:"enabled"`
+	NodeID    uint8  `yaml:"node_id" json:"node_id"`     // Node being commanded
+	Direction string `yaml:"direction" json:"direction"` // open, close or any
+
+	RequireNodeID uint8    `yaml:"require_node_id" json:"require_node_id"`
+	RequireMin    *float64 `yaml:"require_min,omitempty" json:"require_min,omitempty"` // Required node must be at least this closed
+	RequireMax    *float64 `yaml:"require_max,omitempty" json:"require_max,omitempty"` // Required node must be at most this closed
+
+	// Move the required node into range first instead of rejecting the command
+	Sequence bool `yaml:"sequence" json:"sequence"`
+}
+
+// Validate checks an interlock rule
+func (r *InterlockRule) Validate() error {
+	switch r.Direction {
+	case InterlockDirectionOpen, InterlockDirectionClose, InterlockDirectionAny:
+	default:
+		return fmt.Errorf("direction must be one of open, close, any")
+	}
+	if r.NodeID == r.RequireNodeID {
+		return fmt.Errorf("require_node_id must differ from node_id")
+	}
+	if r.RequireMin == nil && r.RequireMax == nil {
+		return fmt.Errorf("at least one of require_min or require_max is required")
+	}
+	if r.RequireMin != nil && (*r.RequireMin < 0 || *r.RequireMin > 100) {
+		return fmt.Errorf("require_min must be between 0 and 100")
+	}
+	if r.RequireMax != nil && (*r.RequireMax < 0 || *r.RequireMax > 100) {
+		return fmt.Errorf("require_max must be between 0 and 100")
+	}
+	if r.RequireMin != nil && r.RequireMax != nil && *r.RequireMin > *r.RequireMax {
+		return fmt.Errorf("require_min must not be greater than require_max")
+	}
+	return nil
+}
+
+// Preset is a gateway-defined scene: named node targets executed by the gateway.
+// Targets with the same delay, position and tilt are sent as one command.
+type Preset struct {
+	Name    string         `yaml:"name" json:"name"`
+	Targets []PresetTarget `yaml:"targets" json:"targets"` // Executed in this order
+}
+
+// PresetTarget is the position of a single node within a preset
+type PresetTarget struct {
+	NodeID       uint8    `yaml:"node_id" json:"node_id"`
+	Position     float64  `yaml:"position" json:"position"`                               // 0 = open, 100 = closed
+	Tilt         *float64 `yaml:"tilt,omitempty" json:"tilt,omitempty"`                   // Slat orientation 0-100, venetian and louver blinds only
+	DelaySeconds int      `yaml:"delay_seconds,omitempty" json:"delay_seconds,omitempty"` // Delay after activation
+}
+
+// Validate checks the name and targets of a preset
+func (p *Preset) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(p.Targets) == 0 {
+		return fmt.Errorf("at least one target is required")
+	}
+	seen := make(map[uint8]bool)
+	for i, t := range p.Targets {
+		if seen[t.NodeID] {
+			return fmt.Errorf("targets[%d]: node %d is used more than once", i, t.NodeID)
+		}
+		seen[t.NodeID] = true
+		if t.Position < 0 || t.Position > 100 {
+			return fmt.Errorf("targets[%d]: position must be between 0 and 100", i)
+		}
+		if t.Tilt != nil && (*t.Tilt < 0 || *t.Tilt > 100) {
+			return fmt.Errorf("targets[%d]: tilt must be between 0 and 100", i)
+		}
+		if t.DelaySeconds < 0 || t.DelaySeconds > 3600 {
+			return fmt.Errorf("targets[%d]: delay_seconds must be between 0 and 3600", i)
+		}
+	}
+	return nil
+}
+
+// Schedule actions
+const (
+	ScheduleActionPosition = "position"
+	ScheduleActionOpen     = "open"
+	ScheduleActionClose    = "close"
+	ScheduleActionStop     = "stop"
+)
+
+// Schedule is a timed action executed by the gateway.
+// The trigger is either a cron expression or a time of day with optional weekdays.
+type Schedule struct {
+	ID       string   `yaml:"id" json:"id"`
+	Name     string   `yaml:"name" json:"name"`
+	Enabled  bool     `yaml:"enabled" json:"enabled"`
+	Cron     string   `yaml:"cron,omitempty" json:"cron,omitempty"`         // e.g. "0 22 * * *"
+	Time     string   `yaml:"time,omitempty" json:"time,omitempty"`         // "HH:MM", used when cron and sun are empty
+	Weekdays []string `yaml:"weekdays,omitempty" json:"weekdays,omitempty"` // "mon".."sun", empty = every day
+
+	// Astronomical trigger: sunrise, sunset, civil_dawn or civil_dusk (requires location)
+	Sun           string `yaml:"sun,omitempty" json:"sun,omitempty"`
+	OffsetMinutes int    `yaml:"offset_minutes,omitempty" json:"offset_minutes,omitempty"`
+	Earliest      string `yaml:"earliest,omitempty" json:"earliest,omitempty"` // "HH:MM", never run before
+	Latest        string `yaml:"latest,omitempty" json:"latest,omitempty"`     // "HH:MM", never run after
+
+	Action     string   `yaml:"action" json:"action"` // position, open, close, stop
+	Position   float64  `yaml:"position,omitempty" json:"position,omitempty"`
+	NodeIDs    []uint8  `yaml:"node_ids,omitempty" json:"node_ids,omitempty"`
+	MappingIDs []string `yaml:"mapping_ids,omitempty" json:"mapping_ids,omitempty"`
+}
+
+// Validate checks the action and targets of a schedule (the trigger is validated by the scheduler)
+func (s *Schedule) Validate() error {
+	switch s.Action {
+	case ScheduleActionPosition:
+		if s.Position < 0 || s.Position > 100 {
+			return fmt.Errorf("position must be between 0 and 100")
+		}
+	case ScheduleActionOpen, ScheduleActionClose, ScheduleActionStop:
+	default:
+		return fmt.Errorf("action must be one of position, open, close, stop")
+	}
+	if len(s.NodeIDs) == 0 && len(s.MappingIDs) == 0 {
+		return fmt.Errorf("at least one node_id or mapping_id is required")
+	}
+	if s.Cron == "" && s.Time == "" && s.Sun == "" {
+		return fmt.Errorf("one of cron, time or sun is required")
+	}
+	return nil
 }
 
 // DefaultConfig returns a config with default values
@@ -71,6 +398,9 @@ func DefaultConfig() *Config {
 			Password:          "",
 			ReconnectInterval: 30 * time.Second,
 			RefreshInterval:   5 * time.Minute,
+
+			StatusPollInterval:       time.Minute,
+			StatusPollMovingInterval: 2 * time.Second,
 		},
 		Server: ServerConfig{
 			Host:         "0.0.0.0",
@@ -87,6 +417,44 @@ func DefaultConfig() *Config {
 			},
 			Mappings: []NodeMapping{},
 		},
+		Schedules: []Schedule{},
+		SunProtection: SunProtectionConfig{
+			Enabled:          false,
+			MinElevation:     10,
+			AzimuthTolerance: 60,
+			Position:         100,
+		},
+		Storage: StorageConfig{
+			Enabled:   false,
+			Path:      "data/loxone2velux.db",
+			Retention: 30 * 24 * time.Hour,
+		},
+		Protection: ProtectionConfig{
+			Rain: ProtectionRuleConfig{
+				Enabled:      false,
+				Position:     100,
+				RestoreAfter: Duration(30 * time.Minute),
+			},
+			Wind: ProtectionRuleConfig{
+				Enabled:      false,
+				Position:     0,
+				RestoreAfter: Duration(30 * time.Minute),
+			},
+		},
+		Interlocks: InterlockConfig{
+			Rules:           []InterlockRule{},
+			SequenceTimeout: Duration(2 * time.Minute),
+			Tolerance:       1,
+		},
+		CommandQueue: CommandQueueConfig{
+			Enabled: false,
+			Size:    50,
+			TTL:     Duration(2 * time.Minute),
+		},
+		Debounce: DebounceConfig{
+			Window:   0, // Disabled, enable for Loxone sliders
+			MaxDelay: Duration(time.Second),
+		},
 		Logging: LoggingConfig{
 			Level:  "info",
 			Format: "console",
@@ -107,6 +475,13 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	if err := cfg.KLF200.loadHostFile(); err != nil {
+		return nil, err
+	}
+	if err := cfg.KLF200.loadPasswordFile(); err != nil {
+		return nil, err
+	}
+
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
@@ -128,6 +503,12 @@ func (c *Config) Validate() error {
 	if c.KLF200.Port <= 0 || c.KLF200.Port > 65535 {
 		return fmt.Errorf("klf200.port must be between 1 and 65535")
 	}
+	if c.KLF200.StatusPollInterval < 0 || c.KLF200.StatusPollMovingInterval < 0 {
+		return fmt.Errorf("klf200 status poll intervals must not be negative")
+	}
+	if len(c.KLF200.TimeZone) > 63 {
+		return fmt.Errorf("klf200.time_zone must be at most 63 characters")
+	}
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("server.port must be between 1 and 65535")
 	}
@@ -135,6 +516,9 @@ func (c *Config) Validate() error {
 	if c.Server.APIToken != "" && len(c.Server.APIToken) < 16 {
 		return fmt.Errorf("server.api_token must be at least 16 characters if set")
 	}
+	if c.Server.AdminToken != "" && len(c.Server.AdminToken) < 16 {
+		return fmt.Errorf("server.admin_token must be at least 16 characters if set")
+	}
 	if c.Loxone.UDPFeedback.Enabled {
 		if c.Loxone.UDPFeedback.IP == "" {
 			return fmt.Errorf("loxone.udp_feedback.ip is required when UDP feedback is enabled")
@@ -143,6 +527,79 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("loxone.udp_feedback.port must be between 1 and 65535")
 		}
 	}
+	for i := range c.Loxone.Mappings {
+		if err := c.Loxone.Mappings[i].Validate(); err != nil {
+			return fmt.Errorf("loxone.mappings[%d] (%s): %w", i, c.Loxone.Mappings[i].Name, err)
+		}
+	}
+	if c.Location.Latitude < -90 || c.Location.Latitude > 90 {
+		return fmt.Errorf("location.latitude must be between -90 and 90")
+	}
+	if c.Location.Longitude < -180 || c.Location.Longitude > 180 {
+		return fmt.Errorf("location.longitude must be between -180 and 180")
+	}
+	if c.SunProtection.Enabled && !c.Location.IsSet() {
+		return fmt.Errorf("location is required when sun protection is enabled")
+	}
+	for name, rule := range map[string]ProtectionRuleConfig{"rain": c.Protection.Rain, "wind": c.Protection.Wind} {
+		if rule.Position < 0 || rule.Position > 100 {
+			return fmt.Errorf("protection.%s.position must be between 0 and 100", name)
+		}
+		if rule.RestoreAfter < 0 {
+			return fmt.Errorf("protection.%s.restore_after must not be negative", name)
+		}
+	}
+	for i := range c.Schedules {
+		if err := c.Schedules[i].Validate(); err != nil {
+			return fmt.Errorf("schedules[%d] (%s): %w", i, c.Schedules[i].Name, err)
+		}
+		if c.Schedules[i].Sun != "" && !c.Location.IsSet() {
+			return fmt.Errorf("schedules[%d] (%s): location is required for sun triggers", i, c.Schedules[i].Name)
+		}
+	}
+	for i := range c.Interlocks.Rules {
+		if err := c.Interlocks.Rules[i].Validate(); err != nil {
+			return fmt.Errorf("interlocks.rules[%d] (%s): %w", i, c.Interlocks.Rules[i].Name, err)
+		}
+	}
+	if c.Interlocks.SequenceTimeout < 0 {
+		return fmt.Errorf("interlocks.sequence_timeout must not be negative")
+	}
+	if c.Interlocks.Tolerance < 0 || c.Interlocks.Tolerance > 100 {
+		return fmt.Errorf("interlocks.tolerance must be between 0 and 100")
+	}
+	if c.CommandQueue.Enabled {
+		if c.CommandQueue.Size < 1 {
+			return fmt.Errorf("command_queue.size must be at least 1")
+		}
+		if c.CommandQueue.TTL <= 0 {
+			return fmt.Errorf("command_queue.ttl must be positive")
+		}
+	}
+	if c.Debounce.Window < 0 || c.Debounce.MaxDelay < 0 {
+		return fmt.Errorf("command_debounce.window and max_delay must not be negative")
+	}
+	if c.Debounce.MaxDelay > 0 && c.Debounce.MaxDelay < c.Debounce.Window {
+		return fmt.Errorf("command_debounce.max_delay must not be shorter than the window")
+	}
+	names := make(map[string]bool)
+	for i := range c.Presets {
+		if err := c.Presets[i].Validate(); err != nil {
+			return fmt.Errorf("presets[%d] (%s): %w", i, c.Presets[i].Name, err)
+		}
+		if names[c.Presets[i].Name] {
+			return fmt.Errorf("presets[%d]: name %q is used more than once", i, c.Presets[i].Name)
+		}
+		names[c.Presets[i].Name] = true
+	}
+	if c.Storage.Enabled {
+		if c.Storage.Path == "" {
+			return fmt.Errorf("storage.path is required when storage is enabled")
+		}
+		if c.Storage.Retention < 0 {
+			return fmt.Errorf("storage.retention must not be negative")
+		}
+	}
 	return nil
 }
 
@@ -164,3 +621,65 @@ func (c *Config) Save(path string) error {
 
 	return nil
 }
+
+// loadHostFile replaces the host with the content of the host file, if it exists
+func (c *KLF200Config) loadHostFile() error {
+	if c.HostFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.HostFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read klf200.host_file: %w", err)
+	}
+	if host := strings.TrimSpace(string(data)); host != "" {
+		c.Host = host
+	}
+	return nil
+}
+
+// SaveHostFile writes the host to the host file (no-op without one)
+func (c *KLF200Config) SaveHostFile() error {
+	if c.HostFile == "" {
+		return nil
+	}
+
+	if err := os.WriteFile(c.HostFile, []byte(c.Host), 0644); err != nil {
+		return fmt.Errorf("failed to write klf200.host_file: %w", err)
+	}
+	return nil
+}
+
+// loadPasswordFile replaces the password with the content of the password file, if it exists
+func (c *KLF200Config) loadPasswordFile() error {
+	if c.PasswordFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.PasswordFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read klf200.password_file: %w", err)
+	}
+	if password := strings.TrimRight(string(data), "\r\n"); password != "" {
+		c.Password = password
+	}
+	return nil
+}
+
+// SavePasswordFile writes the password to the password file (no-op without one)
+func (c *KLF200Config) SavePasswordFile() error {
+	if c.PasswordFile == "" {
+		return nil
+	}
+
+	if err := os.WriteFile(c.PasswordFile, []byte(c.Password), 0600); err != nil {
+		return fmt.Errorf("failed to write klf200.password_file: %w", err)
+	}
+	return nil
+}
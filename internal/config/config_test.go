@@ -0,0 +1,340 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDurationYAML(t *testing.T) {
+	type wrapper struct {
+		Delay Duration `yaml:"delay"`
+	}
+
+	tests := []struct {
+		name    string
+		yaml    string
+		want    Duration
+		wantErr bool
+	}{
+		{name: "string", yaml: "delay: 1m30s", want: Duration(90 * time.Second)},
+		{name: "hours", yaml: "delay: 720h", want: Duration(720 * time.Hour)},
+		{name: "zero", yaml: "delay: 0s", want: 0},
+		{name: "invalid", yaml: "delay: soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got wrapper
+			err := yaml.Unmarshal([]byte(tt.yaml), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got.Delay != tt.want {
+				t.Errorf("got %v, want %v", got.Delay, tt.want)
+			}
+		})
+	}
+
+	// Durations are written as strings and read back unchanged
+	data, err := yaml.Marshal(wrapper{Delay: Duration(90 * time.Second)})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(data) != "delay: 1m30s\n" {
+		t.Errorf("marshaled %q, want %q", data, "delay: 1m30s\n")
+	}
+	var back wrapper
+	if err := yaml.Unmarshal(data, &back); err != nil || back.Delay != Duration(90*time.Second) {
+		t.Errorf("round trip = %v, %v", back.Delay, err)
+	}
+}
+
+func TestDurationJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Duration
+		wantErr bool
+	}{
+		{name: "string", json: `"2m"`, want: Duration(2 * time.Minute)},
+		{name: "nanoseconds", json: `1500000000`, want: Duration(1500 * time.Millisecond)},
+		{name: "zero string", json: `"0s"`, want: 0},
+		{name: "invalid string", json: `"later"`, wantErr: true},
+		{name: "invalid type", json: `true`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Duration
+			err := json.Unmarshal([]byte(tt.json), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	data, err := json.Marshal(struct {
+		Timeout Duration `json:"timeout"`
+	}{Duration(2 * time.Minute)})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(data) != `{"timeout":"2m0s"}` {
+		t.Errorf("marshaled %s, want {\"timeout\":\"2m0s\"}", data)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		errMsg string // Expected part of the error, empty = valid
+	}{
+		{name: "defaults", modify: func(c *Config) {}},
+		{name: "klf200 port", modify: func(c *Config) { c.KLF200.Port = 0 }, errMsg: "klf200.port"},
+		{name: "short api token", modify: func(c *Config) { c.Server.APIToken = "short" }, errMsg: "server.api_token"},
+		{name: "udp feedback without ip", modify: func(c *Config) { c.Loxone.UDPFeedback.Enabled = true }, errMsg: "udp_feedback.ip"},
+		{name: "storage without path", modify: func(c *Config) { c.Storage.Enabled, c.Storage.Path = true, "" }, errMsg: "storage.path"},
+		{name: "negative retention", modify: func(c *Config) { c.Storage.Enabled, c.Storage.Retention = true, -time.Hour }, errMsg: "storage.retention"},
+		{name: "latitude", modify: func(c *Config) { c.Location.Latitude = 91 }, errMsg: "location.latitude"},
+		{name: "sun protection without location", modify: func(c *Config) { c.SunProtection.Enabled = true }, errMsg: "location is required"},
+		{
+			name: "sun schedule without location",
+			modify: func(c *Config) {
+				c.Schedules = []Schedule{{Name: "dusk", Enabled: true, Sun: "sunset", Action: "close", NodeIDs: []uint8{1}}}
+			},
+			errMsg: "location is required for sun triggers",
+		},
+		{name: "protection position", modify: func(c *Config) { c.Protection.Rain.Position = 101 }, errMsg: "protection.rain.position"},
+		{name: "negative restore delay", modify: func(c *Config) { c.Protection.Wind.RestoreAfter = Duration(-time.Second) }, errMsg: "protection.wind.restore_after"},
+		{
+			name: "interlock rule on itself",
+			modify: func(c *Config) {
+				c.Interlocks.Rules = []InterlockRule{{Direction: InterlockDirectionAny, NodeID: 1, RequireNodeID: 1, RequireMax: floatPtr(20)}}
+			},
+			errMsg: "require_node_id must differ",
+		},
+		{
+			name: "interlock rule without range",
+			modify: func(c *Config) {
+				c.Interlocks.Rules = []InterlockRule{{Direction: InterlockDirectionOpen, NodeID: 1, RequireNodeID: 2}}
+			},
+			errMsg: "require_min or require_max",
+		},
+		{
+			name: "interlock rule direction",
+			modify: func(c *Config) {
+				c.Interlocks.Rules = []InterlockRule{{Direction: "up", NodeID: 1, RequireNodeID: 2, RequireMax: floatPtr(20)}}
+			},
+			errMsg: "direction",
+		},
+		{name: "negative sequence timeout", modify: func(c *Config) { c.Interlocks.SequenceTimeout = Duration(-time.Second) }, errMsg: "interlocks.sequence_timeout"},
+		{name: "interlock tolerance", modify: func(c *Config) { c.Interlocks.Tolerance = 101 }, errMsg: "interlocks.tolerance"},
+		{
+			name: "mapping limits reversed",
+			modify: func(c *Config) {
+				c.Loxone.Mappings = []NodeMapping{{Name: "window", MinPosition: floatPtr(60), MaxPosition: floatPtr(40)}}
+			},
+			errMsg: "min_position must not be greater",
+		},
+		{
+			name:   "mapping limit out of range",
+			modify: func(c *Config) { c.Loxone.Mappings = []NodeMapping{{Name: "window", MaxPosition: floatPtr(120)}} },
+			errMsg: "max_position must be between",
+		},
+		{
+			name: "calibration not increasing",
+			modify: func(c *Config) {
+				c.Loxone.Mappings = []NodeMapping{{Name: "blind", Calibration: []CalibrationPoint{{Position: 50, Device: 80}, {Position: 60, Device: 70}}}}
+			},
+			errMsg: "strictly increasing",
+		},
+		{
+			name: "calibration",
+			modify: func(c *Config) {
+				c.Loxone.Mappings = []NodeMapping{{Name: "blind", Calibration: []CalibrationPoint{{Position: 50, Device: 80}, {Position: 60, Device: 85}}}}
+			},
+		},
+		{name: "short admin token", modify: func(c *Config) { c.Server.AdminToken = "short" }, errMsg: "server.admin_token"},
+		{name: "preset without targets", modify: func(c *Config) { c.Presets = []Preset{{Name: "evening"}} }, errMsg: "at least one target"},
+		{
+			name: "preset node twice",
+			modify: func(c *Config) {
+				c.Presets = []Preset{{Name: "evening", Targets: []PresetTarget{{NodeID: 1}, {NodeID: 1}}}}
+			},
+			errMsg: "used more than once",
+		},
+		{
+			name: "preset tilt",
+			modify: func(c *Config) {
+				c.Presets = []Preset{{Name: "evening", Targets: []PresetTarget{{NodeID: 1, Tilt: floatPtr(120)}}}}
+			},
+			errMsg: "tilt must be between",
+		},
+		{
+			name: "preset delay",
+			modify: func(c *Config) {
+				c.Presets = []Preset{{Name: "evening", Targets: []PresetTarget{{NodeID: 1, DelaySeconds: 3601}}}}
+			},
+			errMsg: "delay_seconds",
+		},
+		{
+			name: "duplicate preset names",
+			modify: func(c *Config) {
+				c.Presets = []Preset{{Name: "evening", Targets: []PresetTarget{{NodeID: 1}}}, {Name: "evening", Targets: []PresetTarget{{NodeID: 2}}}}
+			},
+			errMsg: "is used more than once",
+		},
+		{name: "command queue size", modify: func(c *Config) { c.CommandQueue.Enabled, c.CommandQueue.Size = true, 0 }, errMsg: "command_queue.size"},
+		{name: "command queue ttl", modify: func(c *Config) { c.CommandQueue.Enabled, c.CommandQueue.TTL = true, 0 }, errMsg: "command_queue.ttl"},
+		{name: "command queue disabled", modify: func(c *Config) { c.CommandQueue.Enabled, c.CommandQueue.Size, c.CommandQueue.TTL = false, 0, 0 }},
+		{name: "negative debounce window", modify: func(c *Config) { c.Debounce.Window = Duration(-time.Millisecond) }, errMsg: "command_debounce.window"},
+		{
+			name: "debounce max delay below window",
+			modify: func(c *Config) {
+				c.Debounce.Window, c.Debounce.MaxDelay = Duration(time.Second), Duration(500*time.Millisecond)
+			},
+			errMsg: "command_debounce.max_delay",
+		},
+		{name: "debounce without max delay", modify: func(c *Config) { c.Debounce.Window, c.Debounce.MaxDelay = Duration(time.Second), 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			tt.modify(c)
+			err := c.Validate()
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %v, want one about %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestHostFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostFile *string // Content of the host file, nil = no file
+		want     string
+	}{
+		{name: "no host file", want: "192.168.1.100"},
+		{name: "host file", hostFile: strPtr("192.168.1.50\n"), want: "192.168.1.50"},
+		{name: "empty host file", hostFile: strPtr(""), want: "192.168.1.100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			hostFile := filepath.Join(dir, "klf200_host")
+			if tt.hostFile != nil {
+				if err := os.WriteFile(hostFile, []byte(*tt.hostFile), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			configFile := filepath.Join(dir, "config.yaml")
+			data := "klf200:\n  host: \"192.168.1.100\"\n  host_file: \"" + hostFile + "\"\n"
+			if err := os.WriteFile(configFile, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := Load(configFile)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.KLF200.Host != tt.want {
+				t.Errorf("host = %q, want %q", cfg.KLF200.Host, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveHostFile(t *testing.T) {
+	c := KLF200Config{Host: "192.168.1.50"}
+	if err := c.SaveHostFile(); err != nil {
+		t.Fatalf("without host file: %v", err)
+	}
+
+	c.HostFile = filepath.Join(t.TempDir(), "klf200_host")
+	if err := c.SaveHostFile(); err != nil {
+		t.Fatalf("SaveHostFile: %v", err)
+	}
+	loaded := KLF200Config{Host: "192.168.1.100", HostFile: c.HostFile}
+	if err := loaded.loadHostFile(); err != nil {
+		t.Fatalf("loadHostFile: %v", err)
+	}
+	if loaded.Host != c.Host {
+		t.Errorf("host = %q, want %q", loaded.Host, c.Host)
+	}
+}
+
+func TestPasswordFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		passwordFile *string // Content of the password file, nil = no file
+		want         string
+	}{
+		{name: "no password file", want: "velux123"},
+		{name: "password file", passwordFile: strPtr("new-secret\n"), want: "new-secret"},
+		{name: "empty password file", passwordFile: strPtr(""), want: "velux123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := KLF200Config{Password: "velux123", PasswordFile: filepath.Join(t.TempDir(), "klf200_password")}
+			if tt.passwordFile != nil {
+				if err := os.WriteFile(c.PasswordFile, []byte(*tt.passwordFile), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := c.loadPasswordFile(); err != nil {
+				t.Fatalf("loadPasswordFile: %v", err)
+			}
+			if c.Password != tt.want {
+				t.Errorf("password = %q, want %q", c.Password, tt.want)
+			}
+		})
+	}
+}
+
+func TestSavePasswordFile(t *testing.T) {
+	c := KLF200Config{Password: "new-secret", PasswordFile: filepath.Join(t.TempDir(), "klf200_password")}
+	if err := c.SavePasswordFile(); err != nil {
+		t.Fatalf("SavePasswordFile: %v", err)
+	}
+
+	info, err := os.Stat(c.PasswordFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	loaded := KLF200Config{Password: "velux123", PasswordFile: c.PasswordFile}
+	if err := loaded.loadPasswordFile(); err != nil {
+		t.Fatalf("loadPasswordFile: %v", err)
+	}
+	if loaded.Password != c.Password {
+		t.Errorf("password = %q, want %q", loaded.Password, c.Password)
+	}
+}
+
+func strPtr(v string) *string { return &v }
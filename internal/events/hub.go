@@ -0,0 +1,129 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	TypeNodeAdded   = "node_added"
+	TypeNodeRemoved = "node_removed"
+	TypeNodeRenamed = "node_renamed"
+
+	TypeWinkStarted   = "wink_started"
+	TypeWinkCompleted = "wink_completed"
+	TypeWinkFailed    = "wink_failed"
+
+	TypePairingStarted   = "pairing_started"
+	TypePairingProgress  = "pairing_progress"
+	TypePairingCompleted = "pairing_completed"
+	TypePairingFailed    = "pairing_failed"
+
+	TypeSceneRecordingStarted   = "scene_recording_started"
+	TypeSceneRecordingProgress  = "scene_recording_progress"
+	TypeSceneRecordingCompleted = "scene_recording_completed"
+	TypeSceneRecordingFailed    = "scene_recording_failed"
+	TypeSceneRecordingCancelled = "scene_recording_cancelled"
+	TypeSceneChanged            = "scene_changed"
+
+	TypeNodeLocked   = "node_locked"
+	TypeNodeUnlocked = "node_unlocked"
+
+	TypeMaintenanceStarted = "maintenance_started"
+	TypeMaintenanceEnded   = "maintenance_ended"
+)
+
+// maxRecentEvents is the number of events kept for clients that poll or reconnect
+const maxRecentEvents = 200
+
+// subscriberBuffer is the number of events buffered per subscriber before events are dropped
+const subscriberBuffer = 64
+
+// Event is a gateway event delivered to API clients
+type Event struct {
+	ID      uint64      `json:"id"`
+	Type    string      `json:"type"`
+	Time    time.Time   `json:"time"`
+	NodeID  *uint8      `json:"node_id,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Hub distributes events to subscribers and keeps the most recent ones
+type Hub struct {
+	mu          sync.Mutex
+	nextID      uint64
+	recent      []Event
+	subscribers map[chan Event]struct{}
+}
+
+// NewHub creates a new event hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish assigns an ID and time to the event and delivers it to all subscribers.
+// Slow subscribers miss events instead of blocking the publisher.
+func (h *Hub) Publish(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	e.ID = h.nextID
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	h.recent = append(h.recent, e)
+	if len(h.recent) > maxRecentEvents {
+		h.recent = h.recent[len(h.recent)-maxRecentEvents:]
+	}
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// PublishNode publishes an event for a single node
+func (h *Hub) PublishNode(eventType string, nodeID uint8, message string, data interface{}) {
+	h.Publish(Event{Type: eventType, NodeID: &nodeID, Message: message, Data: data})
+}
+
+// Subscribe returns a channel receiving all future events and a function to unsubscribe
+func (h *Hub) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Recent returns the kept events with an ID greater than since (oldest first)
+func (h *Hub) Recent(since uint64) []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make([]Event, 0, len(h.recent))
+	for _, e := range h.recent {
+		if e.ID > since {
+			result = append(result, e)
+		}
+	}
+	return result
+}
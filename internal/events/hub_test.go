@@ -0,0 +1,71 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestHubPublish(t *testing.T) {
+	h := NewHub()
+	ch, unsubscribe := h.Subscribe()
+
+	h.Publish(Event{Type: TypeNodeAdded})
+	h.PublishNode(TypeNodeRenamed, 3, "renamed", nil)
+
+	for i, want := range []string{TypeNodeAdded, TypeNodeRenamed} {
+		e := <-ch
+		if e.Type != want || e.ID != uint64(i+1) || e.Time.IsZero() {
+			t.Errorf("event %d = %+v, want type %s with ID %d and a time", i, e, want, i+1)
+		}
+	}
+
+	unsubscribe()
+	unsubscribe() // Must be safe to call twice
+	if _, ok := <-ch; ok {
+		t.Error("channel still open after unsubscribe")
+	}
+	h.Publish(Event{Type: TypeNodeRemoved}) // Must not deliver to the closed channel
+}
+
+func TestHubRecent(t *testing.T) {
+	h := NewHub()
+	for i := 0; i < maxRecentEvents+10; i++ {
+		h.Publish(Event{Type: TypeNodeAdded})
+	}
+
+	tests := []struct {
+		name    string
+		since   uint64
+		want    int
+		firstID uint64
+	}{
+		{name: "all kept", since: 0, want: maxRecentEvents, firstID: 11},
+		{name: "since an event", since: maxRecentEvents, want: 10, firstID: maxRecentEvents + 1},
+		{name: "nothing new", since: maxRecentEvents + 10, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recent := h.Recent(tt.since)
+			if len(recent) != tt.want {
+				t.Fatalf("got %d events, want %d", len(recent), tt.want)
+			}
+			if tt.want > 0 && recent[0].ID != tt.firstID {
+				t.Errorf("first ID = %d, want %d", recent[0].ID, tt.firstID)
+			}
+		})
+	}
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	h := NewHub()
+	ch, unsubscribe := h.Subscribe()
+	defer unsubscribe()
+
+	// A subscriber that doesn't read must not block the publisher
+	for i := 0; i < subscriberBuffer*2; i++ {
+		h.Publish(Event{Type: TypeNodeAdded})
+	}
+	if len(ch) != subscriberBuffer {
+		t.Errorf("buffered %d events, want %d", len(ch), subscriberBuffer)
+	}
+}
@@ -0,0 +1,139 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+const (
+	// activationLogDelay collects bursts of GW_ACTIVATION_LOG_UPDATED_NTF into a single import
+	activationLogDelay = 2 * time.Second
+	// activationLogInterval is the min. time between imports triggered by notifications;
+	// the KLF-200 reports new lines after every movement
+	activationLogInterval = time.Minute
+)
+
+// ActivationLogEntry is an activation log line with the name of its node
+type ActivationLogEntry struct {
+	*klf200.ActivationLogLine
+	NodeName     string `json:"node_name,omitempty"`
+	RunStatusStr string `json:"run_status_str"`
+}
+
+type activationLog struct {
+	mu        sync.Mutex // Serializes imports
+	scheduled atomic.Bool
+	last      atomic.Int64 // Start of the last import (unix nanoseconds)
+}
+
+// ImportActivationLog imports the activation log lines added since the last import into the store
+func (s *Service) ImportActivationLog(ctx context.Context) (int, error) {
+	if s.store == nil {
+		return 0, nil
+	}
+	if !s.client.IsAuthenticated() {
+		return 0, fmt.Errorf("not connected to KLF-200")
+	}
+
+	s.activationLog.mu.Lock()
+	defer s.activationLog.mu.Unlock()
+	s.activationLog.last.Store(time.Now().UnixNano())
+
+	since, err := s.store.ActivationLogImported()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read last import: %w", err)
+	}
+
+	lines, err := s.client.GetActivationLogSince(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+	if len(lines) == 0 {
+		return 0, nil
+	}
+
+	if err := s.store.AppendActivationLog(lines); err != nil {
+		return 0, fmt.Errorf("failed to store activation log: %w", err)
+	}
+
+	s.logger.Debug().Int("lines", len(lines)).Time("since", since).Msg("Imported activation log")
+	return len(lines), nil
+}
+
+// GetActivationLog returns the activation log lines between from and to, optionally of a single node.
+// With history storage the imported lines are returned (after importing new ones),
+// otherwise the lines are read from the KLF-200 directly.
+func (s *Service) GetActivationLog(ctx context.Context, from, to time.Time, nodeID *uint8) ([]ActivationLogEntry, error) {
+	var lines []*klf200.ActivationLogLine
+	if s.store != nil {
+		if s.client.IsAuthenticated() {
+			if _, err := s.ImportActivationLog(ctx); err != nil {
+				s.logger.Warn().Err(err).Msg("Failed to import activation log, returning imported lines")
+			}
+		}
+
+		var err error
+		if lines, err = s.store.ActivationLog(from, to); err != nil {
+			return nil, err
+		}
+	} else {
+		if !s.client.IsAuthenticated() {
+			return nil, fmt.Errorf("not connected to KLF-200")
+		}
+
+		all, err := s.client.GetActivationLogSince(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range all {
+			if !line.Time.Before(from) && !line.Time.After(to) {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	entries := make([]ActivationLogEntry, 0, len(lines))
+	for _, line := range lines {
+		if nodeID != nil && line.NodeID != *nodeID {
+			continue
+		}
+		entry := ActivationLogEntry{ActivationLogLine: line, RunStatusStr: line.RunStatus.String()}
+		if node, ok := s.nodes.GetNode(line.NodeID); ok {
+			entry.NodeName = node.Name
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// handleActivationLogUpdated schedules an import when the KLF-200 reports new log lines.
+// It runs on the read loop of the client, so the import happens in the background.
+// Notifications are coalesced into at most one import per activationLogInterval.
+func (s *Service) handleActivationLogUpdated() {
+	if s.store == nil || !s.activationLog.scheduled.CompareAndSwap(false, true) {
+		return
+	}
+
+	delay := activationLogDelay
+	next := time.Unix(0, s.activationLog.last.Load()).Add(activationLogInterval)
+	if wait := time.Until(next); wait > delay {
+		delay = wait
+	}
+
+	time.AfterFunc(delay, func() {
+		// Lines logged during the import are picked up by the next notification
+		s.activationLog.scheduled.Store(false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if _, err := s.ImportActivationLog(ctx); err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to import activation log")
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// limitPosition clamps a requested position to the limits of the node mapping
+func limitPosition(mapping *config.NodeMapping, percent float64) float64 {
+	if mapping == nil {
+		return percent
+	}
+	if mapping.MinPosition != nil && percent < *mapping.MinPosition {
+		percent = *mapping.MinPosition
+	}
+	if mapping.MaxPosition != nil && percent > *mapping.MaxPosition {
+		percent = *mapping.MaxPosition
+	}
+	return percent
+}
+
+// calibrationCurve returns the calibration points including the implicit 0 and 100 end points
+func calibrationCurve(mapping *config.NodeMapping) []config.CalibrationPoint {
+	if mapping == nil || len(mapping.Calibration) == 0 {
+		return nil
+	}
+
+	curve := make([]config.CalibrationPoint, 0, len(mapping.Calibration)+2)
+	if first := mapping.Calibration[0]; first.Position > 0 && first.Device > 0 {
+		curve = append(curve, config.CalibrationPoint{Position: 0, Device: 0})
+	}
+	curve = append(curve, mapping.Calibration...)
+	if last := mapping.Calibration[len(mapping.Calibration)-1]; last.Position < 100 && last.Device < 100 {
+		curve = append(curve, config.CalibrationPoint{Position: 100, Device: 100})
+	}
+	return curve
+}
+
+// interpolate maps x through the piecewise linear curve of the points, reading them with from and to
+func interpolate(x float64, points []config.CalibrationPoint, from, to func(config.CalibrationPoint) float64) float64 {
+	if x <= from(points[0]) {
+		return to(points[0])
+	}
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if x <= from(b) {
+			return to(a) + (x-from(a))/(from(b)-from(a))*(to(b)-to(a))
+		}
+	}
+	return to(points[len(points)-1])
+}
+
+func pointPosition(p config.CalibrationPoint) float64 { return p.Position }
+func pointDevice(p config.CalibrationPoint) float64   { return p.Device }
+
+// toDevicePercent converts a displayed position to the position sent to the KLF-200
+func toDevicePercent(mapping *config.NodeMapping, percent float64) float64 {
+	curve := calibrationCurve(mapping)
+	if curve == nil {
+		return percent
+	}
+	return interpolate(percent, curve, pointPosition, pointDevice)
+}
+
+// fromDevicePercent converts a position reported by the KLF-200 to the displayed position
+func fromDevicePercent(mapping *config.NodeMapping, percent float64) float64 {
+	curve := calibrationCurve(mapping)
+	if curve == nil {
+		return percent
+	}
+	return interpolate(percent, curve, pointDevice, pointPosition)
+}
+
+// calibrateNode returns a copy of the node with calibrated position and target
+func (s *Service) calibrateNode(node *klf200.Node) *klf200.Node {
+	calibrated := *node
+	mapping := s.mappingManager.GetByNodeID(node.ID)
+	if mapping == nil || len(mapping.Calibration) == 0 {
+		return &calibrated
+	}
+
+	calibrated.PositionPercent = fromDevicePercent(mapping, node.PositionPercent)
+	calibrated.TargetPercent = fromDevicePercent(mapping, node.TargetPercent)
+	if node.Limitation != nil {
+		limitation := *node.Limitation
+		limitation.MinPercent = fromDevicePercent(mapping, limitation.MinPercent)
+		limitation.MaxPercent = fromDevicePercent(mapping, limitation.MaxPercent)
+		calibrated.Limitation = &limitation
+	}
+	return &calibrated
+}
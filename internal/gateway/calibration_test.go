@@ -0,0 +1,113 @@
+package gateway
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+func TestLimitPosition(t *testing.T) {
+	limited := &config.NodeMapping{MinPosition: floatPtr(10), MaxPosition: floatPtr(80)}
+
+	tests := []struct {
+		name    string
+		mapping *config.NodeMapping
+		percent float64
+		want    float64
+	}{
+		{name: "no mapping", percent: 95, want: 95},
+		{name: "no limits", mapping: &config.NodeMapping{}, percent: 95, want: 95},
+		{name: "within limits", mapping: limited, percent: 50, want: 50},
+		{name: "below minimum", mapping: limited, percent: 0, want: 10},
+		{name: "above maximum", mapping: limited, percent: 100, want: 80},
+		{name: "maximum only", mapping: &config.NodeMapping{MaxPosition: floatPtr(60)}, percent: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limitPosition(tt.mapping, tt.percent); got != tt.want {
+				t.Errorf("limitPosition(%v) = %v, want %v", tt.percent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalibration(t *testing.T) {
+	// A shutter whose slats close at 80% of the travel: displayed 50% is device 80%
+	shutter := &config.NodeMapping{Calibration: []config.CalibrationPoint{{Position: 50, Device: 80}}}
+	// Explicit end points that differ from 0 -> 0 and 100 -> 100
+	offset := &config.NodeMapping{Calibration: []config.CalibrationPoint{
+		{Position: 0, Device: 10},
+		{Position: 100, Device: 90},
+	}}
+
+	tests := []struct {
+		name     string
+		mapping  *config.NodeMapping
+		position float64
+		device   float64
+	}{
+		{name: "no mapping", position: 30, device: 30},
+		{name: "no calibration", mapping: &config.NodeMapping{}, position: 30, device: 30},
+		{name: "implicit start", mapping: shutter, position: 0, device: 0},
+		{name: "first segment", mapping: shutter, position: 25, device: 40},
+		{name: "calibration point", mapping: shutter, position: 50, device: 80},
+		{name: "second segment", mapping: shutter, position: 75, device: 90},
+		{name: "implicit end", mapping: shutter, position: 100, device: 100},
+		{name: "explicit start", mapping: offset, position: 0, device: 10},
+		{name: "explicit middle", mapping: offset, position: 50, device: 50},
+		{name: "explicit end", mapping: offset, position: 100, device: 90},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toDevicePercent(tt.mapping, tt.position); math.Abs(got-tt.device) > 1e-9 {
+				t.Errorf("toDevicePercent(%v) = %v, want %v", tt.position, got, tt.device)
+			}
+			if got := fromDevicePercent(tt.mapping, tt.device); math.Abs(got-tt.position) > 1e-9 {
+				t.Errorf("fromDevicePercent(%v) = %v, want %v", tt.device, got, tt.position)
+			}
+		})
+	}
+
+	// Device positions outside the explicit curve are clamped to its end points
+	if got := fromDevicePercent(offset, 0); got != 0 {
+		t.Errorf("fromDevicePercent(0) = %v, want 0", got)
+	}
+	if got := fromDevicePercent(offset, 100); got != 100 {
+		t.Errorf("fromDevicePercent(100) = %v, want 100", got)
+	}
+}
+
+func TestCalibrateNode(t *testing.T) {
+	s := NewService(&config.KLF200Config{}, &config.LoxoneConfig{Mappings: []config.NodeMapping{
+		{ID: "a", NodeID: 1, Enabled: true, Calibration: []config.CalibrationPoint{{Position: 50, Device: 80}}},
+		{ID: "b", NodeID: 2, Enabled: true},
+	}}, zerolog.Nop())
+
+	node := &klf200.Node{
+		ID:              1,
+		PositionPercent: 80,
+		TargetPercent:   90,
+		Limitation:      &klf200.NodeLimitation{MinPercent: 40, MaxPercent: 100},
+	}
+	got := s.calibrateNode(node)
+	if got.PositionPercent != 50 || got.TargetPercent != 75 {
+		t.Errorf("position %v target %v, want 50 and 75", got.PositionPercent, got.TargetPercent)
+	}
+	if got.Limitation.MinPercent != 25 || got.Limitation.MaxPercent != 100 {
+		t.Errorf("limitation %v-%v, want 25-100", got.Limitation.MinPercent, got.Limitation.MaxPercent)
+	}
+	if node.PositionPercent != 80 || node.Limitation.MinPercent != 40 {
+		t.Errorf("original node modified: %+v", node)
+	}
+
+	uncalibrated := &klf200.Node{ID: 2, PositionPercent: 80}
+	if got := s.calibrateNode(uncalibrated); got == uncalibrated || got.PositionPercent != 80 {
+		t.Errorf("uncalibrated node = %+v, want an unchanged copy", got)
+	}
+}
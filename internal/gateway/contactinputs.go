@@ -0,0 +1,104 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// GetContactInputLinks returns the actions linked to the contact inputs of the KLF-200.
+// Positions of node links are reported calibrated like the node positions.
+func (s *Service) GetContactInputLinks(ctx context.Context) ([]klf200.ContactInputLink, error) {
+	if !s.client.IsAuthenticated() {
+		return nil, fmt.Errorf("not connected to KLF-200")
+	}
+
+	links, err := s.client.GetContactInputLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range links {
+		if links[i].Assignment == klf200.ContactInputNode && links[i].Position <= klf200.PositionMax {
+			mapping := s.mappingManager.GetByNodeID(links[i].ActionID)
+			links[i].PositionPercent = fromDevicePercent(mapping, links[i].PositionPercent)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].InputID < links[j].InputID })
+	return links, nil
+}
+
+// SetContactInputLink links a contact input to a known node or scene.
+// For node links the position is clamped and calibrated like a normal command;
+// the KLF-200 executes the link on its own, without passing the interlock rules.
+func (s *Service) SetContactInputLink(ctx context.Context, link klf200.ContactInputLink) (*klf200.ContactInputLink, error) {
+	if !s.client.IsAuthenticated() {
+		return nil, fmt.Errorf("not connected to KLF-200")
+	}
+
+	if link.InputID < klf200.MinContactInputID || link.InputID > klf200.MaxContactInputID {
+		return nil, fmt.Errorf("input must be %d-%d", klf200.MinContactInputID, klf200.MaxContactInputID)
+	}
+	for _, output := range []uint8{link.SuccessOutputID, link.ErrorOutputID} {
+		if output > klf200.MaxContactInputID {
+			return nil, fmt.Errorf("outputs must be 0 (none) or %d-%d", klf200.MinContactInputID, klf200.MaxContactInputID)
+		}
+	}
+
+	switch link.Assignment {
+	case klf200.ContactInputNode:
+		if _, ok := s.nodes.GetNode(link.ActionID); !ok {
+			return nil, fmt.Errorf("node %d: %w", link.ActionID, klf200.ErrNodeNotFound)
+		}
+		if link.PositionPercent < 0 || link.PositionPercent > 100 {
+			return nil, fmt.Errorf("position must be 0-100")
+		}
+		mapping := s.mappingManager.GetByNodeID(link.ActionID)
+		link.PositionPercent = limitPosition(mapping, link.PositionPercent)
+		link.Position = klf200.PercentToPosition(toDevicePercent(mapping, link.PositionPercent))
+	case klf200.ContactInputScene:
+		scenes, err := s.client.GetSceneList(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read scenes: %w", err)
+		}
+		found := false
+		for _, scene := range scenes {
+			if scene.ID == link.ActionID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("scene %d: %w", link.ActionID, klf200.ErrSceneNotFound)
+		}
+		link.Position = 0
+		link.PositionPercent = 0
+	default:
+		return nil, fmt.Errorf("assignment must be node or scene")
+	}
+
+	if err := s.client.SetContactInputLink(ctx, link); err != nil {
+		return nil, err
+	}
+	link.AssignmentStr = link.Assignment.String()
+
+	s.logger.Info().
+		Uint8("input", link.InputID).
+		Str("assignment", link.AssignmentStr).
+		Uint8("action", link.ActionID).
+		Msg("Contact input linked")
+	return &link, nil
+}
+
+// RemoveContactInputLink removes the action linked to a contact input
+func (s *Service) RemoveContactInputLink(ctx context.Context, inputID uint8) error {
+	if !s.client.IsAuthenticated() {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+	if inputID < klf200.MinContactInputID || inputID > klf200.MaxContactInputID {
+		return fmt.Errorf("input must be %d-%d", klf200.MinContactInputID, klf200.MaxContactInputID)
+	}
+	return s.client.RemoveContactInputLink(ctx, inputID)
+}
@@ -0,0 +1,170 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+)
+
+// pendingMove is a position command waiting for the debounce window of its node.
+// Newer commands of the same source replace the target, every waiting caller gets
+// the result of the one send.
+type pendingMove struct {
+	ctx     context.Context
+	source  CommandSource
+	percent float64
+	first   time.Time
+	timer   *time.Timer
+	waiters []chan error
+}
+
+// debouncer coalesces rapid position commands per node
+type debouncer struct {
+	mu      sync.Mutex
+	cfg     config.DebounceConfig
+	pending map[uint8]*pendingMove
+}
+
+// SetDebounceConfig updates the command debounce settings. Pending commands keep their timers.
+func (s *Service) SetDebounceConfig(cfg config.DebounceConfig) {
+	s.debounce.mu.Lock()
+	defer s.debounce.mu.Unlock()
+
+	s.debounce.cfg = cfg
+}
+
+// debounceMove moves a node once no newer command of the same source arrived within the
+// debounce window, or at the latest after the max. delay. It blocks until the coalesced
+// command was sent. Without a window the command is sent immediately.
+func (s *Service) debounceMove(ctx context.Context, nodeID uint8, percent float64) error {
+	source := SourceFrom(ctx)
+
+	s.debounce.mu.Lock()
+	// Commands of different sources are not merged, maintenance treats them differently.
+	// A pending move of another source is sent first to keep the order.
+	for {
+		p, ok := s.debounce.pending[nodeID]
+		if !ok || p.source == source {
+			break
+		}
+		p.timer.Stop()
+		delete(s.debounce.pending, nodeID)
+		s.debounce.mu.Unlock()
+		s.sendPendingMove(nodeID, p)
+		s.debounce.mu.Lock()
+	}
+
+	cfg := s.debounce.cfg
+	window, maxDelay := time.Duration(cfg.Window), time.Duration(cfg.MaxDelay)
+	if window <= 0 {
+		s.debounce.mu.Unlock()
+		return s.moveNow(ctx, nodeID, percent)
+	}
+
+	// The send outlives the caller if a newer command joins, keep the values (source) only
+	sendCtx := context.WithoutCancel(ctx)
+	done := make(chan error, 1)
+	now := time.Now()
+
+	if s.debounce.pending == nil {
+		s.debounce.pending = make(map[uint8]*pendingMove)
+	}
+	if p, ok := s.debounce.pending[nodeID]; ok {
+		s.logger.Debug().
+			Uint8("node", nodeID).
+			Float64("superseded", p.percent).
+			Float64("position", percent).
+			Msg("Pending move superseded")
+
+		p.ctx = sendCtx
+		p.percent = percent
+		p.waiters = append(p.waiters, done)
+
+		delay := window
+		if maxDelay > 0 {
+			if remaining := time.Until(p.first.Add(maxDelay)); remaining < delay {
+				delay = max(remaining, 0)
+			}
+		}
+		p.timer.Reset(delay)
+	} else {
+		p := &pendingMove{
+			ctx:     sendCtx,
+			source:  source,
+			percent: percent,
+			first:   now,
+			waiters: []chan error{done},
+		}
+		p.timer = time.AfterFunc(window, func() {
+			s.flushMove(nodeID, p)
+		})
+		s.debounce.pending[nodeID] = p
+	}
+	s.debounce.mu.Unlock()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// moveNow sends a move immediately. A pending debounced move of the node is superseded,
+// its callers get the result of this move.
+func (s *Service) moveNow(ctx context.Context, nodeID uint8, percent float64) error {
+	pending := s.takePendingMove(nodeID)
+	err := s.moveTo(ctx, nodeID, percent)
+	resolveMove(pending, err)
+	return err
+}
+
+// flushMove sends a pending move whose debounce window has passed
+func (s *Service) flushMove(nodeID uint8, p *pendingMove) {
+	s.debounce.mu.Lock()
+	if s.debounce.pending[nodeID] != p {
+		// Already sent or dropped, the timer fired again after a reset
+		s.debounce.mu.Unlock()
+		return
+	}
+	delete(s.debounce.pending, nodeID)
+	s.debounce.mu.Unlock()
+
+	s.sendPendingMove(nodeID, p)
+}
+
+// sendPendingMove sends a move removed from the pending ones and resolves its callers
+func (s *Service) sendPendingMove(nodeID uint8, p *pendingMove) {
+	err := s.moveTo(p.ctx, nodeID, p.percent)
+	if n := len(p.waiters); n > 1 {
+		s.logger.Debug().Uint8("node", nodeID).Int("commands", n).Float64("position", p.percent).Msg("Coalesced move sent")
+	}
+	resolveMove(p, err)
+}
+
+// takePendingMove removes the pending move of a node, e.g. when it is stopped.
+// The caller resolves the waiters of the returned move.
+func (s *Service) takePendingMove(nodeID uint8) *pendingMove {
+	s.debounce.mu.Lock()
+	defer s.debounce.mu.Unlock()
+
+	p, ok := s.debounce.pending[nodeID]
+	if !ok {
+		return nil
+	}
+	p.timer.Stop()
+	delete(s.debounce.pending, nodeID)
+	return p
+}
+
+// resolveMove hands the outcome of the coalesced command to every waiting caller
+func resolveMove(p *pendingMove, err error) {
+	if p == nil {
+		return
+	}
+	for _, done := range p.waiters {
+		done <- err
+	}
+}
@@ -0,0 +1,157 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// debounceTestNode is moved by the debounce tests. The service is not connected,
+// every send fails the same way, which is the result the coalesced callers share.
+const debounceTestNode = 1
+
+// startMove runs debounceMove in the background and returns the channel of its result
+func startMove(ctx context.Context, s *Service, percent float64) <-chan error {
+	result := make(chan error, 1)
+	go func() { result <- s.debounceMove(ctx, debounceTestNode, percent) }()
+	return result
+}
+
+// waitForPending waits until the pending move of the test node has the given number of callers
+func waitForPending(t *testing.T, s *Service, waiters int) *pendingMove {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.debounce.mu.Lock()
+		p := s.debounce.pending[debounceTestNode]
+		if p != nil && len(p.waiters) == waiters {
+			s.debounce.mu.Unlock()
+			return p
+		}
+		s.debounce.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("no pending move with %d callers", waiters)
+	return nil
+}
+
+// waitForResult returns the result of a move started with startMove
+func waitForResult(t *testing.T, result <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("move did not return")
+		return nil
+	}
+}
+
+func newDebounceService(window, maxDelay time.Duration) *Service {
+	s := newTestService(&klf200.Node{ID: debounceTestNode, PositionPercent: 50})
+	s.SetDebounceConfig(config.DebounceConfig{Window: config.Duration(window), MaxDelay: config.Duration(maxDelay)})
+	return s
+}
+
+func TestDebounceCoalescesCommands(t *testing.T) {
+	s := newDebounceService(time.Hour, 0)
+	ctx := WithSource(context.Background(), SourceLoxone)
+
+	var results []<-chan error
+	for i, percent := range []float64{10, 40, 70} {
+		results = append(results, startMove(ctx, s, percent))
+		waitForPending(t, s, i+1)
+	}
+
+	p := waitForPending(t, s, 3)
+	if p.percent != 70 || p.source != SourceLoxone {
+		t.Fatalf("pending move to %v from %s, want 70 from %s", p.percent, p.source, SourceLoxone)
+	}
+
+	// An immediate move supersedes the pending one, its callers get its result
+	want := s.moveNow(ctx, debounceTestNode, 20)
+	if want == nil {
+		t.Fatal("move of the unconnected service succeeded")
+	}
+	for i, result := range results {
+		if err := waitForResult(t, result); err == nil || err.Error() != want.Error() {
+			t.Errorf("caller %d: error = %v, want %v", i, err, want)
+		}
+	}
+	if p := s.takePendingMove(debounceTestNode); p != nil {
+		t.Error("pending move left after the immediate move")
+	}
+}
+
+func TestDebounceKeepsSourcesApart(t *testing.T) {
+	s := newDebounceService(time.Hour, 0)
+
+	loxone := startMove(WithSource(context.Background(), SourceLoxone), s, 10)
+	waitForPending(t, s, 1)
+
+	// A command of another source sends the pending move first and waits on its own
+	schedule := startMove(WithSource(context.Background(), SourceSchedule), s, 80)
+	if err := waitForResult(t, loxone); err == nil {
+		t.Error("pending Loxone move was not sent")
+	}
+
+	p := waitForPending(t, s, 1)
+	if p.percent != 80 || p.source != SourceSchedule {
+		t.Errorf("pending move to %v from %s, want 80 from %s", p.percent, p.source, SourceSchedule)
+	}
+
+	resolveMove(s.takePendingMove(debounceTestNode), nil)
+	if err := waitForResult(t, schedule); err != nil {
+		t.Errorf("schedule move: %v", err)
+	}
+}
+
+func TestDebounceMaxDelay(t *testing.T) {
+	// The window never passes, the max. delay sends the move
+	s := newDebounceService(time.Hour, 50*time.Millisecond)
+	ctx := context.Background()
+
+	first := startMove(ctx, s, 10)
+	waitForPending(t, s, 1)
+	second := startMove(ctx, s, 90)
+
+	err := waitForResult(t, second)
+	if err == nil {
+		t.Fatal("move of the unconnected service succeeded")
+	}
+	if firstErr := waitForResult(t, first); firstErr == nil || firstErr.Error() != err.Error() {
+		t.Errorf("first caller: error = %v, want %v", firstErr, err)
+	}
+}
+
+func TestDebounceWithoutWindow(t *testing.T) {
+	s := newDebounceService(0, 0)
+
+	if err := s.debounceMove(context.Background(), debounceTestNode, 30); err == nil {
+		t.Fatal("move of the unconnected service succeeded")
+	}
+	if p := s.takePendingMove(debounceTestNode); p != nil {
+		t.Error("move without a window left a pending move")
+	}
+}
+
+func TestDebounceCallerCancel(t *testing.T) {
+	s := newDebounceService(time.Hour, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	result := startMove(ctx, s, 30)
+	waitForPending(t, s, 1)
+	cancel()
+
+	if err := waitForResult(t, result); err != context.Canceled {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+	// The move itself stays pending for the other callers
+	if p := waitForPending(t, s, 1); p.percent != 30 {
+		t.Errorf("pending move to %v, want 30", p.percent)
+	}
+	resolveMove(s.takePendingMove(debounceTestNode), nil)
+}
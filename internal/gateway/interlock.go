@@ -0,0 +1,388 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// interlockPollInterval is how often a sequence checks whether the required nodes have arrived
+const interlockPollInterval = 500 * time.Millisecond
+
+// InterlockError is returned when a command violates an interlock rule
+type InterlockError struct {
+	Rule     config.InterlockRule `json:"rule"`
+	NodeID   uint8                `json:"node_id"`
+	Position *float64             `json:"position"` // Current position of the required node, nil if unknown
+}
+
+func (e *InterlockError) Error() string {
+	name := e.Rule.Name
+	if name == "" {
+		name = e.Rule.ID
+	}
+
+	var requirement string
+	switch {
+	case e.Rule.RequireMin != nil && e.Rule.RequireMax != nil:
+		requirement = fmt.Sprintf("between %.0f%% and %.0f%%", *e.Rule.RequireMin, *e.Rule.RequireMax)
+	case e.Rule.RequireMin != nil:
+		requirement = fmt.Sprintf("at least %.0f%%", *e.Rule.RequireMin)
+	default:
+		requirement = fmt.Sprintf("at most %.0f%%", *e.Rule.RequireMax)
+	}
+
+	current := "unknown"
+	if e.Position != nil {
+		current = fmt.Sprintf("%.0f%%", *e.Position)
+	}
+
+	return fmt.Sprintf("interlock %q: node %d may only %s while node %d is %s (currently %s)",
+		name, e.NodeID, interlockVerb(e.Rule.Direction), e.Rule.RequireNodeID, requirement, current)
+}
+
+func interlockVerb(direction string) string {
+	if direction == config.InterlockDirectionAny {
+		return "move"
+	}
+	return direction
+}
+
+// InterlockSequence is a command waiting for its required nodes to arrive
+type InterlockSequence struct {
+	NodeID   uint8     `json:"node_id"`
+	Target   float64   `json:"target"`
+	Waiting  []uint8   `json:"waiting_for"`
+	Started  time.Time `json:"started"`
+	Deadline time.Time `json:"deadline"`
+}
+
+// InterlockStatus is the configured rules and the pending sequences
+type InterlockStatus struct {
+	Config    config.InterlockConfig `json:"config"`
+	Sequences []InterlockSequence    `json:"sequences"`
+}
+
+// interlockStep is a move of a required node that must complete first
+type interlockStep struct {
+	rule     config.InterlockRule
+	nodeID   uint8
+	position float64
+}
+
+type pendingSequence struct {
+	info   InterlockSequence
+	cancel context.CancelFunc
+}
+
+// interlocks evaluates the interlock rules and runs sequenced commands
+type interlocks struct {
+	mu      sync.Mutex
+	cfg     config.InterlockConfig
+	pending map[uint8]*pendingSequence
+}
+
+// SetInterlockConfig updates the interlock rules
+func (s *Service) SetInterlockConfig(cfg config.InterlockConfig) {
+	s.interlocks.mu.Lock()
+	defer s.interlocks.mu.Unlock()
+
+	cfg.Rules = append([]config.InterlockRule(nil), cfg.Rules...)
+	s.interlocks.cfg = cfg
+}
+
+// GetInterlockStatus returns the interlock rules and the commands waiting for a sequence
+func (s *Service) GetInterlockStatus() InterlockStatus {
+	s.interlocks.mu.Lock()
+	defer s.interlocks.mu.Unlock()
+
+	status := InterlockStatus{
+		Config:    s.interlocks.cfg,
+		Sequences: make([]InterlockSequence, 0, len(s.interlocks.pending)),
+	}
+	for _, p := range s.interlocks.pending {
+		status.Sequences = append(status.Sequences, p.info)
+	}
+	return status
+}
+
+// moveNode checks maintenance, the node lock and the interlock rules before moving a node to target.
+// If a rule requires sequencing, the required nodes are moved first and the command
+// is sent in the background once they have arrived.
+func (s *Service) moveNode(ctx context.Context, nodeID uint8, target float64, send func(ctx context.Context) error) error {
+	if err := s.checkMaintenance(ctx, nodeID); err != nil {
+		return err
+	}
+	if err := s.checkLock(ctx, nodeID); err != nil {
+		return err
+	}
+
+	s.cancelSequence(nodeID)
+
+	steps, err := s.planInterlocks(nodeID, target, true)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return send(ctx)
+	}
+
+	// The required nodes are moved on behalf of this command, their own rules apply as well
+	for _, step := range steps {
+		if err := s.checkMove(ctx, step.nodeID, step.position); err != nil {
+			return fmt.Errorf("interlock sequence: node %d cannot be moved: %w", step.nodeID, err)
+		}
+	}
+
+	for _, step := range steps {
+		s.logger.Info().
+			Str("rule", step.rule.ID).
+			Uint8("node", nodeID).
+			Uint8("required_node", step.nodeID).
+			Float64("position", step.position).
+			Msg("Interlock sequencing: moving required node first")
+
+		s.cancelSequence(step.nodeID)
+		device := toDevicePercent(s.mappingManager.GetByNodeID(step.nodeID), step.position)
+		if err := s.client.SetPositionWithPriority(ctx, step.nodeID, device, s.commandPriority(ctx, step.nodeID)); err != nil {
+			return fmt.Errorf("interlock sequence: failed to move node %d: %w", step.nodeID, err)
+		}
+	}
+
+	s.startSequence(ctx, nodeID, target, steps, send)
+	return nil
+}
+
+// checkMove runs the maintenance, lock and interlock checks for a move that is not sequenced
+func (s *Service) checkMove(ctx context.Context, nodeID uint8, target float64) error {
+	if err := s.checkMaintenance(ctx, nodeID); err != nil {
+		return err
+	}
+	if err := s.checkLock(ctx, nodeID); err != nil {
+		return err
+	}
+	_, err := s.planInterlocks(nodeID, target, false)
+	return err
+}
+
+// planInterlocks returns the moves needed before nodeID may move to target,
+// or an InterlockError if a rule is violated and cannot be sequenced
+func (s *Service) planInterlocks(nodeID uint8, target float64, allowSequence bool) ([]interlockStep, error) {
+	s.interlocks.mu.Lock()
+	rules := s.interlocks.cfg.Rules
+	tolerance := s.interlocks.cfg.Tolerance
+	s.interlocks.mu.Unlock()
+
+	direction := ""
+	if node, ok := s.GetNode(nodeID); ok {
+		switch {
+		case target < node.PositionPercent:
+			direction = config.InterlockDirectionOpen
+		case target > node.PositionPercent:
+			direction = config.InterlockDirectionClose
+		default:
+			return nil, nil // Already there
+		}
+	}
+
+	var steps []interlockStep
+	var matched []config.InterlockRule
+	for _, rule := range rules {
+		if !rule.Enabled || rule.NodeID != nodeID {
+			continue
+		}
+		// Unknown direction (node not known yet) matches every rule
+		if rule.Direction != config.InterlockDirectionAny && direction != "" && rule.Direction != direction {
+			continue
+		}
+		matched = append(matched, rule)
+
+		required, ok := s.GetNode(rule.RequireNodeID)
+		if !ok {
+			return nil, &InterlockError{Rule: rule, NodeID: nodeID}
+		}
+
+		pos := required.PositionPercent
+		position, satisfied := interlockTarget(rule, pos, tolerance)
+		if satisfied {
+			continue
+		}
+		if !allowSequence || !rule.Sequence {
+			return nil, &InterlockError{Rule: rule, NodeID: nodeID, Position: &pos}
+		}
+
+		// The required node must stay within its own position limits
+		position = limitPosition(s.mappingManager.GetByNodeID(rule.RequireNodeID), position)
+		if _, ok := interlockTarget(rule, position, tolerance); !ok {
+			return nil, &InterlockError{Rule: rule, NodeID: nodeID, Position: &pos}
+		}
+		steps = append(steps, interlockStep{rule: rule, nodeID: rule.RequireNodeID, position: position})
+	}
+
+	// A required node is moved once, rules requiring conflicting ranges cannot be sequenced
+	if rule := agreeStepPositions(steps, matched, tolerance); rule != nil {
+		var pos *float64
+		if required, ok := s.GetNode(rule.RequireNodeID); ok {
+			pos = &required.PositionPercent
+		}
+		return nil, &InterlockError{Rule: *rule, NodeID: nodeID, Position: pos}
+	}
+
+	// The required moves themselves must not violate a rule (no nested sequencing)
+	for _, step := range steps {
+		if _, err := s.planInterlocks(step.nodeID, step.position, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return steps, nil
+}
+
+// agreeStepPositions sets the steps of each required node to the first of their positions that
+// satisfies all matched rules requiring that node. Returns a violated rule if there is none.
+func agreeStepPositions(steps []interlockStep, matched []config.InterlockRule, tolerance float64) *config.InterlockRule {
+	for i := range steps {
+		requiredID := steps[i].nodeID
+
+		var violated *config.InterlockRule
+		for _, candidate := range steps[i:] {
+			if candidate.nodeID != requiredID {
+				continue
+			}
+			violated = nil
+			for j, rule := range matched {
+				if rule.RequireNodeID != requiredID {
+					continue
+				}
+				if _, ok := interlockTarget(rule, candidate.position, tolerance); !ok {
+					violated = &matched[j]
+					break
+				}
+			}
+			if violated == nil {
+				for j := range steps {
+					if steps[j].nodeID == requiredID {
+						steps[j].position = candidate.position
+					}
+				}
+				break
+			}
+		}
+		if violated != nil {
+			return violated
+		}
+	}
+	return nil
+}
+
+// interlockTarget reports whether pos satisfies the rule and otherwise the nearest allowed position
+func interlockTarget(rule config.InterlockRule, pos, tolerance float64) (float64, bool) {
+	if rule.RequireMin != nil && pos < *rule.RequireMin-tolerance {
+		return *rule.RequireMin, false
+	}
+	if rule.RequireMax != nil && pos > *rule.RequireMax+tolerance {
+		return *rule.RequireMax, false
+	}
+	return pos, true
+}
+
+// startSequence waits in the background until the required nodes have arrived, then checks
+// the command again and sends it. The command keeps the source of the caller's context.
+func (s *Service) startSequence(parent context.Context, nodeID uint8, target float64, steps []interlockStep, send func(ctx context.Context) error) {
+	s.interlocks.mu.Lock()
+	timeout := time.Duration(s.interlocks.cfg.SequenceTimeout)
+	tolerance := s.interlocks.cfg.Tolerance
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), timeout)
+	seq := &pendingSequence{
+		info: InterlockSequence{
+			NodeID:   nodeID,
+			Target:   target,
+			Started:  time.Now(),
+			Deadline: time.Now().Add(timeout),
+		},
+		cancel: cancel,
+	}
+	for _, step := range steps {
+		seq.info.Waiting = append(seq.info.Waiting, step.nodeID)
+	}
+	if s.interlocks.pending == nil {
+		s.interlocks.pending = make(map[uint8]*pendingSequence)
+	}
+	s.interlocks.pending[nodeID] = seq
+	s.interlocks.mu.Unlock()
+
+	go func() {
+		defer func() {
+			cancel()
+			s.interlocks.mu.Lock()
+			if s.interlocks.pending[nodeID] == seq {
+				delete(s.interlocks.pending, nodeID)
+			}
+			s.interlocks.mu.Unlock()
+		}()
+
+		ticker := time.NewTicker(interlockPollInterval)
+		defer ticker.Stop()
+
+		for !s.stepsDone(steps, tolerance) {
+			select {
+			case <-ctx.Done():
+				if ctx.Err() == context.Canceled {
+					return // Superseded by a newer command
+				}
+				s.logger.Warn().
+					Err(ctx.Err()).
+					Uint8("node", nodeID).
+					Float64("target", target).
+					Msg("Interlock sequence aborted, required nodes did not arrive")
+				return
+			case <-ticker.C:
+			}
+		}
+
+		// Maintenance or a lock may have started while waiting
+		if err := s.checkMove(ctx, nodeID, target); err != nil {
+			s.logger.Warn().Err(err).Uint8("node", nodeID).Float64("target", target).Msg("Interlock sequence: command rejected")
+			return
+		}
+		if err := send(ctx); err != nil {
+			s.logger.Error().Err(err).Uint8("node", nodeID).Float64("target", target).Msg("Interlock sequence: command failed")
+			return
+		}
+		s.logger.Info().Uint8("node", nodeID).Float64("target", target).Msg("Interlock sequence completed")
+	}()
+}
+
+// stepsDone reports whether all required nodes have stopped within their allowed range
+func (s *Service) stepsDone(steps []interlockStep, tolerance float64) bool {
+	for _, step := range steps {
+		node, ok := s.GetNode(step.nodeID)
+		if !ok || node.State == klf200.NodeStateExecuting {
+			return false
+		}
+		if _, satisfied := interlockTarget(step.rule, node.PositionPercent, tolerance); !satisfied {
+			return false
+		}
+	}
+	return true
+}
+
+// cancelSequence aborts a pending sequenced command for a node (a newer command supersedes it)
+func (s *Service) cancelSequence(nodeID uint8) {
+	s.interlocks.mu.Lock()
+	defer s.interlocks.mu.Unlock()
+
+	if p, ok := s.interlocks.pending[nodeID]; ok {
+		p.cancel()
+		delete(s.interlocks.pending, nodeID)
+		s.logger.Info().Uint8("node", nodeID).Msg("Pending interlock sequence cancelled")
+	}
+}
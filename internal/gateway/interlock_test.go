@@ -0,0 +1,188 @@
+package gateway
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestPlanInterlocks(t *testing.T) {
+	// The awning (node 2) may only close while the window (node 1) is at most 20% closed
+	awning := config.InterlockRule{
+		ID: "awning", Enabled: true, NodeID: 2, Direction: config.InterlockDirectionClose,
+		RequireNodeID: 1, RequireMax: floatPtr(20),
+	}
+	sequenced := awning
+	sequenced.Sequence = true
+	disabled := awning
+	disabled.Enabled = false
+	anyDirection := awning
+	anyDirection.Direction = config.InterlockDirectionAny
+	// The window (node 1) may only open while the blind (node 3) is at least 50% closed
+	window := config.InterlockRule{
+		ID: "window", Enabled: true, NodeID: 1, Direction: config.InterlockDirectionOpen,
+		RequireNodeID: 3, RequireMin: floatPtr(50),
+	}
+	// Further rules of the awning on the window, sequenced
+	atMost40 := config.InterlockRule{
+		ID: "at-most-40", Enabled: true, NodeID: 2, Direction: config.InterlockDirectionAny,
+		RequireNodeID: 1, RequireMax: floatPtr(40), Sequence: true,
+	}
+	atLeast10 := config.InterlockRule{
+		ID: "at-least-10", Enabled: true, NodeID: 2, Direction: config.InterlockDirectionAny,
+		RequireNodeID: 1, RequireMin: floatPtr(10), Sequence: true,
+	}
+	atLeast30 := atLeast10
+	atLeast30.ID, atLeast30.RequireMin = "at-least-30", floatPtr(30)
+	// The window may not open further than 25%
+	windowLimited := []config.NodeMapping{{ID: "w", NodeID: 1, Enabled: true, MinPosition: floatPtr(25)}}
+
+	tests := []struct {
+		name          string
+		rules         []config.InterlockRule
+		tolerance     float64
+		mappings      []config.NodeMapping
+		window        float64 // Position of node 1
+		nodeID        uint8
+		target        float64
+		allowSequence bool
+		wantSteps     []interlockStep
+		wantErr       bool
+		wantPosition  *float64
+	}{
+		{name: "no rules", window: 100, nodeID: 2, target: 100},
+		{name: "satisfied", rules: []config.InterlockRule{awning}, window: 10, nodeID: 2, target: 100},
+		{name: "within tolerance", rules: []config.InterlockRule{awning}, tolerance: 5, window: 24, nodeID: 2, target: 100},
+		{name: "other direction", rules: []config.InterlockRule{awning}, window: 100, nodeID: 2, target: 0},
+		{name: "any direction", rules: []config.InterlockRule{anyDirection}, window: 100, nodeID: 2, target: 0, wantErr: true, wantPosition: floatPtr(100)},
+		{name: "already there", rules: []config.InterlockRule{awning}, window: 100, nodeID: 2, target: 50},
+		{name: "disabled", rules: []config.InterlockRule{disabled}, window: 100, nodeID: 2, target: 100},
+		{name: "violated", rules: []config.InterlockRule{awning}, window: 100, nodeID: 2, target: 100, allowSequence: true, wantErr: true, wantPosition: floatPtr(100)},
+		{
+			name: "sequenced", rules: []config.InterlockRule{sequenced}, window: 100, nodeID: 2, target: 100, allowSequence: true,
+			wantSteps: []interlockStep{{rule: sequenced, nodeID: 1, position: 20}},
+		},
+		{name: "sequencing not allowed", rules: []config.InterlockRule{sequenced}, window: 100, nodeID: 2, target: 100, wantErr: true, wantPosition: floatPtr(100)},
+		{name: "required move violates a rule", rules: []config.InterlockRule{sequenced, window}, window: 100, nodeID: 2, target: 100, allowSequence: true, wantErr: true, wantPosition: floatPtr(0)},
+		{name: "required node unknown", rules: []config.InterlockRule{{ID: "x", Enabled: true, NodeID: 2, Direction: config.InterlockDirectionAny, RequireNodeID: 9, RequireMax: floatPtr(20)}}, window: 0, nodeID: 2, target: 100, wantErr: true},
+		{
+			name: "required node limits", rules: []config.InterlockRule{atLeast10}, mappings: windowLimited, window: 0, nodeID: 2, target: 100, allowSequence: true,
+			wantSteps: []interlockStep{{rule: atLeast10, nodeID: 1, position: 25}},
+		},
+		{name: "required position outside the limits", rules: []config.InterlockRule{sequenced}, mappings: windowLimited, window: 100, nodeID: 2, target: 100, allowSequence: true, wantErr: true, wantPosition: floatPtr(100)},
+		{
+			name: "rules agree on one position", rules: []config.InterlockRule{sequenced, atLeast10}, window: 100, nodeID: 2, target: 100, allowSequence: true,
+			wantSteps: []interlockStep{{rule: sequenced, nodeID: 1, position: 20}},
+		},
+		{
+			name: "step keeps a satisfied rule", rules: []config.InterlockRule{atLeast10, atMost40}, window: 0, nodeID: 2, target: 100, allowSequence: true,
+			wantSteps: []interlockStep{{rule: atLeast10, nodeID: 1, position: 10}},
+		},
+		{name: "conflicting ranges", rules: []config.InterlockRule{sequenced, atLeast30}, window: 100, nodeID: 2, target: 100, allowSequence: true, wantErr: true, wantPosition: floatPtr(100)},
+		{name: "commanded node unknown", rules: []config.InterlockRule{{ID: "x", Enabled: true, NodeID: 8, Direction: config.InterlockDirectionOpen, RequireNodeID: 1, RequireMax: floatPtr(20)}}, window: 100, nodeID: 8, target: 100, wantErr: true, wantPosition: floatPtr(100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(
+				&klf200.Node{ID: 1, PositionPercent: tt.window},
+				&klf200.Node{ID: 2, PositionPercent: 50},
+				&klf200.Node{ID: 3, PositionPercent: 0},
+			)
+			s.mappingManager.Load(tt.mappings)
+			s.SetInterlockConfig(config.InterlockConfig{Rules: tt.rules, Tolerance: tt.tolerance})
+
+			steps, err := s.planInterlocks(tt.nodeID, tt.target, tt.allowSequence)
+			if tt.wantErr {
+				var interlockErr *InterlockError
+				if !errors.As(err, &interlockErr) {
+					t.Fatalf("error = %v, want an InterlockError", err)
+				}
+				if (interlockErr.Position == nil) != (tt.wantPosition == nil) ||
+					(tt.wantPosition != nil && *interlockErr.Position != *tt.wantPosition) {
+					t.Errorf("position = %v, want %v", interlockErr.Position, tt.wantPosition)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(steps) != len(tt.wantSteps) {
+				t.Fatalf("steps = %+v, want %+v", steps, tt.wantSteps)
+			}
+			for i, step := range steps {
+				want := tt.wantSteps[i]
+				if step.rule.ID != want.rule.ID || step.nodeID != want.nodeID || step.position != want.position {
+					t.Errorf("step %d = %+v, want %+v", i, step, want)
+				}
+			}
+		})
+	}
+}
+
+func TestInterlockTarget(t *testing.T) {
+	rule := config.InterlockRule{RequireMin: floatPtr(30), RequireMax: floatPtr(70)}
+
+	tests := []struct {
+		name          string
+		pos           float64
+		tolerance     float64
+		wantPosition  float64
+		wantSatisfied bool
+	}{
+		{name: "in range", pos: 50, wantPosition: 50, wantSatisfied: true},
+		{name: "below", pos: 10, wantPosition: 30},
+		{name: "above", pos: 90, wantPosition: 70},
+		{name: "below within tolerance", pos: 28, tolerance: 2, wantPosition: 28, wantSatisfied: true},
+		{name: "above within tolerance", pos: 72, tolerance: 2, wantPosition: 72, wantSatisfied: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			position, satisfied := interlockTarget(rule, tt.pos, tt.tolerance)
+			if position != tt.wantPosition || satisfied != tt.wantSatisfied {
+				t.Errorf("got %v, %v, want %v, %v", position, satisfied, tt.wantPosition, tt.wantSatisfied)
+			}
+		})
+	}
+}
+
+func TestStepsDone(t *testing.T) {
+	step := interlockStep{rule: config.InterlockRule{RequireMax: floatPtr(20)}, nodeID: 1, position: 20}
+
+	tests := []struct {
+		name  string
+		nodes []*klf200.Node
+		want  bool
+	}{
+		{name: "arrived", nodes: []*klf200.Node{{ID: 1, PositionPercent: 20, State: klf200.NodeStateDone}}, want: true},
+		{name: "still moving", nodes: []*klf200.Node{{ID: 1, PositionPercent: 20, State: klf200.NodeStateExecuting}}},
+		{name: "stopped out of range", nodes: []*klf200.Node{{ID: 1, PositionPercent: 60, State: klf200.NodeStateDone}}},
+		{name: "node unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(tt.nodes...)
+			if got := s.stepsDone([]interlockStep{step}, 0); got != tt.want {
+				t.Errorf("stepsDone = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterlockErrorMessage(t *testing.T) {
+	err := &InterlockError{
+		Rule:     config.InterlockRule{Name: "Awning", Direction: config.InterlockDirectionClose, RequireNodeID: 1, RequireMax: floatPtr(20)},
+		NodeID:   2,
+		Position: floatPtr(100),
+	}
+	want := `interlock "Awning": node 2 may only close while node 1 is at most 20% (currently 100%)`
+	if got := err.Error(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// LimitationRequest describes a limitation enforced by the KLF-200 itself
+// (positions 0 = open, 100 = closed, nil = leave unchanged)
+type LimitationRequest struct {
+	MinPosition *float64
+	MaxPosition *float64
+	Duration    time.Duration // 0 = until cleared
+	Originator  klf200.Originator
+	Priority    klf200.Priority
+}
+
+// SetLimitation restricts the travel range of a node on the device
+func (s *Service) SetLimitation(ctx context.Context, nodeID uint8, req LimitationRequest) error {
+	if _, ok := s.nodes.GetNode(nodeID); !ok {
+		return klf200.ErrNodeNotFound
+	}
+	if !s.client.IsAuthenticated() {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+
+	limitationTime, err := klf200.LimitationTimeFromDuration(req.Duration)
+	if err != nil {
+		return err
+	}
+
+	mapping := s.mappingManager.GetByNodeID(nodeID)
+	minValue, maxValue := klf200.PositionIgnore, klf200.PositionIgnore
+	if req.MinPosition != nil {
+		minValue = klf200.PercentToPosition(toDevicePercent(mapping, *req.MinPosition))
+	}
+	if req.MaxPosition != nil {
+		maxValue = klf200.PercentToPosition(toDevicePercent(mapping, *req.MaxPosition))
+	}
+
+	s.logger.Info().
+		Uint8("node", nodeID).
+		Str("originator", req.Originator.String()).
+		Dur("duration", req.Duration).
+		Msg("Setting limitation")
+
+	return s.client.SetLimitation(ctx, []uint8{nodeID}, req.Originator, req.Priority, minValue, maxValue, limitationTime)
+}
+
+// ClearLimitation removes the limitations set by an originator on a node
+func (s *Service) ClearLimitation(ctx context.Context, nodeID uint8, originator klf200.Originator, priority klf200.Priority) error {
+	if _, ok := s.nodes.GetNode(nodeID); !ok {
+		return klf200.ErrNodeNotFound
+	}
+	if !s.client.IsAuthenticated() {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+
+	s.logger.Info().Uint8("node", nodeID).Str("originator", originator.String()).Msg("Clearing limitation")
+
+	return s.client.ClearLimitation(ctx, []uint8{nodeID}, originator, priority)
+}
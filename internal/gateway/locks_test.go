@@ -0,0 +1,42 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+func TestCheckLock(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		lock    *klf200.NodeLock
+		source  CommandSource
+		wantErr bool
+	}{
+		{name: "not locked", source: SourceLoxone},
+		{name: "locked until released", lock: &klf200.NodeLock{}, source: SourceLoxone, wantErr: true},
+		{name: "locked until later", lock: &klf200.NodeLock{Expires: &future}, source: SourceAPI, wantErr: true},
+		{name: "lock expired", lock: &klf200.NodeLock{Expires: &past}, source: SourceLoxone},
+		{name: "manual overrides", lock: &klf200.NodeLock{}, source: SourceManual},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(&klf200.Node{ID: 1, Lock: tt.lock})
+
+			err := s.checkLock(WithSource(context.Background(), tt.source), 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkLock = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrNodeLocked) {
+				t.Errorf("checkLock = %v, want ErrNodeLocked", err)
+			}
+		})
+	}
+}
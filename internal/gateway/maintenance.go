@@ -0,0 +1,269 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/events"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+	"github.com/stefanbeyeler/loxone2velux/internal/storage"
+)
+
+// defaultMaintenanceDuration is used when maintenance is started without a duration
+const defaultMaintenanceDuration = 2 * time.Hour
+
+var (
+	// ErrMaintenance is returned when a command without admin rights tries to move a node in maintenance
+	ErrMaintenance = errors.New("maintenance mode is active")
+	// ErrNoMaintenance is returned when ending a maintenance that is not active
+	ErrNoMaintenance = errors.New("no maintenance active")
+)
+
+// CommandSource identifies who issued a node command
+type CommandSource string
+
+const (
+	SourceManual   CommandSource = "manual"   // REST API and web UI with the admin token
+	SourceAPI      CommandSource = "api"      // REST API and web UI with the API token
+	SourceLoxone   CommandSource = "loxone"   // Loxone routes
+	SourceSchedule CommandSource = "schedule" // Schedules
+	SourceRule     CommandSource = "rule"     // Sun protection rules, restores after rain and wind
+)
+
+type sourceKey struct{}
+
+// WithSource returns a context carrying the source of the node commands issued with it
+func WithSource(ctx context.Context, source CommandSource) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// SourceFrom returns the command source of the context (default: api)
+func SourceFrom(ctx context.Context) CommandSource {
+	if source, ok := ctx.Value(sourceKey{}).(CommandSource); ok {
+		return source
+	}
+	return SourceAPI
+}
+
+// canOverride reports whether the commands of the context may move nodes in maintenance
+// and locked nodes. Only manual commands of an admin may, every other source is rejected.
+func canOverride(ctx context.Context) bool {
+	return SourceFrom(ctx) == SourceManual
+}
+
+// maintenance holds the active maintenance windows, for all nodes and per node
+type maintenance struct {
+	mu     sync.Mutex
+	global *maintenanceEntry
+	nodes  map[uint8]*maintenanceEntry
+}
+
+// maintenanceEntry is an active maintenance window and its expiry timer
+type maintenanceEntry struct {
+	window storage.MaintenanceWindow
+	timer  *time.Timer
+}
+
+// StartMaintenance blocks commands from Loxone, schedules and rules for a node
+// (nil = all nodes) until the duration (0 = default) has passed or the maintenance is ended.
+// Manual commands and protective moves are still executed.
+func (s *Service) StartMaintenance(nodeID *uint8, duration time.Duration, reason string) (*storage.MaintenanceWindow, error) {
+	if nodeID != nil {
+		if _, ok := s.nodes.GetNode(*nodeID); !ok {
+			return nil, klf200.ErrNodeNotFound
+		}
+	}
+	if duration < 0 {
+		return nil, fmt.Errorf("duration must not be negative")
+	}
+	if duration == 0 {
+		duration = defaultMaintenanceDuration
+	}
+
+	now := time.Now()
+	window := storage.MaintenanceWindow{NodeID: nodeID, Reason: reason, Since: now, Expires: now.Add(duration)}
+	s.setMaintenance(window)
+
+	s.logger.Info().Str("scope", maintenanceScope(nodeID)).Dur("duration", duration).Str("reason", reason).Msg("Maintenance started")
+	s.events.Publish(events.Event{
+		Type:    events.TypeMaintenanceStarted,
+		NodeID:  nodeID,
+		Message: fmt.Sprintf("Maintenance started for %s", maintenanceScope(nodeID)),
+		Data:    window,
+	})
+
+	return &window, nil
+}
+
+// EndMaintenance ends the maintenance of a node (nil = the maintenance of all nodes)
+func (s *Service) EndMaintenance(nodeID *uint8) error {
+	s.maintenance.mu.Lock()
+	entry := s.maintenance.global
+	if nodeID != nil {
+		entry = s.maintenance.nodes[*nodeID]
+	}
+	s.maintenance.mu.Unlock()
+
+	if entry == nil || !s.endMaintenance(entry, "Maintenance ended") {
+		return ErrNoMaintenance
+	}
+	return nil
+}
+
+// GetMaintenance returns the active maintenance windows, the one for all nodes first
+func (s *Service) GetMaintenance() []storage.MaintenanceWindow {
+	s.maintenance.mu.Lock()
+	defer s.maintenance.mu.Unlock()
+
+	return s.maintenanceWindows()
+}
+
+// maintenanceWindows returns the active windows; the caller holds the lock
+func (s *Service) maintenanceWindows() []storage.MaintenanceWindow {
+	windows := []storage.MaintenanceWindow{}
+	if s.maintenance.global != nil {
+		windows = append(windows, s.maintenance.global.window)
+	}
+	nodeWindows := make([]storage.MaintenanceWindow, 0, len(s.maintenance.nodes))
+	for _, entry := range s.maintenance.nodes {
+		nodeWindows = append(nodeWindows, entry.window)
+	}
+	sort.Slice(nodeWindows, func(i, j int) bool { return *nodeWindows[i].NodeID < *nodeWindows[j].NodeID })
+	return append(windows, nodeWindows...)
+}
+
+// InMaintenance returns true if the node is covered by an active maintenance window
+func (s *Service) InMaintenance(nodeID uint8) bool {
+	s.maintenance.mu.Lock()
+	defer s.maintenance.mu.Unlock()
+
+	now := time.Now()
+	if e := s.maintenance.global; e != nil && now.Before(e.window.Expires) {
+		return true
+	}
+	e, ok := s.maintenance.nodes[nodeID]
+	return ok && now.Before(e.window.Expires)
+}
+
+// checkMaintenance rejects commands from automation for nodes in maintenance
+func (s *Service) checkMaintenance(ctx context.Context, nodeID uint8) error {
+	if canOverride(ctx) || !s.InMaintenance(nodeID) {
+		return nil
+	}
+	return fmt.Errorf("node %d: %w, %s commands are rejected", nodeID, ErrMaintenance, SourceFrom(ctx))
+}
+
+// setMaintenance activates a window, replacing an existing one for the same scope
+func (s *Service) setMaintenance(window storage.MaintenanceWindow) {
+	entry := &maintenanceEntry{window: window}
+
+	s.maintenance.mu.Lock()
+	var previous *maintenanceEntry
+	if window.NodeID == nil {
+		previous = s.maintenance.global
+		s.maintenance.global = entry
+	} else {
+		if s.maintenance.nodes == nil {
+			s.maintenance.nodes = make(map[uint8]*maintenanceEntry)
+		}
+		previous = s.maintenance.nodes[*window.NodeID]
+		s.maintenance.nodes[*window.NodeID] = entry
+	}
+	if previous != nil {
+		previous.timer.Stop()
+	}
+	entry.timer = time.AfterFunc(time.Until(window.Expires), func() {
+		s.endMaintenance(entry, "Maintenance expired")
+	})
+	s.persistMaintenance()
+	s.maintenance.mu.Unlock()
+
+	s.sendMaintenanceUDPFeedback(window.NodeID)
+}
+
+// endMaintenance removes the window if it is still active. Returns false if it was already removed.
+func (s *Service) endMaintenance(entry *maintenanceEntry, message string) bool {
+	nodeID := entry.window.NodeID
+
+	s.maintenance.mu.Lock()
+	if nodeID == nil && s.maintenance.global == entry {
+		s.maintenance.global = nil
+	} else if nodeID != nil && s.maintenance.nodes[*nodeID] == entry {
+		delete(s.maintenance.nodes, *nodeID)
+	} else {
+		s.maintenance.mu.Unlock()
+		return false
+	}
+	entry.timer.Stop()
+	s.persistMaintenance()
+	s.maintenance.mu.Unlock()
+
+	s.sendMaintenanceUDPFeedback(nodeID)
+
+	s.logger.Info().Str("scope", maintenanceScope(nodeID)).Msg(message)
+	s.events.Publish(events.Event{
+		Type:    events.TypeMaintenanceEnded,
+		NodeID:  nodeID,
+		Message: fmt.Sprintf("%s for %s", message, maintenanceScope(nodeID)),
+		Data:    entry.window,
+	})
+	return true
+}
+
+// restoreMaintenance re-activates the persisted maintenance windows that have not expired
+func (s *Service) restoreMaintenance() {
+	windows, err := s.store.LoadMaintenance()
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to load persisted maintenance")
+		return
+	}
+
+	now := time.Now()
+	for _, window := range windows {
+		if !now.Before(window.Expires) {
+			continue
+		}
+		s.setMaintenance(window)
+		s.logger.Info().Str("scope", maintenanceScope(window.NodeID)).Time("expires", window.Expires).Msg("Restored maintenance")
+	}
+}
+
+// persistMaintenance stores the active windows; the caller holds the lock
+func (s *Service) persistMaintenance() {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.SaveMaintenance(s.maintenanceWindows()); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to persist maintenance")
+	}
+}
+
+// sendMaintenanceUDPFeedback sends the maintenance property of the mappings of a node (nil = all mappings)
+func (s *Service) sendMaintenanceUDPFeedback(nodeID *uint8) {
+	if !s.udpSender.IsEnabled() {
+		return
+	}
+
+	for _, mapping := range s.mappingManager.GetAll() {
+		if !mapping.Enabled || (nodeID != nil && mapping.NodeID != *nodeID) {
+			continue
+		}
+		value := 0
+		if s.InMaintenance(mapping.NodeID) {
+			value = 1
+		}
+		s.udpSender.Send(mapping.LoxoneID, "maintenance", value)
+	}
+}
+
+// maintenanceScope describes the nodes covered by a maintenance window
+func maintenanceScope(nodeID *uint8) string {
+	if nodeID == nil {
+		return "all nodes"
+	}
+	return fmt.Sprintf("node %d", *nodeID)
+}
@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+func TestCheckMaintenance(t *testing.T) {
+	node1, node2 := uint8(1), uint8(2)
+
+	tests := []struct {
+		name    string
+		window  *uint8 // node of the maintenance window, nil = all nodes
+		active  bool
+		nodeID  uint8
+		source  CommandSource
+		wantErr bool
+	}{
+		{name: "no maintenance", nodeID: 1, source: SourceLoxone},
+		{name: "all nodes, loxone", active: true, nodeID: 1, source: SourceLoxone, wantErr: true},
+		{name: "all nodes, api", active: true, nodeID: 2, source: SourceAPI, wantErr: true},
+		{name: "all nodes, schedule", active: true, nodeID: 1, source: SourceSchedule, wantErr: true},
+		{name: "all nodes, rule", active: true, nodeID: 1, source: SourceRule, wantErr: true},
+		{name: "all nodes, manual", active: true, nodeID: 1, source: SourceManual},
+		{name: "same node", window: &node1, active: true, nodeID: 1, source: SourceLoxone, wantErr: true},
+		{name: "other node", window: &node2, active: true, nodeID: 1, source: SourceLoxone},
+		{name: "same node, manual", window: &node1, active: true, nodeID: 1, source: SourceManual},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(&klf200.Node{ID: 1}, &klf200.Node{ID: 2})
+			if tt.active {
+				if _, err := s.StartMaintenance(tt.window, time.Hour, "test"); err != nil {
+					t.Fatalf("StartMaintenance: %v", err)
+				}
+			}
+
+			err := s.checkMaintenance(WithSource(context.Background(), tt.source), tt.nodeID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkMaintenance = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrMaintenance) {
+				t.Errorf("checkMaintenance = %v, want ErrMaintenance", err)
+			}
+		})
+	}
+}
+
+func TestStartMaintenanceValidation(t *testing.T) {
+	s := newTestService(&klf200.Node{ID: 1})
+
+	unknown := uint8(9)
+	if _, err := s.StartMaintenance(&unknown, time.Hour, ""); !errors.Is(err, klf200.ErrNodeNotFound) {
+		t.Errorf("unknown node: err = %v, want ErrNodeNotFound", err)
+	}
+	if _, err := s.StartMaintenance(nil, -time.Minute, ""); err == nil {
+		t.Error("negative duration: expected an error")
+	}
+
+	window, err := s.StartMaintenance(nil, 0, "")
+	if err != nil {
+		t.Fatalf("StartMaintenance: %v", err)
+	}
+	if got := window.Expires.Sub(window.Since); got != defaultMaintenanceDuration {
+		t.Errorf("default duration = %s, want %s", got, defaultMaintenanceDuration)
+	}
+	if err := s.EndMaintenance(nil); err != nil {
+		t.Errorf("EndMaintenance: %v", err)
+	}
+	if err := s.EndMaintenance(nil); !errors.Is(err, ErrNoMaintenance) {
+		t.Errorf("second EndMaintenance: err = %v, want ErrNoMaintenance", err)
+	}
+}
+
+func TestMaintenanceExpires(t *testing.T) {
+	s := newTestService(&klf200.Node{ID: 1})
+	nodeID := uint8(1)
+
+	if _, err := s.StartMaintenance(&nodeID, 20*time.Millisecond, "cleaning"); err != nil {
+		t.Fatalf("StartMaintenance: %v", err)
+	}
+	if !s.InMaintenance(1) {
+		t.Fatal("node not in maintenance after start")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.GetMaintenance()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("maintenance did not expire")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s.InMaintenance(1) {
+		t.Error("node still in maintenance after expiry")
+	}
+	if err := s.checkMaintenance(WithSource(context.Background(), SourceLoxone), 1); err != nil {
+		t.Errorf("checkMaintenance after expiry = %v", err)
+	}
+}
+
+func TestMaintenanceReplacesWindow(t *testing.T) {
+	s := newTestService(&klf200.Node{ID: 1})
+
+	if _, err := s.StartMaintenance(nil, 20*time.Millisecond, "first"); err != nil {
+		t.Fatalf("StartMaintenance: %v", err)
+	}
+	if _, err := s.StartMaintenance(nil, time.Hour, "second"); err != nil {
+		t.Fatalf("StartMaintenance: %v", err)
+	}
+
+	// The timer of the replaced window must not end the new one
+	time.Sleep(50 * time.Millisecond)
+	windows := s.GetMaintenance()
+	if len(windows) != 1 || windows[0].Reason != "second" {
+		t.Errorf("windows = %+v, want the second window", windows)
+	}
+}
@@ -0,0 +1,47 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// GetNetworkSetup reads the LAN configuration of the KLF-200
+func (s *Service) GetNetworkSetup(ctx context.Context) (*klf200.NetworkSetup, error) {
+	if !s.client.IsAuthenticated() {
+		return nil, fmt.Errorf("not connected to KLF-200")
+	}
+	return s.client.GetNetworkSetup(ctx)
+}
+
+// SetNetworkSetup changes the LAN configuration of the KLF-200.
+// The new setup becomes active after a reboot of the KLF-200.
+func (s *Service) SetNetworkSetup(ctx context.Context, setup klf200.NetworkSetup) error {
+	if !s.client.IsAuthenticated() {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+
+	if !setup.DHCP {
+		if setup.IP.To4() == nil || setup.Mask.To4() == nil || setup.Gateway.To4() == nil {
+			return fmt.Errorf("ip, mask and gateway must be IPv4 addresses for a static setup")
+		}
+		if setup.IP.IsUnspecified() {
+			return fmt.Errorf("ip must not be 0.0.0.0")
+		}
+	}
+
+	return s.client.SetNetworkSetup(ctx, setup)
+}
+
+// RebootGateway restarts the KLF-200. The connection is closed and
+// re-established by the reconnect loop once the KLF-200 is back,
+// using the host from the current configuration.
+func (s *Service) RebootGateway(ctx context.Context) error {
+	if !s.client.IsAuthenticated() {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+
+	s.logger.Warn().Msg("Rebooting KLF-200, connection will be re-established automatically")
+	return s.client.Reboot(ctx)
+}
@@ -0,0 +1,184 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/events"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// NodeRenamed is the event data of a node_renamed event
+type NodeRenamed struct {
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+}
+
+// NodeSettings are the node settings stored in the KLF-200. Nil fields are left unchanged.
+type NodeSettings struct {
+	Name      *string          `json:"name,omitempty"`
+	Order     *uint16          `json:"order,omitempty"`
+	Placement *uint8           `json:"placement,omitempty"`
+	Velocity  *klf200.Velocity `json:"velocity,omitempty"`
+}
+
+// Events returns the event hub of the gateway
+func (s *Service) Events() *events.Hub {
+	return s.events
+}
+
+// RefreshNode re-reads the information of a single node from the KLF-200
+func (s *Service) RefreshNode(ctx context.Context, nodeID uint8) (*klf200.Node, error) {
+	if !s.client.IsAuthenticated() {
+		return nil, fmt.Errorf("not connected to KLF-200")
+	}
+
+	previous, known := s.nodes.GetNode(nodeID)
+
+	node, err := s.client.GetNodeInformation(ctx, nodeID)
+	if errors.Is(err, klf200.ErrNodeNotFound) {
+		if known {
+			s.removeNode(previous)
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.nodes.SetNode(node)
+	if known {
+		s.diffNode(previous, node)
+	} else {
+		s.publishNodeAdded(node)
+	}
+
+	s.sendNodeUDPFeedback(node)
+	if current, ok := s.nodes.GetNode(nodeID); ok {
+		s.persistNode(previous, current)
+	}
+
+	current, _ := s.GetNode(nodeID)
+	return current, nil
+}
+
+// ConfigureNode changes the name, order/placement and velocity of a node in the KLF-200
+// and returns the node as re-read afterwards
+func (s *Service) ConfigureNode(ctx context.Context, nodeID uint8, settings NodeSettings) (*klf200.Node, error) {
+	if !s.client.IsAuthenticated() {
+		return nil, fmt.Errorf("not connected to KLF-200")
+	}
+
+	node, ok := s.nodes.GetNode(nodeID)
+	if !ok {
+		return nil, klf200.ErrNodeNotFound
+	}
+
+	if settings.Name != nil && *settings.Name != node.Name {
+		if err := s.client.SetNodeName(ctx, nodeID, *settings.Name); err != nil {
+			return nil, fmt.Errorf("failed to set name: %w", err)
+		}
+	}
+
+	if settings.Order != nil || settings.Placement != nil {
+		// Order and placement are always set together
+		order, placement := node.Order, node.Placement
+		if settings.Order != nil {
+			order = *settings.Order
+		}
+		if settings.Placement != nil {
+			placement = *settings.Placement
+		}
+		if order != node.Order || placement != node.Placement {
+			if err := s.client.SetNodeOrderAndPlacement(ctx, nodeID, order, placement); err != nil {
+				return nil, fmt.Errorf("failed to set order and placement: %w", err)
+			}
+		}
+	}
+
+	if settings.Velocity != nil && *settings.Velocity != node.Velocity {
+		if err := s.client.SetNodeVelocity(ctx, nodeID, *settings.Velocity); err != nil {
+			return nil, fmt.Errorf("failed to set velocity: %w", err)
+		}
+	}
+
+	// Re-read the node so the NodeManager holds what the KLF-200 actually stored
+	return s.RefreshNode(ctx, nodeID)
+}
+
+// diffNodes emits events for nodes that were added, removed or renamed between two node lists
+func (s *Service) diffNodes(previous map[uint8]*klf200.Node, nodes []*klf200.Node) {
+	seen := make(map[uint8]bool, len(nodes))
+	for _, node := range nodes {
+		seen[node.ID] = true
+		if old, ok := previous[node.ID]; ok {
+			s.diffNode(old, node)
+		} else {
+			s.publishNodeAdded(node)
+		}
+	}
+
+	for id, old := range previous {
+		if !seen[id] {
+			s.nodeRemoved(old)
+		}
+	}
+}
+
+// diffNode emits a rename event if the name of a node changed
+func (s *Service) diffNode(old, node *klf200.Node) {
+	if old.Name == node.Name {
+		return
+	}
+
+	s.logger.Info().Uint8("id", node.ID).Str("old", old.Name).Str("new", node.Name).Msg("Node renamed")
+	s.events.PublishNode(events.TypeNodeRenamed, node.ID,
+		fmt.Sprintf("Node %d renamed from %q to %q", node.ID, old.Name, node.Name),
+		NodeRenamed{OldName: old.Name, NewName: node.Name})
+}
+
+func (s *Service) publishNodeAdded(node *klf200.Node) {
+	s.logger.Info().Uint8("id", node.ID).Str("name", node.Name).Msg("Node added")
+	s.events.PublishNode(events.TypeNodeAdded, node.ID,
+		fmt.Sprintf("Node %d (%s) added", node.ID, node.Name), node)
+}
+
+// removeNode drops a node that no longer exists on the KLF-200
+func (s *Service) removeNode(node *klf200.Node) {
+	s.nodes.RemoveNode(node.ID)
+	s.nodeRemoved(node)
+}
+
+// nodeRemoved cleans up after a removed node and emits a node_removed event
+func (s *Service) nodeRemoved(node *klf200.Node) {
+	if s.store != nil {
+		if err := s.store.DeleteNode(node.ID); err != nil {
+			s.logger.Warn().Err(err).Uint8("id", node.ID).Msg("Failed to delete persisted node")
+		}
+	}
+
+	message := fmt.Sprintf("Node %d (%s) removed", node.ID, node.Name)
+	if mapping := s.mappingManager.GetByNodeID(node.ID); mapping != nil {
+		message += fmt.Sprintf(", mapping %q now points to a missing node", mapping.Name)
+	}
+
+	s.logger.Warn().Uint8("id", node.ID).Str("name", node.Name).Msg("Node removed")
+	s.events.PublishNode(events.TypeNodeRemoved, node.ID, message, node)
+}
+
+// OrphanedMappings returns the mappings that point to node IDs unknown to the KLF-200
+func (s *Service) OrphanedMappings() []config.NodeMapping {
+	if s.nodes.NodeCount() == 0 {
+		return nil // Nodes not loaded yet, nothing to compare against
+	}
+
+	var orphaned []config.NodeMapping
+	for _, mapping := range s.mappingManager.GetAll() {
+		if _, ok := s.nodes.GetNode(mapping.NodeID); !ok {
+			orphaned = append(orphaned, mapping)
+		}
+	}
+	return orphaned
+}
@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/events"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+func TestDiffNodes(t *testing.T) {
+	kitchen := &klf200.Node{ID: 1, Name: "Kitchen"}
+	bath := &klf200.Node{ID: 2, Name: "Bath"}
+
+	tests := []struct {
+		name     string
+		previous []*klf200.Node
+		nodes    []*klf200.Node
+		want     []string
+	}{
+		{name: "unchanged", previous: []*klf200.Node{kitchen, bath}, nodes: []*klf200.Node{kitchen, bath}},
+		{name: "added", previous: []*klf200.Node{kitchen}, nodes: []*klf200.Node{kitchen, bath}, want: []string{events.TypeNodeAdded}},
+		{name: "removed", previous: []*klf200.Node{kitchen, bath}, nodes: []*klf200.Node{kitchen}, want: []string{events.TypeNodeRemoved}},
+		{name: "renamed", previous: []*klf200.Node{kitchen}, nodes: []*klf200.Node{{ID: 1, Name: "Dining"}}, want: []string{events.TypeNodeRenamed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService()
+			previous := make(map[uint8]*klf200.Node)
+			for _, node := range tt.previous {
+				previous[node.ID] = node
+			}
+
+			s.diffNodes(previous, tt.nodes)
+
+			var got []string
+			for _, e := range s.Events().Recent(0) {
+				got = append(got, e.Type)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrphanedMappings(t *testing.T) {
+	mappings := []config.NodeMapping{
+		{Name: "Kitchen", NodeID: 1},
+		{Name: "Old window", NodeID: 9},
+	}
+
+	tests := []struct {
+		name  string
+		nodes []*klf200.Node
+		want  []string
+	}{
+		{name: "nodes not loaded", want: nil},
+		{name: "missing node", nodes: []*klf200.Node{{ID: 1}}, want: []string{"Old window"}},
+		{name: "all present", nodes: []*klf200.Node{{ID: 1}, {ID: 9}}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(tt.nodes...)
+			s.mappingManager.Load(mappings)
+
+			var got []string
+			for _, m := range s.OrphanedMappings() {
+				got = append(got, m.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orphaned = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
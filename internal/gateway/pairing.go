@@ -0,0 +1,247 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/events"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// pairingTimeout is how long a discovery or controller copy may take before it is reported as failed
+const pairingTimeout = 10 * time.Minute
+
+// Pairing operations
+const (
+	PairingDiscover       = "discover"
+	PairingControllerCopy = "controller_copy"
+)
+
+// Pairing job states
+const (
+	PairingRunning   = "running"
+	PairingCompleted = "completed"
+	PairingFailed    = "failed"
+)
+
+// ErrPairingBusy is returned when a pairing operation is started while another one is running
+var ErrPairingBusy = errors.New("another pairing operation is running")
+
+// PairingJob is a running or finished configuration service operation
+type PairingJob struct {
+	Operation string                       `json:"operation"`
+	State     string                       `json:"state"`
+	Message   string                       `json:"message,omitempty"`
+	Started   time.Time                    `json:"started"`
+	Finished  *time.Time                   `json:"finished,omitempty"`
+	Discovery *klf200.DiscoveryResult      `json:"discovery,omitempty"`
+	Copy      *klf200.ControllerCopyResult `json:"controller_copy,omitempty"`
+}
+
+// pairing tracks the configuration service operation of the KLF-200 (only one at a time)
+type pairing struct {
+	mu    sync.Mutex
+	job   *PairingJob
+	timer *time.Timer
+}
+
+// GetPairingStatus returns the current or last pairing job, nil if there was none
+func (s *Service) GetPairingStatus() *PairingJob {
+	s.pairing.mu.Lock()
+	defer s.pairing.mu.Unlock()
+
+	if s.pairing.job == nil {
+		return nil
+	}
+	job := *s.pairing.job
+	return &job
+}
+
+// GetSystemTable returns the actuators paired with the KLF-200
+func (s *Service) GetSystemTable(ctx context.Context) ([]klf200.SystemTableEntry, error) {
+	if !s.client.IsAuthenticated() {
+		return nil, fmt.Errorf("not connected to KLF-200")
+	}
+	return s.client.GetSystemTable(ctx)
+}
+
+// StartDiscovery searches for new products (nodeType 0 = all types).
+// The products must be in pairing mode, the result is reported through the event hub.
+func (s *Service) StartDiscovery(ctx context.Context, nodeType uint8) (*PairingJob, error) {
+	return s.startPairing(PairingDiscover, "Searching for new products, put them into pairing mode now",
+		func() error { return s.client.DiscoverNodes(ctx, nodeType) })
+}
+
+// StartControllerCopy copies the system key and products from or to another controller
+func (s *Service) StartControllerCopy(ctx context.Context, mode klf200.ControllerCopyMode) (*PairingJob, error) {
+	message := "Sending configuration, start receiving on the other controller now"
+	if mode == klf200.ControllerCopyReceive {
+		message = "Waiting for configuration, start sending on the other controller now"
+	}
+	return s.startPairing(PairingControllerCopy, message,
+		func() error { return s.client.ControllerCopy(ctx, mode) })
+}
+
+// RemoveNodes removes products from the KLF-200. The node list is re-read afterwards.
+func (s *Service) RemoveNodes(ctx context.Context, nodeIDs []uint8) error {
+	if !s.client.IsAuthenticated() {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+
+	s.pairing.mu.Lock()
+	busy := s.pairing.job != nil && s.pairing.job.State == PairingRunning
+	s.pairing.mu.Unlock()
+	if busy {
+		return ErrPairingBusy
+	}
+
+	if err := s.client.RemoveNodes(ctx, nodeIDs); err != nil {
+		return err
+	}
+
+	s.logger.Info().Interface("nodes", nodeIDs).Msg("Nodes removed from KLF-200")
+	s.refreshNodesAsync()
+	return nil
+}
+
+// startPairing starts a configuration service operation finished by a notification
+func (s *Service) startPairing(operation, message string, start func() error) (*PairingJob, error) {
+	if !s.client.IsAuthenticated() {
+		return nil, fmt.Errorf("not connected to KLF-200")
+	}
+
+	job := &PairingJob{
+		Operation: operation,
+		State:     PairingRunning,
+		Message:   message,
+		Started:   time.Now(),
+	}
+
+	// Reserve the slot first, the lock must not be held while waiting for the
+	// confirmation (notifications handled on the read loop take it too)
+	s.pairing.mu.Lock()
+	if s.pairing.job != nil && s.pairing.job.State == PairingRunning {
+		s.pairing.mu.Unlock()
+		return nil, ErrPairingBusy
+	}
+	previous := s.pairing.job
+	s.pairing.job = job
+	s.pairing.mu.Unlock()
+
+	if err := start(); err != nil {
+		s.pairing.mu.Lock()
+		if s.pairing.job == job {
+			s.pairing.job = previous
+		}
+		s.pairing.mu.Unlock()
+		return nil, err
+	}
+
+	s.pairing.mu.Lock()
+	s.pairing.timer = time.AfterFunc(pairingTimeout, func() {
+		s.finishPairing(operation, PairingFailed, "No result from KLF-200", nil)
+	})
+	result := *job
+	s.pairing.mu.Unlock()
+
+	s.logger.Info().Str("operation", operation).Msg("Pairing started")
+	s.events.Publish(events.Event{Type: events.TypePairingStarted, Message: message, Data: result})
+
+	return &result, nil
+}
+
+// finishPairing completes the running job of the operation
+func (s *Service) finishPairing(operation, state, message string, update func(job *PairingJob)) {
+	s.pairing.mu.Lock()
+	job := s.pairing.job
+	if job == nil || job.State != PairingRunning || job.Operation != operation {
+		s.pairing.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	job.State = state
+	job.Message = message
+	job.Finished = &now
+	if update != nil {
+		update(job)
+	}
+	if s.pairing.timer != nil {
+		s.pairing.timer.Stop()
+	}
+	result := *job
+	s.pairing.mu.Unlock()
+
+	eventType := events.TypePairingCompleted
+	if state == PairingFailed {
+		eventType = events.TypePairingFailed
+		s.logger.Warn().Str("operation", operation).Str("message", message).Msg("Pairing failed")
+	} else {
+		s.logger.Info().Str("operation", operation).Str("message", message).Msg("Pairing completed")
+	}
+	s.events.Publish(events.Event{Type: eventType, Message: message, Data: result})
+}
+
+// handleDiscovery is called with the result of a node discovery
+func (s *Service) handleDiscovery(result *klf200.DiscoveryResult) {
+	state, message := PairingCompleted, fmt.Sprintf("%d product(s) added", len(result.Added))
+	switch result.Status {
+	case klf200.DiscoverStatusOK:
+	case klf200.DiscoverStatusPartialOK:
+		message += fmt.Sprintf(", %d not reachable", len(result.RFError))
+	default:
+		state, message = PairingFailed, fmt.Sprintf("Discovery failed: %s", result.StatusStr)
+	}
+
+	s.finishPairing(PairingDiscover, state, message, func(job *PairingJob) { job.Discovery = result })
+	if len(result.Added) > 0 || len(result.Removed) > 0 {
+		s.refreshNodesAsync()
+	}
+}
+
+// handleControllerCopy is called with the result of a controller copy
+func (s *Service) handleControllerCopy(result *klf200.ControllerCopyResult) {
+	state, message := PairingCompleted, "Controller copy completed"
+	if !result.OK {
+		state, message = PairingFailed, fmt.Sprintf("Controller copy failed (status %d)", result.Status)
+	}
+
+	s.finishPairing(PairingControllerCopy, state, message, func(job *PairingJob) { job.Copy = result })
+	if result.OK {
+		s.refreshNodesAsync()
+	}
+}
+
+// handleSystemTableUpdate is called when products were added to or removed from the KLF-200
+func (s *Service) handleSystemTableUpdate(update *klf200.SystemTableUpdate) {
+	s.pairing.mu.Lock()
+	running := s.pairing.job != nil && s.pairing.job.State == PairingRunning
+	s.pairing.mu.Unlock()
+
+	if running {
+		s.events.Publish(events.Event{
+			Type: events.TypePairingProgress,
+			Message: fmt.Sprintf("System table updated: %d added, %d removed",
+				len(update.Added), len(update.Removed)),
+			Data: update,
+		})
+	}
+
+	s.refreshNodesAsync()
+}
+
+// refreshNodesAsync re-reads all nodes in the background.
+// Callbacks run on the read loop of the client and must not wait for responses themselves.
+func (s *Service) refreshNodesAsync() {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := s.refreshNodes(ctx); err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to refresh nodes after pairing change")
+		}
+	}()
+}
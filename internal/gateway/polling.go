@@ -0,0 +1,152 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// maxNodesPerRequest is the size of the node index array in KLF-200 requests
+const maxNodesPerRequest = 20
+
+// pollDisabledRecheck is how often a disabled status poll checks whether it was enabled again
+const pollDisabledRecheck = 30 * time.Second
+
+// statusPollLoop periodically requests the status of all nodes and, more often, of moving nodes.
+// This corrects drift when position notifications are lost (e.g. changes made by remotes).
+func (s *Service) statusPollLoop() {
+	defer s.wg.Done()
+
+	interval, movingInterval := s.pollIntervals()
+	tick := pollTick(interval, movingInterval)
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	var lastFull time.Time
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			// The intervals can be changed at runtime through the config API
+			interval, movingInterval = s.pollIntervals()
+			if t := pollTick(interval, movingInterval); t != tick {
+				tick = t
+				ticker.Reset(tick)
+			}
+
+			if interval <= 0 || !s.client.IsAuthenticated() {
+				continue
+			}
+
+			var nodeIDs []uint8
+			if now.Sub(lastFull) >= interval {
+				lastFull = now
+				for _, node := range s.nodes.GetAllNodes() {
+					nodeIDs = append(nodeIDs, node.ID)
+				}
+			} else if movingInterval > 0 {
+				for _, node := range s.nodes.GetAllNodes() {
+					if node.State == klf200.NodeStateExecuting {
+						nodeIDs = append(nodeIDs, node.ID)
+					}
+				}
+			}
+
+			s.pollStatus(nodeIDs)
+		}
+	}
+}
+
+// pollIntervals returns the configured full and moving status poll intervals
+func (s *Service) pollIntervals() (time.Duration, time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.StatusPollInterval, s.cfg.StatusPollMovingInterval
+}
+
+// pollTick returns the ticker period for the given intervals, a disabled poll only rechecks the config
+func pollTick(interval, movingInterval time.Duration) time.Duration {
+	if interval <= 0 {
+		return pollDisabledRecheck
+	}
+	if movingInterval > 0 && movingInterval < interval {
+		return movingInterval
+	}
+	return interval
+}
+
+// pollStatus requests the main status information of the given nodes
+func (s *Service) pollStatus(nodeIDs []uint8) {
+	for start := 0; start < len(nodeIDs); start += maxNodesPerRequest {
+		end := start + maxNodesPerRequest
+		if end > len(nodeIDs) {
+			end = len(nodeIDs)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := s.client.RequestStatus(ctx, nodeIDs[start:end], klf200.StatusTypeMainInfo)
+		cancel()
+		if err != nil {
+			s.logger.Debug().Err(err).Interface("nodes", nodeIDs[start:end]).Msg("Status poll failed")
+		}
+	}
+}
+
+// handleStatusUpdate reconciles the node cache with a status request answer
+func (s *Service) handleStatusUpdate(status *klf200.NodeStatus) {
+	node, ok := s.nodes.GetNode(status.NodeID)
+	if !ok {
+		return
+	}
+
+	update := *node
+	switch status.StatusType {
+	case klf200.StatusTypeMainInfo:
+		setPosition(&update.CurrentPosition, &update.PositionPercent, status.CurrentPosition)
+		setPosition(&update.TargetPosition, &update.TargetPercent, status.TargetPosition)
+		update.RemainingTime = status.RemainingTime
+		update.State = status.RunStatus.NodeState()
+	case klf200.StatusTypeCurrentPosition:
+		if value, ok := status.Parameters[0]; ok {
+			setPosition(&update.CurrentPosition, &update.PositionPercent, value)
+		}
+		s.nodes.UpdateFunctionalParams(status.NodeID, status.Parameters)
+	case klf200.StatusTypeTargetPosition:
+		if value, ok := status.Parameters[0]; ok {
+			setPosition(&update.TargetPosition, &update.TargetPercent, value)
+		}
+	case klf200.StatusTypeRemainingTime:
+		if value, ok := status.Parameters[0]; ok {
+			update.RemainingTime = value
+		}
+	}
+
+	if update.CurrentPosition == node.CurrentPosition &&
+		update.TargetPosition == node.TargetPosition &&
+		update.RemainingTime == node.RemainingTime &&
+		update.State == node.State {
+		return
+	}
+
+	update.StateStr = update.State.String()
+	update.LastUpdate = time.Now()
+
+	s.logger.Debug().
+		Uint8("id", update.ID).
+		Float64("position", update.PositionPercent).
+		Str("state", update.StateStr).
+		Msg("Node status reconciled")
+
+	s.handleNodeUpdate(&update)
+}
+
+// setPosition updates a raw position and its percentage, ignoring special values (unknown, ignore, ...)
+func setPosition(raw *uint16, percent *float64, value uint16) {
+	if value > klf200.PositionMax {
+		return
+	}
+	*raw = value
+	*percent = klf200.PositionToPercent(value)
+}
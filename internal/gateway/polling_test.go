@@ -0,0 +1,41 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+)
+
+func TestPollTick(t *testing.T) {
+	tests := []struct {
+		name           string
+		interval       time.Duration
+		movingInterval time.Duration
+		want           time.Duration
+	}{
+		{name: "full only", interval: time.Minute, want: time.Minute},
+		{name: "moving is faster", interval: time.Minute, movingInterval: 2 * time.Second, want: 2 * time.Second},
+		{name: "moving is slower", interval: time.Minute, movingInterval: 2 * time.Minute, want: time.Minute},
+		{name: "disabled", want: pollDisabledRecheck},
+		{name: "disabled with moving interval", movingInterval: 2 * time.Second, want: pollDisabledRecheck},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pollTick(tt.interval, tt.movingInterval); got != tt.want {
+				t.Errorf("pollTick(%v, %v) = %v, want %v", tt.interval, tt.movingInterval, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPollIntervalsFollowConfig(t *testing.T) {
+	s := newTestService()
+	s.UpdateConfig(&config.KLF200Config{StatusPollInterval: time.Minute, StatusPollMovingInterval: time.Second})
+
+	interval, movingInterval := s.pollIntervals()
+	if interval != time.Minute || movingInterval != time.Second {
+		t.Errorf("pollIntervals() = %v, %v, want 1m0s, 1s", interval, movingInterval)
+	}
+}
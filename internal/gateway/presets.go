@@ -0,0 +1,342 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// ErrPresetNotFound is returned when activating an unknown preset
+var ErrPresetNotFound = errors.New("preset not found")
+
+// PresetResult is the outcome of a preset activation
+type PresetResult struct {
+	Name    string           `json:"name"`
+	Sent    []uint8          `json:"sent"`              // Nodes commanded immediately
+	Pending []uint8          `json:"pending,omitempty"` // Nodes waiting for their delay
+	Errors  map[uint8]string `json:"errors,omitempty"`
+}
+
+// presetRun is an activation with delayed targets still pending
+type presetRun struct {
+	cancel context.CancelFunc
+}
+
+// presets holds the gateway-defined presets and their pending activations
+type presets struct {
+	mu      sync.Mutex
+	list    []config.Preset
+	running map[string]*presetRun
+}
+
+// presetStep is the targets of a preset sharing the same delay
+type presetStep struct {
+	delay   time.Duration
+	targets []config.PresetTarget
+}
+
+// presetBatch is the nodes moved with one command
+type presetBatch struct {
+	device   float64
+	tilt     *float64
+	priority klf200.Priority
+	nodes    []uint8
+}
+
+// SetPresets updates the presets
+func (s *Service) SetPresets(list []config.Preset) {
+	s.presets.mu.Lock()
+	defer s.presets.mu.Unlock()
+
+	s.presets.list = append([]config.Preset(nil), list...)
+}
+
+// GetPreset returns a preset by name
+func (s *Service) GetPreset(name string) (config.Preset, bool) {
+	s.presets.mu.Lock()
+	defer s.presets.mu.Unlock()
+
+	for _, p := range s.presets.list {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return config.Preset{}, false
+}
+
+// ActivatePreset moves the nodes of a preset. Targets without delay are sent before
+// returning, delayed targets in the background. Activating a preset again cancels
+// its pending targets.
+func (s *Service) ActivatePreset(ctx context.Context, name string) (*PresetResult, error) {
+	if !s.client.IsAuthenticated() {
+		return nil, fmt.Errorf("not connected to KLF-200")
+	}
+
+	preset, ok := s.GetPreset(name)
+	if !ok {
+		return nil, ErrPresetNotFound
+	}
+
+	started := time.Now()
+	steps := presetSteps(preset.Targets)
+	result := &PresetResult{Name: name, Sent: []uint8{}, Errors: make(map[uint8]string)}
+	if len(steps) == 0 {
+		return result, nil
+	}
+
+	// Delayed targets keep the command source for the maintenance check
+	runCtx, cancel := context.WithCancel(WithSource(context.Background(), SourceFrom(ctx)))
+	run := &presetRun{cancel: cancel}
+	s.presets.mu.Lock()
+	if previous, ok := s.presets.running[name]; ok {
+		previous.cancel()
+	}
+	if s.presets.running == nil {
+		s.presets.running = make(map[string]*presetRun)
+	}
+	s.presets.running[name] = run
+	s.presets.mu.Unlock()
+
+	if steps[0].delay == 0 {
+		s.runPresetStep(ctx, steps[0].targets, result)
+		steps = steps[1:]
+	}
+
+	s.logger.Info().
+		Str("preset", name).
+		Interface("sent", result.Sent).
+		Int("failed", len(result.Errors)).
+		Msg("Preset activated")
+
+	if len(steps) == 0 {
+		s.finishPreset(name, run)
+		return result, nil
+	}
+
+	for _, step := range steps {
+		for _, t := range step.targets {
+			result.Pending = append(result.Pending, t.NodeID)
+		}
+	}
+
+	go func() {
+		defer s.finishPreset(name, run)
+
+		for _, step := range steps {
+			timer := time.NewTimer(time.Until(started.Add(step.delay)))
+			select {
+			case <-runCtx.Done():
+				timer.Stop()
+				s.logger.Info().Str("preset", name).Msg("Pending preset targets cancelled")
+				return
+			case <-timer.C:
+			}
+
+			stepCtx, stepCancel := context.WithTimeout(runCtx, 30*time.Second)
+			stepResult := &PresetResult{Name: name, Errors: make(map[uint8]string)}
+			s.runPresetStep(stepCtx, step.targets, stepResult)
+			stepCancel()
+
+			for nodeID, msg := range stepResult.Errors {
+				s.logger.Warn().Str("preset", name).Uint8("node", nodeID).Str("error", msg).Msg("Delayed preset target failed")
+			}
+		}
+	}()
+
+	return result, nil
+}
+
+// finishPreset removes the activation from the pending ones
+func (s *Service) finishPreset(name string, run *presetRun) {
+	s.presets.mu.Lock()
+	defer s.presets.mu.Unlock()
+
+	if s.presets.running[name] == run {
+		delete(s.presets.running, name)
+	}
+	run.cancel()
+}
+
+// runPresetStep moves the targets of one step. Targets with the same device position
+// and tilt are batched into one command; targets sequenced by interlock rules are sent on their own.
+// Pending debounced moves of the targets are superseded, their callers get the preset result.
+func (s *Service) runPresetStep(ctx context.Context, targets []config.PresetTarget, result *PresetResult) {
+	var batches []*presetBatch
+
+	superseded := make(map[uint8]*pendingMove)
+	defer func() {
+		for nodeID, pending := range superseded {
+			var err error
+			if msg, failed := result.Errors[nodeID]; failed {
+				err = errors.New(msg)
+			}
+			resolveMove(pending, err)
+		}
+	}()
+
+	for _, t := range targets {
+		nodeID := t.NodeID
+		node, ok := s.nodes.GetNode(nodeID)
+		if !ok {
+			result.Errors[nodeID] = klf200.ErrNodeNotFound.Error()
+			continue
+		}
+
+		mapping := s.mappingManager.GetByNodeID(nodeID)
+		target := limitPosition(mapping, t.Position)
+		device := toDevicePercent(mapping, target)
+		tilt := t.Tilt
+		if !node.NodeType.SupportsTilt() {
+			tilt = nil
+		}
+
+		if err := s.checkMaintenance(ctx, nodeID); err != nil {
+			result.Errors[nodeID] = err.Error()
+			continue
+		}
+		if err := s.checkLock(ctx, nodeID); err != nil {
+			result.Errors[nodeID] = err.Error()
+			continue
+		}
+
+		steps, err := s.planInterlocks(nodeID, target, true)
+		if err != nil {
+			result.Errors[nodeID] = err.Error()
+			continue
+		}
+
+		s.dropQueuedCommand(nodeID)
+		if pending := s.takePendingMove(nodeID); pending != nil {
+			superseded[nodeID] = pending
+		}
+
+		priority := s.commandPriority(ctx, nodeID)
+		if len(steps) > 0 {
+			err := s.moveNode(ctx, nodeID, target, func(ctx context.Context) error {
+				return s.client.SetPositionsWithPriority(ctx, []uint8{nodeID}, device, tilt, priority)
+			})
+			if err != nil {
+				result.Errors[nodeID] = err.Error()
+			} else {
+				result.Sent = append(result.Sent, nodeID)
+			}
+			continue
+		}
+
+		s.cancelSequence(nodeID)
+		batch := findPresetBatch(batches, device, tilt, priority)
+		if batch == nil {
+			batch = &presetBatch{device: device, tilt: tilt, priority: priority}
+			batches = append(batches, batch)
+		}
+		batch.nodes = append(batch.nodes, nodeID)
+	}
+
+	for _, batch := range batches {
+		for start := 0; start < len(batch.nodes); start += klf200.MaxCommandNodes {
+			end := start + klf200.MaxCommandNodes
+			if end > len(batch.nodes) {
+				end = len(batch.nodes)
+			}
+			nodes := batch.nodes[start:end]
+
+			if err := s.client.SetPositionsWithPriority(ctx, nodes, batch.device, batch.tilt, batch.priority); err != nil {
+				for _, nodeID := range nodes {
+					result.Errors[nodeID] = err.Error()
+				}
+				continue
+			}
+			result.Sent = append(result.Sent, nodes...)
+		}
+	}
+}
+
+// findPresetBatch returns the batch for the device position, tilt and priority, nil if there is none yet
+func findPresetBatch(batches []*presetBatch, device float64, tilt *float64, priority klf200.Priority) *presetBatch {
+	for _, b := range batches {
+		if b.device != device || b.priority != priority || (b.tilt == nil) != (tilt == nil) {
+			continue
+		}
+		if tilt == nil || *b.tilt == *tilt {
+			return b
+		}
+	}
+	return nil
+}
+
+// presetSteps groups the targets by delay, keeping their order within a step
+func presetSteps(targets []config.PresetTarget) []presetStep {
+	var steps []presetStep
+	for _, t := range targets {
+		delay := time.Duration(t.DelaySeconds) * time.Second
+		found := false
+		for i := range steps {
+			if steps[i].delay == delay {
+				steps[i].targets = append(steps[i].targets, t)
+				found = true
+				break
+			}
+		}
+		if !found {
+			steps = append(steps, presetStep{delay: delay, targets: []config.PresetTarget{t}})
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].delay < steps[j].delay })
+	return steps
+}
+
+// CapturePreset creates a preset from the current positions of the nodes (empty = all nodes).
+// The tilt of venetian and louver blinds is captured as well. While connected the positions
+// are read from the KLF-200 first, the tilt is not part of the position notifications.
+func (s *Service) CapturePreset(ctx context.Context, name string, nodeIDs []uint8) (config.Preset, error) {
+	preset := config.Preset{Name: name, Targets: []config.PresetTarget{}}
+
+	if len(nodeIDs) == 0 {
+		for _, node := range s.GetNodes() {
+			nodeIDs = append(nodeIDs, node.ID)
+		}
+		sort.Slice(nodeIDs, func(i, j int) bool { return nodeIDs[i] < nodeIDs[j] })
+	}
+	for _, id := range nodeIDs {
+		if _, ok := s.nodes.GetNode(id); !ok {
+			return preset, fmt.Errorf("node %d: %w", id, klf200.ErrNodeNotFound)
+		}
+	}
+
+	if s.client.IsAuthenticated() {
+		for start := 0; start < len(nodeIDs); start += maxNodesPerRequest {
+			end := min(start+maxNodesPerRequest, len(nodeIDs))
+			if err := s.client.RequestParameterStatus(ctx, nodeIDs[start:end], klf200.TiltParameter); err != nil {
+				s.logger.Warn().Err(err).Interface("nodes", nodeIDs[start:end]).Msg("Failed to refresh status, capturing known positions")
+			}
+		}
+	}
+
+	nodes := make([]*klf200.Node, 0, len(nodeIDs))
+	for _, id := range nodeIDs {
+		if node, ok := s.GetNode(id); ok {
+			nodes = append(nodes, node)
+		}
+	}
+
+	for _, node := range nodes {
+		target := config.PresetTarget{
+			NodeID:   node.ID,
+			Position: math.Round(node.PositionPercent*10) / 10,
+		}
+		if raw := node.FunctionalParams[klf200.TiltParameter-1]; node.NodeType.SupportsTilt() && raw <= klf200.PositionMax {
+			tilt := math.Round(klf200.PositionToPercent(raw)*10) / 10
+			target.Tilt = &tilt
+		}
+		preset.Targets = append(preset.Targets, target)
+	}
+
+	return preset, preset.Validate()
+}
@@ -0,0 +1,78 @@
+package gateway
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+func TestPresetSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []config.PresetTarget
+		want    []presetStep
+	}{
+		{name: "no targets"},
+		{
+			name:    "immediate",
+			targets: []config.PresetTarget{{NodeID: 1}, {NodeID: 2}},
+			want:    []presetStep{{delay: 0, targets: []config.PresetTarget{{NodeID: 1}, {NodeID: 2}}}},
+		},
+		{
+			name: "grouped and sorted by delay",
+			targets: []config.PresetTarget{
+				{NodeID: 1, DelaySeconds: 30},
+				{NodeID: 2},
+				{NodeID: 3, DelaySeconds: 30},
+				{NodeID: 4, DelaySeconds: 10},
+			},
+			want: []presetStep{
+				{delay: 0, targets: []config.PresetTarget{{NodeID: 2}}},
+				{delay: 10 * time.Second, targets: []config.PresetTarget{{NodeID: 4, DelaySeconds: 10}}},
+				{delay: 30 * time.Second, targets: []config.PresetTarget{{NodeID: 1, DelaySeconds: 30}, {NodeID: 3, DelaySeconds: 30}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := presetSteps(tt.targets); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("presetSteps() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindPresetBatch(t *testing.T) {
+	batches := []*presetBatch{
+		{device: 50, priority: klf200.PriorityUserLevel2, nodes: []uint8{1}},
+		{device: 50, tilt: floatPtr(20), priority: klf200.PriorityUserLevel2, nodes: []uint8{2}},
+		{device: 50, priority: klf200.PriorityHumanProtection, nodes: []uint8{3}},
+	}
+
+	tests := []struct {
+		name     string
+		device   float64
+		tilt     *float64
+		priority klf200.Priority
+		want     *presetBatch
+	}{
+		{name: "same position", device: 50, priority: klf200.PriorityUserLevel2, want: batches[0]},
+		{name: "other position", device: 60, priority: klf200.PriorityUserLevel2},
+		{name: "same tilt", device: 50, tilt: floatPtr(20), priority: klf200.PriorityUserLevel2, want: batches[1]},
+		{name: "other tilt", device: 50, tilt: floatPtr(30), priority: klf200.PriorityUserLevel2},
+		{name: "other priority", device: 50, priority: klf200.PriorityHumanProtection, want: batches[2]},
+		{name: "no batch with that priority", device: 50, priority: klf200.PriorityComfortLevel1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findPresetBatch(batches, tt.device, tt.tilt, tt.priority); got != tt.want {
+				t.Errorf("findPresetBatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// Protection triggers
+const (
+	ProtectionTriggerRain = "rain"
+	ProtectionTriggerWind = "wind"
+)
+
+// Protection actions
+const (
+	ProtectionActionProtect = "protect"
+	ProtectionActionRestore = "restore"
+)
+
+// maxProtectionLogEntries is the number of protection actions kept in memory
+const maxProtectionLogEntries = 200
+
+// ProtectionEvent records a single action taken by the protection policy
+type ProtectionEvent struct {
+	Time     time.Time `json:"time"`
+	Trigger  string    `json:"trigger"`
+	Action   string    `json:"action"`
+	NodeID   uint8     `json:"node_id"`
+	Position float64   `json:"position"`
+	Success  bool      `json:"success"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// ProtectionStatus is the current state of the protection policy
+type ProtectionStatus struct {
+	Config      config.ProtectionConfig `json:"config"`
+	RainActive  bool                    `json:"rain_active"`
+	WindActive  bool                    `json:"wind_active"`
+	RainRestore *time.Time              `json:"rain_restore_at,omitempty"`
+	WindRestore *time.Time              `json:"wind_restore_at,omitempty"`
+	Log         []ProtectionEvent       `json:"log"`
+}
+
+// protectionState tracks one protection trigger (rain or wind)
+type protectionState struct {
+	active    bool
+	saved     map[uint8]float64 // Positions before protection, for restore
+	timer     *time.Timer
+	restoreAt time.Time
+}
+
+// protection implements the automatic rain and wind protection policy
+type protection struct {
+	mu   sync.Mutex
+	cfg  config.ProtectionConfig
+	rain protectionState
+	wind protectionState
+	log  []ProtectionEvent
+}
+
+// defaultProtectionTypes are the node types moved when a rule has no explicit node list
+var defaultProtectionTypes = map[string][]klf200.NodeType{
+	ProtectionTriggerRain: {klf200.NodeTypeWindowOpener},
+	ProtectionTriggerWind: {
+		klf200.NodeTypeAwningBlind,
+		klf200.NodeTypeHorizontalAwning,
+		klf200.NodeTypeVerticalExteriorAwning,
+	},
+}
+
+// SetProtectionConfig updates the rain and wind protection policy
+func (s *Service) SetProtectionConfig(cfg config.ProtectionConfig) {
+	s.protection.mu.Lock()
+	defer s.protection.mu.Unlock()
+
+	cfg.Rain.NodeIDs = append([]uint8(nil), cfg.Rain.NodeIDs...)
+	cfg.Wind.NodeIDs = append([]uint8(nil), cfg.Wind.NodeIDs...)
+	s.protection.cfg = cfg
+}
+
+// GetProtectionStatus returns the protection policy state and the most recent actions (newest first)
+func (s *Service) GetProtectionStatus() ProtectionStatus {
+	p := &s.protection
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := ProtectionStatus{
+		Config:     p.cfg,
+		RainActive: p.rain.active,
+		WindActive: p.wind.active,
+		Log:        make([]ProtectionEvent, len(p.log)),
+	}
+	if !p.rain.restoreAt.IsZero() {
+		t := p.rain.restoreAt
+		status.RainRestore = &t
+	}
+	if !p.wind.restoreAt.IsZero() {
+		t := p.wind.restoreAt
+		status.WindRestore = &t
+	}
+	for i, e := range p.log {
+		status.Log[len(p.log)-1-i] = e
+	}
+	return status
+}
+
+// applyProtection reacts to rain and wind changes
+func (s *Service) applyProtection(status klf200.SensorStatus) {
+	p := &s.protection
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s.updateProtection(ProtectionTriggerRain, &p.rain, p.cfg.Rain, status.RainDetected)
+	s.updateProtection(ProtectionTriggerWind, &p.wind, p.cfg.Wind, status.WindDetected)
+}
+
+// updateProtection handles a single trigger transition (p.mu must be held)
+func (s *Service) updateProtection(trigger string, state *protectionState, rule config.ProtectionRuleConfig, detected bool) {
+	if detected == state.active {
+		return
+	}
+	state.active = detected
+
+	if !rule.Enabled {
+		return
+	}
+
+	if detected {
+		// Condition (re)appeared: cancel a pending restore, keep positions saved before the first trigger
+		if state.timer != nil {
+			state.timer.Stop()
+			state.timer = nil
+			state.restoreAt = time.Time{}
+		}
+		if state.saved == nil {
+			state.saved = make(map[uint8]float64)
+		}
+
+		targets := s.protectionTargets(trigger, rule)
+		for _, nodeID := range targets {
+			if _, ok := state.saved[nodeID]; ok {
+				continue
+			}
+			if node, ok := s.GetNode(nodeID); ok {
+				state.saved[nodeID] = node.PositionPercent
+			}
+		}
+
+		s.logger.Warn().
+			Str("trigger", trigger).
+			Int("nodes", len(targets)).
+			Float64("position", rule.Position).
+			Msg("Protection triggered")
+
+		go s.runProtection(trigger, ProtectionActionProtect, targets, func(uint8) float64 { return rule.Position })
+		return
+	}
+
+	if !rule.Restore || len(state.saved) == 0 {
+		state.saved = nil
+		return
+	}
+
+	// Condition cleared: restore after the hold-off unless it comes back
+	restoreAfter := time.Duration(rule.RestoreAfter)
+	state.restoreAt = time.Now().Add(restoreAfter)
+	state.timer = time.AfterFunc(restoreAfter, func() {
+		s.restoreProtection(trigger, state)
+	})
+
+	s.logger.Info().
+		Str("trigger", trigger).
+		Time("restore_at", state.restoreAt).
+		Msg("Protection condition cleared, restore scheduled")
+}
+
+// restoreProtection moves the protected nodes back to their previous positions
+func (s *Service) restoreProtection(trigger string, state *protectionState) {
+	p := &s.protection
+	p.mu.Lock()
+	if state.active || state.timer == nil {
+		p.mu.Unlock()
+		return
+	}
+	saved := state.saved
+	state.saved = nil
+	state.timer = nil
+	state.restoreAt = time.Time{}
+	p.mu.Unlock()
+
+	targets := make([]uint8, 0, len(saved))
+	for nodeID := range saved {
+		targets = append(targets, nodeID)
+	}
+
+	s.logger.Info().Str("trigger", trigger).Int("nodes", len(targets)).Msg("Restoring positions after protection")
+
+	s.runProtection(trigger, ProtectionActionRestore, targets, func(nodeID uint8) float64 { return saved[nodeID] })
+}
+
+// protectionTargets returns the configured nodes or all known nodes of the default types
+func (s *Service) protectionTargets(trigger string, rule config.ProtectionRuleConfig) []uint8 {
+	if len(rule.NodeIDs) > 0 {
+		return rule.NodeIDs
+	}
+
+	var targets []uint8
+	for _, node := range s.GetNodes() {
+		for _, t := range defaultProtectionTypes[trigger] {
+			if node.NodeType == t {
+				targets = append(targets, node.ID)
+				break
+			}
+		}
+	}
+	return targets
+}
+
+// runProtection sends the protection commands and records the results.
+// Protective moves use the environment protection priority so that user-level commands cannot
+// override them and bypass all rules. Restores are normal rule commands: nodes in maintenance,
+// locked nodes and moves forbidden by an interlock rule are skipped.
+func (s *Service) runProtection(trigger, action string, targets []uint8, position func(uint8) float64) {
+	for _, nodeID := range targets {
+		pos := position(nodeID)
+
+		ctx, cancel := context.WithTimeout(WithSource(context.Background(), SourceRule), 30*time.Second)
+		var err error
+		if action == ProtectionActionProtect {
+			err = s.setPositionWithPriority(ctx, nodeID, pos, klf200.PriorityEnvironmentProtection)
+		} else {
+			err = s.moveTo(ctx, nodeID, pos)
+		}
+		cancel()
+
+		event := ProtectionEvent{
+			Time:     time.Now(),
+			Trigger:  trigger,
+			Action:   action,
+			NodeID:   nodeID,
+			Position: pos,
+			Success:  err == nil,
+		}
+		if blocked(err) {
+			event.Error = err.Error()
+			s.logger.Warn().Err(err).
+				Str("trigger", trigger).
+				Uint8("node", nodeID).
+				Msg("Protection restore skipped")
+		} else if err != nil {
+			event.Error = err.Error()
+			s.logger.Error().Err(err).
+				Str("trigger", trigger).
+				Str("action", action).
+				Uint8("node", nodeID).
+				Msg("Protection command failed")
+		} else {
+			s.logger.Info().
+				Str("trigger", trigger).
+				Str("action", action).
+				Uint8("node", nodeID).
+				Float64("position", pos).
+				Msg("Protection command sent")
+		}
+
+		s.protection.mu.Lock()
+		s.protection.log = append(s.protection.log, event)
+		if len(s.protection.log) > maxProtectionLogEntries {
+			s.protection.log = s.protection.log[len(s.protection.log)-maxProtectionLogEntries:]
+		}
+		s.protection.mu.Unlock()
+	}
+}
+
+// blocked reports whether a command was rejected by maintenance, a node lock or an interlock rule
+func blocked(err error) bool {
+	var interlockErr *InterlockError
+	return errors.Is(err, ErrMaintenance) || errors.Is(err, ErrNodeLocked) || errors.As(err, &interlockErr)
+}
@@ -0,0 +1,145 @@
+package gateway
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// waitForProtectionLog waits until the protection log has n entries and returns them, oldest first
+func waitForProtectionLog(t *testing.T, s *Service, n int) []ProtectionEvent {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.protection.mu.Lock()
+		log := append([]ProtectionEvent(nil), s.protection.log...)
+		s.protection.mu.Unlock()
+		if len(log) >= n {
+			return log
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("protection log has less than %d entries", n)
+	return nil
+}
+
+// setNodePosition changes the known position of a node
+func setNodePosition(s *Service, nodeID uint8, percent float64) {
+	node, _ := s.nodes.GetNode(nodeID)
+	node.PositionPercent = percent
+	nodes := s.nodes.GetAllNodes()
+	for i := range nodes {
+		if nodes[i].ID == nodeID {
+			nodes[i] = node
+		}
+	}
+	s.nodes.SetNodes(nodes)
+}
+
+func TestProtectionSaveAndRestore(t *testing.T) {
+	s := newTestService(
+		&klf200.Node{ID: 1, PositionPercent: 20},
+		&klf200.Node{ID: 2, PositionPercent: 60},
+	)
+	s.SetProtectionConfig(config.ProtectionConfig{Rain: config.ProtectionRuleConfig{
+		Enabled:      true,
+		NodeIDs:      []uint8{1, 2},
+		Position:     0,
+		Restore:      true,
+		RestoreAfter: config.Duration(time.Hour),
+	}})
+
+	s.applyProtection(klf200.SensorStatus{RainDetected: true})
+	protect := waitForProtectionLog(t, s, 2)
+	for _, event := range protect {
+		if event.Action != ProtectionActionProtect || event.Trigger != ProtectionTriggerRain || event.Position != 0 {
+			t.Errorf("event %+v, want a rain protection to 0%%", event)
+		}
+	}
+
+	// Clearing schedules the restore, rain before it keeps the positions saved at the first trigger
+	s.applyProtection(klf200.SensorStatus{})
+	if status := s.GetProtectionStatus(); status.RainActive || status.RainRestore == nil {
+		t.Fatalf("status %+v, want a scheduled restore", status)
+	}
+	setNodePosition(s, 1, 0)
+	s.applyProtection(klf200.SensorStatus{RainDetected: true})
+	if status := s.GetProtectionStatus(); !status.RainActive || status.RainRestore != nil {
+		t.Fatalf("status %+v, want the restore cancelled", status)
+	}
+	waitForProtectionLog(t, s, 4)
+
+	s.protection.mu.Lock()
+	s.protection.cfg.Rain.RestoreAfter = config.Duration(time.Millisecond)
+	s.protection.mu.Unlock()
+	s.applyProtection(klf200.SensorStatus{})
+
+	restored := map[uint8]float64{}
+	for _, event := range waitForProtectionLog(t, s, 6)[4:] {
+		if event.Action != ProtectionActionRestore {
+			t.Errorf("event %+v, want a restore", event)
+		}
+		restored[event.NodeID] = event.Position
+	}
+	if want := map[uint8]float64{1: 20, 2: 60}; !reflect.DeepEqual(restored, want) {
+		t.Errorf("restored %v, want %v", restored, want)
+	}
+	if status := s.GetProtectionStatus(); status.RainRestore != nil || len(status.Log) != 6 {
+		t.Errorf("status %+v, want no pending restore and 6 log entries", status)
+	}
+}
+
+func TestProtectionWithoutRestore(t *testing.T) {
+	s := newTestService(&klf200.Node{ID: 1, PositionPercent: 20})
+	s.SetProtectionConfig(config.ProtectionConfig{Wind: config.ProtectionRuleConfig{
+		Enabled:  true,
+		NodeIDs:  []uint8{1},
+		Position: 0,
+	}})
+
+	s.applyProtection(klf200.SensorStatus{WindDetected: true})
+	waitForProtectionLog(t, s, 1)
+	s.applyProtection(klf200.SensorStatus{})
+
+	s.protection.mu.Lock()
+	defer s.protection.mu.Unlock()
+	if s.protection.wind.saved != nil || s.protection.wind.timer != nil {
+		t.Errorf("wind state %+v, want nothing saved or scheduled", s.protection.wind)
+	}
+}
+
+func TestProtectionTargets(t *testing.T) {
+	s := newTestService(
+		&klf200.Node{ID: 1, NodeType: klf200.NodeTypeWindowOpener},
+		&klf200.Node{ID: 2, NodeType: klf200.NodeTypeHorizontalAwning},
+		&klf200.Node{ID: 3, NodeType: klf200.NodeTypeAwningBlind},
+		&klf200.Node{ID: 4, NodeType: klf200.NodeTypeRollerShutter},
+	)
+
+	tests := []struct {
+		name    string
+		trigger string
+		rule    config.ProtectionRuleConfig
+		want    []uint8
+	}{
+		{name: "rain defaults", trigger: ProtectionTriggerRain, want: []uint8{1}},
+		{name: "wind defaults", trigger: ProtectionTriggerWind, want: []uint8{2, 3}},
+		{name: "configured nodes", trigger: ProtectionTriggerRain, rule: config.ProtectionRuleConfig{NodeIDs: []uint8{4, 1}}, want: []uint8{4, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.protectionTargets(tt.trigger, tt.rule)
+			if len(tt.rule.NodeIDs) == 0 {
+				sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("targets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
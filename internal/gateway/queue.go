@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+)
+
+// ErrCommandQueued is returned when a command was queued because the KLF-200 is not connected
+var ErrCommandQueued = errors.New("accepted, queued until the KLF-200 is connected")
+
+// QueueActionPosition is the action of queued commands; stops are never queued,
+// they drop the queued command of their node instead
+const QueueActionPosition = "position"
+
+// QueuedCommand is a node command waiting for the connection to the KLF-200
+type QueuedCommand struct {
+	NodeID   uint8         `json:"node_id"`
+	Action   string        `json:"action"`
+	Position float64       `json:"position"` // Action position: 0 = open, 100 = closed
+	Source   CommandSource `json:"source"`
+	Queued   time.Time     `json:"queued"`
+	Expires  time.Time     `json:"expires"`
+}
+
+// commandQueue holds the commands issued while disconnected, oldest first, one per node
+type commandQueue struct {
+	mu       sync.Mutex
+	cfg      config.CommandQueueConfig
+	commands []QueuedCommand
+}
+
+// SetCommandQueueConfig updates the command queue settings. Disabling the queue drops the queued commands.
+func (s *Service) SetCommandQueueConfig(cfg config.CommandQueueConfig) {
+	s.queue.mu.Lock()
+	defer s.queue.mu.Unlock()
+
+	s.queue.cfg = cfg
+	if !cfg.Enabled {
+		s.queue.commands = nil
+	} else if len(s.queue.commands) > cfg.Size {
+		s.queue.commands = s.queue.commands[len(s.queue.commands)-cfg.Size:]
+	}
+}
+
+// GetCommandQueue returns the queued commands that have not expired, oldest first
+func (s *Service) GetCommandQueue() []QueuedCommand {
+	s.queue.mu.Lock()
+	defer s.queue.mu.Unlock()
+
+	s.pruneQueue(time.Now())
+	return append([]QueuedCommand{}, s.queue.commands...)
+}
+
+// ClearCommandQueue drops all queued commands and returns how many were dropped
+func (s *Service) ClearCommandQueue() int {
+	s.queue.mu.Lock()
+	defer s.queue.mu.Unlock()
+
+	n := len(s.queue.commands)
+	s.queue.commands = nil
+	return n
+}
+
+// queueCommand queues a command while the KLF-200 is not connected. It returns ErrCommandQueued
+// on success, the usual "not connected" error if the queue is disabled or full.
+func (s *Service) queueCommand(ctx context.Context, nodeID uint8, action string, position float64) error {
+	if err := s.checkMaintenance(ctx, nodeID); err != nil {
+		return err
+	}
+	if err := s.checkLock(ctx, nodeID); err != nil {
+		return err
+	}
+
+	s.queue.mu.Lock()
+	defer s.queue.mu.Unlock()
+
+	if !s.queue.cfg.Enabled {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+
+	now := time.Now()
+	s.pruneQueue(now)
+	s.removeQueued(nodeID)
+	if len(s.queue.commands) >= s.queue.cfg.Size {
+		return fmt.Errorf("not connected to KLF-200 and the command queue is full")
+	}
+
+	s.queue.commands = append(s.queue.commands, QueuedCommand{
+		NodeID:   nodeID,
+		Action:   action,
+		Position: position,
+		Source:   SourceFrom(ctx),
+		Queued:   now,
+		Expires:  now.Add(time.Duration(s.queue.cfg.TTL)),
+	})
+
+	s.logger.Info().Uint8("node", nodeID).Str("action", action).Float64("position", position).Msg("Command queued until reconnected")
+	return ErrCommandQueued
+}
+
+// dropQueuedCommand removes the queued command of a node, a newer command was sent directly
+func (s *Service) dropQueuedCommand(nodeID uint8) {
+	s.queue.mu.Lock()
+	defer s.queue.mu.Unlock()
+
+	s.removeQueued(nodeID)
+}
+
+// removeQueued removes the command of a node; the caller holds the lock
+func (s *Service) removeQueued(nodeID uint8) {
+	for i, cmd := range s.queue.commands {
+		if cmd.NodeID == nodeID {
+			s.queue.commands = append(s.queue.commands[:i], s.queue.commands[i+1:]...)
+			return
+		}
+	}
+}
+
+// pruneQueue drops expired commands; the caller holds the lock
+func (s *Service) pruneQueue(now time.Time) {
+	kept := s.queue.commands[:0]
+	for _, cmd := range s.queue.commands {
+		if now.Before(cmd.Expires) {
+			kept = append(kept, cmd)
+		} else {
+			s.logger.Warn().Uint8("node", cmd.NodeID).Str("action", cmd.Action).Msg("Queued command expired")
+		}
+	}
+	s.queue.commands = kept
+}
+
+// replayCommandQueue sends the queued commands after the connection was established, oldest first
+func (s *Service) replayCommandQueue(ctx context.Context) {
+	s.queue.mu.Lock()
+	s.pruneQueue(time.Now())
+	commands := s.queue.commands
+	s.queue.commands = nil
+	s.queue.mu.Unlock()
+
+	for _, cmd := range commands {
+		cmdCtx := WithSource(ctx, cmd.Source)
+
+		var err error
+		if !s.client.IsAuthenticated() {
+			err = s.queueCommand(cmdCtx, cmd.NodeID, QueueActionPosition, cmd.Position)
+		} else {
+			// Replayed commands are not debounced, they are the last ones of their node
+			err = s.moveNow(cmdCtx, cmd.NodeID, cmd.Position)
+		}
+
+		if errors.Is(err, ErrCommandQueued) {
+			continue // Disconnected again, queued anew
+		}
+		if err != nil {
+			s.logger.Warn().Err(err).Uint8("node", cmd.NodeID).Str("action", cmd.Action).Msg("Failed to replay queued command")
+			continue
+		}
+		s.logger.Info().
+			Uint8("node", cmd.NodeID).
+			Str("action", cmd.Action).
+			Dur("delay", time.Since(cmd.Queued)).
+			Msg("Replayed queued command")
+	}
+}
@@ -0,0 +1,159 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// newQueueTestService returns a disconnected service with nodes 1-3 and the command queue enabled
+func newQueueTestService(size int, ttl time.Duration) *Service {
+	s := newTestService(&klf200.Node{ID: 1}, &klf200.Node{ID: 2}, &klf200.Node{ID: 3})
+	s.SetCommandQueueConfig(config.CommandQueueConfig{Enabled: true, Size: size, TTL: config.Duration(ttl)})
+	return s
+}
+
+func queuedNodes(s *Service) []uint8 {
+	var ids []uint8
+	for _, cmd := range s.GetCommandQueue() {
+		ids = append(ids, cmd.NodeID)
+	}
+	return ids
+}
+
+func TestQueueCommand(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("disabled", func(t *testing.T) {
+		s := newTestService(&klf200.Node{ID: 1})
+		err := s.SetPosition(ctx, 1, 50)
+		if err == nil || errors.Is(err, ErrCommandQueued) {
+			t.Errorf("SetPosition = %v, want a not connected error", err)
+		}
+		if n := len(s.GetCommandQueue()); n != 0 {
+			t.Errorf("%d commands queued, want 0", n)
+		}
+	})
+
+	t.Run("last command per node wins", func(t *testing.T) {
+		s := newQueueTestService(10, time.Minute)
+		for _, err := range []error{s.SetPosition(ctx, 1, 50), s.Open(ctx, 2), s.Close(ctx, 1)} {
+			if !errors.Is(err, ErrCommandQueued) {
+				t.Fatalf("err = %v, want ErrCommandQueued", err)
+			}
+		}
+
+		queue := s.GetCommandQueue()
+		if len(queue) != 2 || queue[0].NodeID != 2 || queue[1].NodeID != 1 {
+			t.Fatalf("queue = %+v, want node 2, then node 1", queue)
+		}
+		if queue[1].Position != 100 || queue[1].Action != QueueActionPosition {
+			t.Errorf("node 1 command = %+v, want position 100", queue[1])
+		}
+		if queue[1].Source != SourceAPI {
+			t.Errorf("source = %q, want %q", queue[1].Source, SourceAPI)
+		}
+	})
+
+	t.Run("full", func(t *testing.T) {
+		s := newQueueTestService(2, time.Minute)
+		s.SetPosition(ctx, 1, 10)
+		s.SetPosition(ctx, 2, 20)
+
+		err := s.SetPosition(ctx, 3, 30)
+		if err == nil || errors.Is(err, ErrCommandQueued) {
+			t.Errorf("SetPosition on a full queue = %v, want an error", err)
+		}
+		// Replacing the command of a queued node still works
+		if err := s.SetPosition(ctx, 1, 15); !errors.Is(err, ErrCommandQueued) {
+			t.Errorf("SetPosition for a queued node = %v, want ErrCommandQueued", err)
+		}
+		if got := queuedNodes(s); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+			t.Errorf("queued nodes = %v, want [2 1]", got)
+		}
+	})
+
+	t.Run("expired commands are dropped", func(t *testing.T) {
+		s := newQueueTestService(1, 10*time.Millisecond)
+		s.SetPosition(ctx, 1, 10)
+		time.Sleep(20 * time.Millisecond)
+
+		if n := len(s.GetCommandQueue()); n != 0 {
+			t.Errorf("%d commands queued after the TTL, want 0", n)
+		}
+		// The expired command no longer takes up room
+		if err := s.SetPosition(ctx, 2, 20); !errors.Is(err, ErrCommandQueued) {
+			t.Errorf("SetPosition = %v, want ErrCommandQueued", err)
+		}
+	})
+
+	t.Run("maintenance rejects", func(t *testing.T) {
+		s := newQueueTestService(10, time.Minute)
+		if _, err := s.StartMaintenance(nil, time.Hour, ""); err != nil {
+			t.Fatalf("StartMaintenance: %v", err)
+		}
+		if err := s.SetPosition(WithSource(ctx, SourceLoxone), 1, 10); !errors.Is(err, ErrMaintenance) {
+			t.Errorf("SetPosition = %v, want ErrMaintenance", err)
+		}
+		if err := s.SetPosition(WithSource(ctx, SourceManual), 1, 10); !errors.Is(err, ErrCommandQueued) {
+			t.Errorf("manual SetPosition = %v, want ErrCommandQueued", err)
+		}
+	})
+}
+
+func TestStopNodeDropsQueuedCommand(t *testing.T) {
+	ctx := context.Background()
+	s := newQueueTestService(10, time.Minute)
+	s.SetPosition(ctx, 1, 10)
+	s.SetPosition(ctx, 2, 20)
+
+	err := s.StopNode(ctx, 1)
+	if err == nil || errors.Is(err, ErrCommandQueued) {
+		t.Errorf("StopNode = %v, want a not connected error", err)
+	}
+	if got := queuedNodes(s); len(got) != 1 || got[0] != 2 {
+		t.Errorf("queued nodes = %v, want [2]", got)
+	}
+}
+
+func TestSetCommandQueueConfig(t *testing.T) {
+	ctx := context.Background()
+	s := newQueueTestService(10, time.Minute)
+	s.SetPosition(ctx, 1, 10)
+	s.SetPosition(ctx, 2, 20)
+	s.SetPosition(ctx, 3, 30)
+
+	// Shrinking keeps the newest commands
+	s.SetCommandQueueConfig(config.CommandQueueConfig{Enabled: true, Size: 2, TTL: config.Duration(time.Minute)})
+	if got := queuedNodes(s); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Errorf("queued nodes = %v, want [2 3]", got)
+	}
+
+	s.SetCommandQueueConfig(config.CommandQueueConfig{Enabled: false, Size: 2, TTL: config.Duration(time.Minute)})
+	if n := len(s.GetCommandQueue()); n != 0 {
+		t.Errorf("%d commands queued after disabling, want 0", n)
+	}
+}
+
+func TestReplayCommandQueue(t *testing.T) {
+	ctx := context.Background()
+	s := newQueueTestService(10, 30*time.Millisecond)
+	s.SetPosition(WithSource(ctx, SourceSchedule), 1, 10)
+	time.Sleep(40 * time.Millisecond)
+	s.SetPosition(WithSource(ctx, SourceLoxone), 2, 20)
+
+	// Still disconnected: the expired command is dropped, the other one is queued anew
+	s.replayCommandQueue(ctx)
+
+	queue := s.GetCommandQueue()
+	if len(queue) != 1 || queue[0].NodeID != 2 || queue[0].Position != 20 {
+		t.Fatalf("queue = %+v, want node 2 at 20", queue)
+	}
+	if queue[0].Source != SourceLoxone {
+		t.Errorf("source = %q, want %q", queue[0].Source, SourceLoxone)
+	}
+}
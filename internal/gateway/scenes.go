@@ -0,0 +1,339 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/events"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// sceneRecordingTimeout is how long a scene recording may stay open before it is cancelled
+const sceneRecordingTimeout = 15 * time.Minute
+
+// Scene recording states
+const (
+	SceneInitializing = "initializing" // KLF-200 reads the state of all nodes
+	SceneRecording    = "recording"    // Move the devices into position now
+	SceneSaving       = "saving"       // Waiting for the new scene ID
+	SceneCompleted    = "completed"
+	SceneFailed       = "failed"
+	SceneCancelled    = "cancelled"
+)
+
+var (
+	// ErrSceneRecordingBusy is returned when a recording is started while another one is open
+	ErrSceneRecordingBusy = errors.New("another scene recording is in progress")
+	// ErrNoSceneRecording is returned when saving or cancelling without an open recording
+	ErrNoSceneRecording = errors.New("no scene recording in progress")
+)
+
+// SceneRecordingJob is the guided "move devices, then save" workflow for a new scene
+type SceneRecordingJob struct {
+	Name        string     `json:"name,omitempty"`
+	State       string     `json:"state"`
+	Message     string     `json:"message,omitempty"`
+	Started     time.Time  `json:"started"`
+	Finished    *time.Time `json:"finished,omitempty"`
+	MovedNodes  []uint8    `json:"moved_nodes"`
+	FailedNodes []uint8    `json:"failed_nodes,omitempty"` // Nodes the KLF-200 could not read when initializing
+	SceneID     *uint8     `json:"scene_id,omitempty"`
+}
+
+// scenes tracks the scene recording of the KLF-200 (only one at a time)
+type scenes struct {
+	mu    sync.Mutex
+	job   *SceneRecordingJob
+	timer *time.Timer
+}
+
+// open returns true if the job has not finished yet
+func (j *SceneRecordingJob) open() bool {
+	return j != nil && (j.State == SceneInitializing || j.State == SceneRecording || j.State == SceneSaving)
+}
+
+// snapshot returns a copy of the job that is safe to hand out
+func (j *SceneRecordingJob) snapshot() SceneRecordingJob {
+	c := *j
+	c.MovedNodes = append([]uint8{}, j.MovedNodes...)
+	return c
+}
+
+// GetScenes returns the scenes stored in the KLF-200
+func (s *Service) GetScenes(ctx context.Context) ([]klf200.Scene, error) {
+	if !s.client.IsAuthenticated() {
+		return nil, fmt.Errorf("not connected to KLF-200")
+	}
+
+	list, err := s.client.GetSceneList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// DeleteScene deletes a scene from the KLF-200
+func (s *Service) DeleteScene(ctx context.Context, sceneID uint8) error {
+	if !s.client.IsAuthenticated() {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+	return s.client.DeleteScene(ctx, sceneID)
+}
+
+// RenameScene changes the name of a scene
+func (s *Service) RenameScene(ctx context.Context, sceneID uint8, name string) error {
+	if !s.client.IsAuthenticated() {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+	return s.client.RenameScene(ctx, sceneID, name)
+}
+
+// GetSceneRecording returns the current or last scene recording, nil if there was none
+func (s *Service) GetSceneRecording() *SceneRecordingJob {
+	s.scenes.mu.Lock()
+	defer s.scenes.mu.Unlock()
+
+	if s.scenes.job == nil {
+		return nil
+	}
+	job := s.scenes.job.snapshot()
+	return &job
+}
+
+// StartSceneRecording starts recording a new scene. Once the KLF-200 has read the
+// current state, the devices can be moved into position and the scene saved with
+// SaveSceneRecording. Progress is reported through the event hub.
+func (s *Service) StartSceneRecording(ctx context.Context, name string) (*SceneRecordingJob, error) {
+	if !s.client.IsAuthenticated() {
+		return nil, fmt.Errorf("not connected to KLF-200")
+	}
+
+	job := &SceneRecordingJob{
+		Name:       name,
+		State:      SceneInitializing,
+		Message:    "Reading the current state of all devices",
+		Started:    time.Now(),
+		MovedNodes: []uint8{},
+	}
+
+	// Reserve the slot first, the lock must not be held while waiting for the
+	// confirmation (notifications handled on the read loop take it too)
+	s.scenes.mu.Lock()
+	if s.scenes.job.open() {
+		s.scenes.mu.Unlock()
+		return nil, ErrSceneRecordingBusy
+	}
+	previous := s.scenes.job
+	s.scenes.job = job
+	s.scenes.mu.Unlock()
+
+	if err := s.client.InitializeScene(ctx); err != nil {
+		s.scenes.mu.Lock()
+		if s.scenes.job == job {
+			s.scenes.job = previous
+		}
+		s.scenes.mu.Unlock()
+		return nil, err
+	}
+
+	s.scenes.mu.Lock()
+	s.scenes.timer = time.AfterFunc(sceneRecordingTimeout, s.expireSceneRecording)
+	result := job.snapshot()
+	s.scenes.mu.Unlock()
+
+	s.logger.Info().Str("name", name).Msg("Scene recording started")
+	s.events.Publish(events.Event{Type: events.TypeSceneRecordingStarted, Message: result.Message, Data: result})
+
+	return &result, nil
+}
+
+// SaveSceneRecording stores the devices moved since the start as a new scene.
+// An empty name keeps the name given when starting.
+func (s *Service) SaveSceneRecording(ctx context.Context, name string) (*SceneRecordingJob, error) {
+	if !s.client.IsAuthenticated() {
+		return nil, fmt.Errorf("not connected to KLF-200")
+	}
+
+	s.scenes.mu.Lock()
+	job := s.scenes.job
+	if job == nil || job.State != SceneRecording {
+		err := ErrNoSceneRecording
+		if job.open() {
+			err = fmt.Errorf("scene recording is %s, wait until it is recording", job.State)
+		}
+		s.scenes.mu.Unlock()
+		return nil, err
+	}
+	if name == "" {
+		name = job.Name
+	}
+	if name == "" {
+		s.scenes.mu.Unlock()
+		return nil, fmt.Errorf("scene name is required")
+	}
+	job.Name = name
+	job.State = SceneSaving
+	job.Message = "Saving scene"
+	s.scenes.mu.Unlock()
+
+	if err := s.client.RecordScene(ctx, name); err != nil {
+		s.scenes.mu.Lock()
+		if s.scenes.job == job && job.State == SceneSaving {
+			job.State = SceneRecording
+			job.Message = "Saving failed, move the devices into position and save again"
+		}
+		s.scenes.mu.Unlock()
+		return nil, err
+	}
+
+	s.scenes.mu.Lock()
+	result := job.snapshot()
+	s.scenes.mu.Unlock()
+
+	s.events.Publish(events.Event{Type: events.TypeSceneRecordingProgress, Message: result.Message, Data: result})
+	return &result, nil
+}
+
+// CancelSceneRecording cancels the open scene recording
+func (s *Service) CancelSceneRecording(ctx context.Context) error {
+	s.scenes.mu.Lock()
+	open := s.scenes.job.open()
+	s.scenes.mu.Unlock()
+	if !open {
+		return ErrNoSceneRecording
+	}
+
+	if s.client.IsAuthenticated() {
+		if err := s.client.CancelInitializeScene(ctx); err != nil {
+			return err
+		}
+	}
+
+	s.finishSceneRecording(SceneCancelled, "Scene recording cancelled", nil)
+	return nil
+}
+
+// expireSceneRecording cancels a recording that was not saved in time
+func (s *Service) expireSceneRecording() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if s.client.IsAuthenticated() {
+		if err := s.client.CancelInitializeScene(ctx); err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to cancel expired scene recording")
+		}
+	}
+	s.finishSceneRecording(SceneFailed, "Scene recording timed out", nil)
+}
+
+// finishSceneRecording completes the open scene recording
+func (s *Service) finishSceneRecording(state, message string, update func(job *SceneRecordingJob)) {
+	s.scenes.mu.Lock()
+	job := s.scenes.job
+	if !job.open() {
+		s.scenes.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	job.State = state
+	job.Message = message
+	job.Finished = &now
+	if update != nil {
+		update(job)
+	}
+	if s.scenes.timer != nil {
+		s.scenes.timer.Stop()
+	}
+	result := job.snapshot()
+	s.scenes.mu.Unlock()
+
+	eventType := events.TypeSceneRecordingCompleted
+	switch state {
+	case SceneFailed:
+		eventType = events.TypeSceneRecordingFailed
+		s.logger.Warn().Str("message", message).Msg("Scene recording failed")
+	case SceneCancelled:
+		eventType = events.TypeSceneRecordingCancelled
+		s.logger.Info().Msg("Scene recording cancelled")
+	default:
+		s.logger.Info().Str("name", result.Name).Msg("Scene recorded")
+	}
+	s.events.Publish(events.Event{Type: eventType, Message: message, Data: result})
+}
+
+// handleSceneInit is called when the KLF-200 has read the state of all nodes
+func (s *Service) handleSceneInit(result *klf200.SceneInitResult) {
+	if result.Status == klf200.SceneInitError {
+		s.finishSceneRecording(SceneFailed, "KLF-200 could not read the state of the devices",
+			func(job *SceneRecordingJob) { job.FailedNodes = result.FailedNodes })
+		return
+	}
+
+	message := "Move the devices into position, then save the scene"
+	if len(result.FailedNodes) > 0 {
+		message = fmt.Sprintf("%s (%d device(s) not reachable)", message, len(result.FailedNodes))
+	}
+
+	s.scenes.mu.Lock()
+	job := s.scenes.job
+	if job == nil || job.State != SceneInitializing {
+		s.scenes.mu.Unlock()
+		return
+	}
+	job.State = SceneRecording
+	job.Message = message
+	job.FailedNodes = result.FailedNodes
+	snapshot := job.snapshot()
+	s.scenes.mu.Unlock()
+
+	s.events.Publish(events.Event{Type: events.TypeSceneRecordingProgress, Message: message, Data: snapshot})
+}
+
+// handleSceneRecorded is called with the ID of the newly recorded scene
+func (s *Service) handleSceneRecorded(result *klf200.SceneRecordResult) {
+	if !result.OK {
+		s.finishSceneRecording(SceneFailed, "KLF-200 could not save the scene", nil)
+		return
+	}
+
+	sceneID := result.SceneID
+	s.finishSceneRecording(SceneCompleted, fmt.Sprintf("Scene %d saved", sceneID),
+		func(job *SceneRecordingJob) { job.SceneID = &sceneID })
+}
+
+// handleSceneChanged is called when a scene was deleted or modified
+func (s *Service) handleSceneChanged(change *klf200.SceneChange) {
+	message := fmt.Sprintf("Scene %d modified", change.SceneID)
+	if change.Deleted {
+		message = fmt.Sprintf("Scene %d deleted", change.SceneID)
+	}
+	s.events.Publish(events.Event{Type: events.TypeSceneChanged, Message: message, Data: change})
+}
+
+// sceneNodeMoved records a node moved while a scene recording is open
+func (s *Service) sceneNodeMoved(nodeID uint8) {
+	s.scenes.mu.Lock()
+	job := s.scenes.job
+	if job == nil || job.State != SceneRecording {
+		s.scenes.mu.Unlock()
+		return
+	}
+	for _, id := range job.MovedNodes {
+		if id == nodeID {
+			s.scenes.mu.Unlock()
+			return
+		}
+	}
+	job.MovedNodes = append(job.MovedNodes, nodeID)
+	snapshot := job.snapshot()
+	s.scenes.mu.Unlock()
+
+	s.events.PublishNode(events.TypeSceneRecordingProgress, nodeID,
+		fmt.Sprintf("%d device(s) moved", len(snapshot.MovedNodes)), snapshot)
+}
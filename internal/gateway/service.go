@@ -3,14 +3,17 @@ package gateway
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
 
 	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/events"
 	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
 	"github.com/stefanbeyeler/loxone2velux/internal/loxone"
+	"github.com/stefanbeyeler/loxone2velux/internal/storage"
 )
 
 // Service is the main gateway service
@@ -20,8 +23,25 @@ type Service struct {
 	nodes          *klf200.NodeManager
 	udpSender      *loxone.UDPSender
 	mappingManager *loxone.MappingManager
+	store          *storage.Store
+	protection     protection
+	interlocks     interlocks
+	winks          winks
+	pairing        pairing
+	system         system
+	activationLog  activationLog
+	scenes         scenes
+	presets        presets
+	maintenance    maintenance
+	queue          commandQueue
+	debounce       debouncer
+	events         *events.Hub
 	logger         zerolog.Logger
 
+	// Last derived sensor status, for change detection
+	sensorStatus   klf200.SensorStatus
+	sensorStatusMu sync.Mutex
+
 	mu       sync.RWMutex
 	stopChan chan struct{}
 	wg       sync.WaitGroup
@@ -50,6 +70,7 @@ func NewService(cfg *config.KLF200Config, loxoneCfg *config.LoxoneConfig, logger
 		cfg:            cfg,
 		client:         klf200.NewClient(clientCfg),
 		nodes:          klf200.NewNodeManager(),
+		events:         events.NewHub(),
 		udpSender:      udpSender,
 		mappingManager: mappingMgr,
 		logger:         logger.With().Str("component", "gateway").Logger(),
@@ -57,6 +78,28 @@ func NewService(cfg *config.KLF200Config, loxoneCfg *config.LoxoneConfig, logger
 	}
 }
 
+// SetStore enables persistence of node state and history.
+// Previously persisted nodes are restored so the last known state is available before the KLF-200 connects.
+func (s *Service) SetStore(store *storage.Store) {
+	s.store = store
+
+	nodes, err := store.LoadNodes()
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to load persisted nodes")
+		return
+	}
+	if len(nodes) > 0 && s.nodes.NodeCount() == 0 {
+		// Limitations are volatile, they are queried again after connecting
+		for _, node := range nodes {
+			node.Limitation = nil
+		}
+		s.nodes.SetNodes(nodes)
+		s.logger.Info().Int("count", len(nodes)).Msg("Restored persisted nodes")
+	}
+
+	s.restoreMaintenance()
+}
+
 // Start starts the gateway service
 func (s *Service) Start(ctx context.Context) error {
 	s.logger.Info().
@@ -66,7 +109,16 @@ func (s *Service) Start(ctx context.Context) error {
 
 	// Set callbacks
 	s.client.SetNodeUpdateCallback(s.handleNodeUpdate)
-	s.client.SetSensorUpdateCallback(s.handleSensorUpdate)
+	s.client.SetLimitationUpdateCallback(s.handleLimitationUpdate)
+	s.client.SetStatusUpdateCallback(s.handleStatusUpdate)
+	s.client.SetRunStatusCallback(s.handleRunStatus)
+	s.client.SetWinkCallback(s.handleWinkDone)
+	s.client.SetDiscoveryCallback(s.handleDiscovery)
+	s.client.SetControllerCopyCallback(s.handleControllerCopy)
+	s.client.SetSystemTableCallback(s.handleSystemTableUpdate)
+	s.client.SetActivationLogCallback(s.handleActivationLogUpdated)
+	s.client.SetSceneCallbacks(s.handleSceneInit, s.handleSceneRecorded, s.handleSceneChanged)
+	s.client.SetPasswordChangeCallback(s.handlePasswordChange)
 	s.client.SetDisconnectCallback(s.handleDisconnect)
 
 	// Try initial connection (non-blocking on failure)
@@ -84,6 +136,10 @@ func (s *Service) Start(ctx context.Context) error {
 	s.wg.Add(1)
 	go s.reconnectLoop()
 
+	// Start status polling
+	s.wg.Add(1)
+	go s.statusPollLoop()
+
 	return connectErr
 }
 
@@ -103,6 +159,18 @@ func (s *Service) connect(ctx context.Context) error {
 		s.logger.Warn().Err(err).Msg("Failed to get initial nodes")
 	}
 
+	s.syncSystem(ctx)
+
+	// Send the commands issued while disconnected
+	s.replayCommandQueue(ctx)
+
+	// Catch up on what happened while disconnected (e.g. commands from remotes)
+	if n, err := s.ImportActivationLog(ctx); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to import activation log")
+	} else if n > 0 {
+		s.logger.Info().Int("lines", n).Msg("Imported activation log")
+	}
+
 	return nil
 }
 
@@ -113,9 +181,29 @@ func (s *Service) refreshNodes(ctx context.Context) error {
 		return err
 	}
 
+	previous := make(map[uint8]*klf200.Node)
+	for _, node := range s.nodes.GetAllNodes() {
+		previous[node.ID] = node
+	}
+
 	s.nodes.SetNodes(nodes)
 	s.logger.Info().Int("count", len(nodes)).Msg("Refreshed nodes")
 
+	// The first discovery is not a change
+	if len(previous) > 0 {
+		s.diffNodes(previous, nodes)
+	}
+	for _, mapping := range s.OrphanedMappings() {
+		s.logger.Warn().
+			Str("mapping", mapping.Name).
+			Uint8("node", mapping.NodeID).
+			Msg("Mapping points to a node that does not exist")
+	}
+
+	for _, node := range nodes {
+		s.persistNode(previous[node.ID], node)
+	}
+
 	return nil
 }
 
@@ -137,6 +225,8 @@ func (s *Service) refreshLoop() {
 					s.logger.Warn().Err(err).Msg("Failed to refresh nodes")
 				}
 				cancel()
+				// Re-derive the sensor status so that expired limitations are released
+				s.updateSensorStatus()
 			}
 		}
 	}
@@ -170,13 +260,69 @@ func (s *Service) reconnectLoop() {
 
 // handleNodeUpdate handles node position updates
 func (s *Service) handleNodeUpdate(node *klf200.Node) {
+	previous, _ := s.nodes.GetNode(node.ID)
 	s.nodes.UpdateNode(node)
-	s.logger.Debug().
-		Uint8("id", node.ID).
-		Float64("position", node.PositionPercent).
-		Msg("Node position updated")
 
-	s.sendNodeUDPFeedback(node)
+	// The update may carry no position (run status), report the merged state
+	if current, ok := s.nodes.GetNode(node.ID); ok {
+		s.logger.Debug().
+			Uint8("id", current.ID).
+			Float64("position", current.PositionPercent).
+			Msg("Node position updated")
+
+		s.sendNodeUDPFeedback(current)
+		s.persistNode(previous, current)
+		if previous != nil && previous.CurrentPosition != current.CurrentPosition {
+			s.sceneNodeMoved(node.ID)
+		}
+	}
+}
+
+// persistNode stores the node state and appends a history entry if position, target or state changed.
+// Updates that change nothing but the update time are not written, every write is an fsync.
+func (s *Service) persistNode(previous, current *klf200.Node) {
+	if s.store == nil || !nodeChanged(previous, current) {
+		return
+	}
+
+	if previous != nil &&
+		previous.CurrentPosition == current.CurrentPosition &&
+		previous.TargetPosition == current.TargetPosition &&
+		previous.State == current.State {
+		if err := s.store.SaveNode(current); err != nil {
+			s.logger.Warn().Err(err).Uint8("id", current.ID).Msg("Failed to persist node")
+		}
+		return
+	}
+
+	// History is recorded in displayed (calibrated) positions
+	calibrated := s.calibrateNode(current)
+	entry := storage.NodeHistoryEntry{
+		Time:            calibrated.LastUpdate,
+		NodeID:          calibrated.ID,
+		PositionPercent: calibrated.PositionPercent,
+		TargetPercent:   calibrated.TargetPercent,
+		State:           calibrated.State,
+		StateStr:        calibrated.StateStr,
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if err := s.store.SaveNodeWithHistory(current, entry); err != nil {
+		s.logger.Warn().Err(err).Uint8("id", current.ID).Msg("Failed to persist node")
+	}
+}
+
+// nodeChanged reports whether a node differs from its previous state in more than
+// the update time and the remaining movement time
+func nodeChanged(previous, current *klf200.Node) bool {
+	if previous == nil {
+		return true
+	}
+	a, b := *previous, *current
+	a.LastUpdate, b.LastUpdate = time.Time{}, time.Time{}
+	a.RemainingTime, b.RemainingTime = 0, 0
+	return !reflect.DeepEqual(a, b)
 }
 
 // sendNodeUDPFeedback sends position/state updates for a node via UDP
@@ -190,12 +336,37 @@ func (s *Service) sendNodeUDPFeedback(node *klf200.Node) {
 		return
 	}
 
+	node = s.calibrateNode(node)
 	id := mapping.LoxoneID
 	s.udpSender.Send(id, "position", int(node.PositionPercent))
 	s.udpSender.Send(id, "target", int(node.TargetPercent))
 	s.udpSender.Send(id, "state", int(node.State))
 }
 
+// handleLimitationUpdate stores a node limitation and updates the derived sensor status
+func (s *Service) handleLimitationUpdate(status *klf200.LimitationStatus) {
+	if !s.nodes.UpdateLimitation(status) {
+		s.logger.Debug().Uint8("id", status.NodeID).Msg("Limitation for unknown node ignored")
+		return
+	}
+	s.updateSensorStatus()
+}
+
+// updateSensorStatus derives the house-wide sensor status and handles rain/wind changes
+func (s *Service) updateSensorStatus() {
+	status := s.nodes.SensorStatus()
+
+	s.sensorStatusMu.Lock()
+	changed := status.RainDetected != s.sensorStatus.RainDetected ||
+		status.WindDetected != s.sensorStatus.WindDetected
+	s.sensorStatus = status
+	s.sensorStatusMu.Unlock()
+
+	if changed {
+		s.handleSensorUpdate(status)
+	}
+}
+
 // handleSensorUpdate handles sensor status changes and sends UDP feedback
 func (s *Service) handleSensorUpdate(status klf200.SensorStatus) {
 	s.logger.Debug().
@@ -203,6 +374,19 @@ func (s *Service) handleSensorUpdate(status klf200.SensorStatus) {
 		Bool("wind", status.WindDetected).
 		Msg("Sensor status changed")
 
+	if s.store != nil {
+		entry := storage.SensorHistoryEntry{
+			Time:         status.LastUpdate,
+			RainDetected: status.RainDetected,
+			WindDetected: status.WindDetected,
+		}
+		if err := s.store.AppendSensorHistory(entry); err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to append sensor history")
+		}
+	}
+
+	s.applyProtection(status)
+
 	if !s.udpSender.IsEnabled() {
 		return
 	}
@@ -261,14 +445,30 @@ func (s *Service) IsConnected() bool {
 	return s.client.IsAuthenticated()
 }
 
-// GetNodes returns all nodes
+// GetNodes returns all nodes (positions calibrated, expired locks dropped)
 func (s *Service) GetNodes() []*klf200.Node {
-	return s.nodes.GetAllNodes()
+	now := time.Now()
+	nodes := s.nodes.GetAllNodes()
+	for i, node := range nodes {
+		nodes[i] = s.calibrateNode(node)
+		if !node.Lock.Active(now) {
+			nodes[i].Lock = nil
+		}
+	}
+	return nodes
 }
 
-// GetNode returns a node by ID
+// GetNode returns a node by ID (position calibrated, expired lock dropped)
 func (s *Service) GetNode(id uint8) (*klf200.Node, bool) {
-	return s.nodes.GetNode(id)
+	node, ok := s.nodes.GetNode(id)
+	if !ok {
+		return nil, false
+	}
+	calibrated := s.calibrateNode(node)
+	if !node.Lock.Active(time.Now()) {
+		calibrated.Lock = nil
+	}
+	return calibrated, true
 }
 
 // GetNodeCount returns the number of nodes
@@ -276,45 +476,112 @@ func (s *Service) GetNodeCount() int {
 	return s.nodes.NodeCount()
 }
 
-// SetPosition sets the position of a node
+// SetPosition sets the position of a node. While disconnected the command is queued
+// if the command queue is enabled (ErrCommandQueued). Rapid commands for the same node
+// are coalesced, only the last target is sent and its result is returned to all callers.
 func (s *Service) SetPosition(ctx context.Context, nodeID uint8, percent float64) error {
+	if !s.client.IsAuthenticated() {
+		return s.queueCommand(ctx, nodeID, QueueActionPosition, percent)
+	}
+
+	s.dropQueuedCommand(nodeID)
+	return s.debounceMove(ctx, nodeID, percent)
+}
+
+// setPositionWithPriority sets the position of a node with an explicit command priority.
+// Used for protective moves, which bypass the interlock rules.
+func (s *Service) setPositionWithPriority(ctx context.Context, nodeID uint8, percent float64, priority klf200.Priority) error {
 	if !s.client.IsAuthenticated() {
 		return fmt.Errorf("not connected to KLF-200")
 	}
 
-	return s.client.SetPosition(ctx, nodeID, percent)
+	s.cancelSequence(nodeID)
+
+	mapping := s.mappingManager.GetByNodeID(nodeID)
+	device := toDevicePercent(mapping, limitPosition(mapping, percent))
+	return s.client.SetPositionWithPriority(ctx, nodeID, device, priority)
 }
 
-// Open fully opens a node
+// Open fully opens a node (or as far as its limits allow)
 func (s *Service) Open(ctx context.Context, nodeID uint8) error {
 	if !s.client.IsAuthenticated() {
-		return fmt.Errorf("not connected to KLF-200")
+		return s.queueCommand(ctx, nodeID, QueueActionPosition, 0)
 	}
 
-	return s.client.Open(ctx, nodeID)
+	s.dropQueuedCommand(nodeID)
+	return s.debounceMove(ctx, nodeID, 0)
 }
 
-// Close fully closes a node
+// Close fully closes a node (or as far as its limits allow)
 func (s *Service) Close(ctx context.Context, nodeID uint8) error {
 	if !s.client.IsAuthenticated() {
-		return fmt.Errorf("not connected to KLF-200")
+		return s.queueCommand(ctx, nodeID, QueueActionPosition, 100)
 	}
 
-	return s.client.Close(ctx, nodeID)
+	s.dropQueuedCommand(nodeID)
+	return s.debounceMove(ctx, nodeID, 100)
 }
 
-// StopNode stops a node's movement
-func (s *Service) StopNode(ctx context.Context, nodeID uint8) error {
-	if !s.client.IsAuthenticated() {
-		return fmt.Errorf("not connected to KLF-200")
+// moveTo clamps the position to the node limits, checks the interlock rules
+// and sends the calibrated device position
+func (s *Service) moveTo(ctx context.Context, nodeID uint8, percent float64) error {
+	mapping := s.mappingManager.GetByNodeID(nodeID)
+
+	target := limitPosition(mapping, percent)
+	if target != percent {
+		s.logger.Debug().
+			Uint8("node", nodeID).
+			Float64("requested", percent).
+			Float64("limited", target).
+			Msg("Position clamped to node limits")
 	}
 
-	return s.client.Stop(ctx, nodeID)
+	device := toDevicePercent(mapping, target)
+	return s.moveNode(ctx, nodeID, target, func(ctx context.Context) error {
+		return s.client.SetPositionWithPriority(ctx, nodeID, device, s.commandPriority(ctx, nodeID))
+	})
+}
+
+// StopNode stops a node's movement. A pending debounced or queued move is dropped,
+// its callers get the result of the stop. Stops are never queued and never rejected
+// by maintenance or locks.
+func (s *Service) StopNode(ctx context.Context, nodeID uint8) error {
+	s.dropQueuedCommand(nodeID)
+	s.cancelSequence(nodeID)
+	pending := s.takePendingMove(nodeID)
+
+	err := fmt.Errorf("not connected to KLF-200")
+	if s.client.IsAuthenticated() {
+		err = s.client.Stop(ctx, nodeID)
+	}
+	resolveMove(pending, err)
+	return err
 }
 
-// GetSensorStatus returns the current sensor status
+// GetSensorStatus returns the house-wide sensor status derived from the node limitations
 func (s *Service) GetSensorStatus() klf200.SensorStatus {
-	return s.client.GetSensorStatus()
+	return s.nodes.SensorStatus()
+}
+
+// IsHistoryEnabled returns true if node state and history are persisted
+func (s *Service) IsHistoryEnabled() bool {
+	return s.store != nil
+}
+
+// GetNodeHistory returns the recorded changes of a node in the given time range
+func (s *Service) GetNodeHistory(nodeID uint8, from, to time.Time) ([]storage.NodeHistoryEntry, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("history storage is not enabled")
+	}
+	return s.store.NodeHistory(nodeID, from, to)
+}
+
+// GetSensorHistory returns the recorded sensor changes in the given time range
+func (s *Service) GetSensorHistory(from, to time.Time) ([]storage.SensorHistoryEntry, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("history storage is not enabled")
+	}
+	return s.store.SensorHistory(from, to)
 }
 
 // RefreshSensorStatus queries the KLF-200 for current sensor/limitation status
@@ -330,7 +597,12 @@ func (s *Service) RefreshSensorStatus(ctx context.Context) error {
 		nodeIDs[i] = n.ID
 	}
 
-	return s.client.RefreshSensorStatus(ctx, nodeIDs)
+	// Limitation status may not be supported by all firmware versions,
+	// keep the last known values instead of failing
+	if err := s.client.RequestLimitationStatus(ctx, nodeIDs); err != nil {
+		s.logger.Warn().Err(err).Msg("Sensor status refresh failed, keeping previous values")
+	}
+	return nil
 }
 
 // Reconnect disconnects and reconnects to the KLF-200
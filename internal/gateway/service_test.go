@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// newTestService returns a service that is not connected, with the given nodes known
+func newTestService(nodes ...*klf200.Node) *Service {
+	s := NewService(&config.KLF200Config{}, nil, zerolog.Nop())
+	s.nodes.SetNodes(nodes)
+	return s
+}
+
+func TestNodeChanged(t *testing.T) {
+	previous := &klf200.Node{ID: 1, PositionPercent: 40, State: klf200.NodeStateDone, LastUpdate: time.Unix(100, 0)}
+
+	tests := []struct {
+		name     string
+		previous *klf200.Node
+		current  klf200.Node
+		want     bool
+	}{
+		{name: "new node", current: *previous, want: true},
+		{name: "unchanged", previous: previous, current: *previous},
+		{name: "only the update time", previous: previous, current: klf200.Node{ID: 1, PositionPercent: 40, State: klf200.NodeStateDone, LastUpdate: time.Unix(200, 0)}},
+		{name: "only the remaining time", previous: previous, current: klf200.Node{ID: 1, PositionPercent: 40, State: klf200.NodeStateDone, LastUpdate: time.Unix(100, 0), RemainingTime: 5}},
+		{name: "position", previous: previous, current: klf200.Node{ID: 1, PositionPercent: 50, State: klf200.NodeStateDone, LastUpdate: time.Unix(100, 0)}, want: true},
+		{name: "state", previous: previous, current: klf200.Node{ID: 1, PositionPercent: 40, State: klf200.NodeStateExecuting, LastUpdate: time.Unix(100, 0)}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := tt.current
+			if got := nodeChanged(tt.previous, &current); got != tt.want {
+				t.Errorf("nodeChanged = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
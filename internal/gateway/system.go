@@ -0,0 +1,147 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// SystemInfo is the version, state and clock information of the KLF-200
+type SystemInfo struct {
+	Version     *klf200.GatewayVersion `json:"version,omitempty"`
+	Protocol    string                 `json:"protocol_version,omitempty"`
+	State       *klf200.GatewayState   `json:"state,omitempty"`
+	TimeZone    string                 `json:"time_zone,omitempty"`
+	ClockSynced *time.Time             `json:"clock_synced,omitempty"`
+	ClockError  string                 `json:"clock_error,omitempty"`
+	UpdatedAt   *time.Time             `json:"updated_at,omitempty"`
+}
+
+type system struct {
+	mu               sync.Mutex
+	info             SystemInfo
+	onPasswordChange func(password string)
+}
+
+// GetSystemInfo returns the last known KLF-200 system information
+func (s *Service) GetSystemInfo() SystemInfo {
+	s.system.mu.Lock()
+	defer s.system.mu.Unlock()
+	return s.system.info
+}
+
+// RefreshSystemInfo reads the version and state of the KLF-200
+func (s *Service) RefreshSystemInfo(ctx context.Context) (SystemInfo, error) {
+	if !s.client.IsAuthenticated() {
+		return s.GetSystemInfo(), fmt.Errorf("not connected to KLF-200")
+	}
+
+	version, err := s.client.GetVersion(ctx)
+	if err != nil {
+		return s.GetSystemInfo(), fmt.Errorf("failed to get version: %w", err)
+	}
+	protocol, err := s.client.GetProtocolVersion(ctx)
+	if err != nil {
+		return s.GetSystemInfo(), fmt.Errorf("failed to get protocol version: %w", err)
+	}
+	state, err := s.client.GetState(ctx)
+	if err != nil {
+		return s.GetSystemInfo(), fmt.Errorf("failed to get state: %w", err)
+	}
+
+	now := time.Now()
+	s.system.mu.Lock()
+	s.system.info.Version = version
+	s.system.info.Protocol = protocol.String()
+	s.system.info.State = state
+	s.system.info.UpdatedAt = &now
+	info := s.system.info
+	s.system.mu.Unlock()
+
+	return info, nil
+}
+
+// SyncClock sets the KLF-200 clock to the current time and the configured time zone.
+// The KLF-200 RTC drifts, which would break the timestamps of its activation log.
+func (s *Service) SyncClock(ctx context.Context) error {
+	if !s.client.IsAuthenticated() {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+
+	s.mu.RLock()
+	timeZone := s.cfg.TimeZone
+	s.mu.RUnlock()
+
+	err := s.client.SetUTC(ctx, time.Now())
+	if err == nil && timeZone != "" {
+		if tzErr := s.client.SetTimeZone(ctx, timeZone); tzErr != nil {
+			err = fmt.Errorf("failed to set time zone: %w", tzErr)
+		}
+	}
+
+	s.system.mu.Lock()
+	defer s.system.mu.Unlock()
+
+	s.system.info.TimeZone = timeZone
+	if err != nil {
+		s.system.info.ClockError = err.Error()
+		return err
+	}
+	now := time.Now()
+	s.system.info.ClockSynced = &now
+	s.system.info.ClockError = ""
+	return nil
+}
+
+// syncSystem reads the system information and syncs the clock after connecting
+func (s *Service) syncSystem(ctx context.Context) {
+	info, err := s.RefreshSystemInfo(ctx)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to read KLF-200 system information")
+	} else {
+		s.logger.Info().
+			Str("firmware", info.Version.Software).
+			Str("protocol", info.Protocol).
+			Str("state", info.State.StateStr).
+			Msg("KLF-200 system information")
+	}
+
+	if err := s.SyncClock(ctx); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to sync KLF-200 clock")
+	} else {
+		s.logger.Info().Msg("KLF-200 clock synced")
+	}
+}
+
+// SetPasswordChangeHandler sets the function that persists a password changed by another
+// client of the KLF-200, otherwise the next restart cannot authenticate
+func (s *Service) SetPasswordChangeHandler(handler func(password string)) {
+	s.system.mu.Lock()
+	defer s.system.mu.Unlock()
+	s.system.onPasswordChange = handler
+}
+
+// handlePasswordChange is called when another client changed the KLF-200 password
+func (s *Service) handlePasswordChange(password string) {
+	s.system.mu.Lock()
+	handler := s.system.onPasswordChange
+	s.system.mu.Unlock()
+
+	if handler == nil {
+		s.logger.Warn().Msg("KLF-200 password was changed by another client, update klf200.password in the configuration")
+		return
+	}
+	handler(password)
+}
+
+// ChangePassword changes the KLF-200 password. The caller is responsible for
+// persisting the new password, otherwise the next restart cannot authenticate.
+func (s *Service) ChangePassword(ctx context.Context, newPassword string) error {
+	if !s.client.IsAuthenticated() {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+	return s.client.ChangePassword(ctx, newPassword)
+}
@@ -0,0 +1,145 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/events"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// winkGracePeriod is how long after the wink time a missing wink notification is reported as failure
+const winkGracePeriod = 30 * time.Second
+
+// WinkResult is the event data of the wink events
+type WinkResult struct {
+	SessionID   uint16 `json:"session_id"`
+	Seconds     uint8  `json:"seconds"`
+	StatusReply uint8  `json:"status_reply,omitempty"`
+}
+
+type winkSession struct {
+	nodeID    uint8
+	seconds   uint8
+	failed    *klf200.StatusReply
+	confirmed bool // The KLF-200 accepted the wink request
+	done      bool // The wink notification arrived before the confirmation
+	timer     *time.Timer
+}
+
+// winks tracks the wink sessions waiting for their result
+type winks struct {
+	mu       sync.Mutex
+	sessions map[uint16]*winkSession
+}
+
+// Wink makes a node give its identification signal. winkTime is in seconds,
+// 0 uses the manufacturer default. The result is reported through the event hub.
+func (s *Service) Wink(ctx context.Context, nodeID uint8, winkTime uint8) error {
+	if !s.client.IsAuthenticated() {
+		return fmt.Errorf("not connected to KLF-200")
+	}
+	if _, ok := s.nodes.GetNode(nodeID); !ok {
+		return klf200.ErrNodeNotFound
+	}
+	if winkTime == 0 || winkTime > klf200.WinkTimeMaxSeconds {
+		winkTime = klf200.WinkTimeManufacturerDefault
+	}
+
+	// The session is registered before sending, the notifications can arrive before the confirmation
+	sessionID := s.client.NewSessionID()
+	session := &winkSession{nodeID: nodeID, seconds: winkTime}
+	s.winks.mu.Lock()
+	if s.winks.sessions == nil {
+		s.winks.sessions = make(map[uint16]*winkSession)
+	}
+	s.winks.sessions[sessionID] = session
+	s.winks.mu.Unlock()
+
+	if err := s.client.Wink(ctx, sessionID, []uint8{nodeID}, winkTime); err != nil {
+		s.winks.mu.Lock()
+		delete(s.winks.sessions, sessionID)
+		s.winks.mu.Unlock()
+
+		s.events.PublishNode(events.TypeWinkFailed, nodeID,
+			fmt.Sprintf("Wink of node %d failed: %v", nodeID, err), nil)
+		return err
+	}
+
+	timeout := winkGracePeriod
+	if winkTime <= klf200.WinkTimeMaxSeconds {
+		timeout += time.Duration(winkTime) * time.Second
+	}
+
+	s.winks.mu.Lock()
+	session.confirmed = true
+	session.timer = time.AfterFunc(timeout, func() { s.finishWink(sessionID, true) })
+	done := session.done
+	s.winks.mu.Unlock()
+
+	s.events.PublishNode(events.TypeWinkStarted, nodeID,
+		fmt.Sprintf("Node %d is giving its identification signal", nodeID),
+		WinkResult{SessionID: sessionID, Seconds: winkTime})
+
+	if done {
+		s.finishWink(sessionID, false)
+	}
+	return nil
+}
+
+// handleRunStatus records failures of wink sessions (e.g. the node is out of reach)
+func (s *Service) handleRunStatus(sessionID uint16, nodeID uint8, runStatus klf200.RunStatus, reply klf200.StatusReply) {
+	if runStatus != klf200.RunStatusExecutionFailed {
+		return
+	}
+
+	s.winks.mu.Lock()
+	defer s.winks.mu.Unlock()
+
+	if session, ok := s.winks.sessions[sessionID]; ok && session.nodeID == nodeID {
+		session.failed = &reply
+	}
+}
+
+// handleWinkDone is called when the KLF-200 reports the end of a wink session
+func (s *Service) handleWinkDone(sessionID uint16) {
+	s.finishWink(sessionID, false)
+}
+
+// finishWink reports the result of a wink session
+func (s *Service) finishWink(sessionID uint16, timedOut bool) {
+	s.winks.mu.Lock()
+	session, ok := s.winks.sessions[sessionID]
+	if ok && !session.confirmed {
+		// Reported once the request is confirmed, after the started event
+		session.done = true
+		s.winks.mu.Unlock()
+		return
+	}
+	delete(s.winks.sessions, sessionID)
+	s.winks.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	session.timer.Stop()
+
+	result := WinkResult{SessionID: sessionID, Seconds: session.seconds}
+	switch {
+	case session.failed != nil:
+		result.StatusReply = uint8(*session.failed)
+		s.logger.Warn().Uint8("node", session.nodeID).Uint8("reply", result.StatusReply).Msg("Wink failed")
+		s.events.PublishNode(events.TypeWinkFailed, session.nodeID,
+			fmt.Sprintf("Wink of node %d failed (status reply 0x%02X)", session.nodeID, result.StatusReply), result)
+	case timedOut:
+		s.logger.Warn().Uint8("node", session.nodeID).Msg("Wink not confirmed by KLF-200")
+		s.events.PublishNode(events.TypeWinkFailed, session.nodeID,
+			fmt.Sprintf("Wink of node %d was not confirmed", session.nodeID), result)
+	default:
+		s.logger.Info().Uint8("node", session.nodeID).Msg("Wink completed")
+		s.events.PublishNode(events.TypeWinkCompleted, session.nodeID,
+			fmt.Sprintf("Node %d finished its identification signal", session.nodeID), result)
+	}
+}
@@ -0,0 +1,64 @@
+package gateway
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/events"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+func TestFinishWink(t *testing.T) {
+	tests := []struct {
+		name      string
+		confirmed bool
+		failed    bool
+		timedOut  bool
+		want      []string
+		wantKept  bool
+	}{
+		{name: "completed", confirmed: true, want: []string{events.TypeWinkCompleted}},
+		{name: "node failed", confirmed: true, failed: true, want: []string{events.TypeWinkFailed}},
+		{name: "timed out", confirmed: true, timedOut: true, want: []string{events.TypeWinkFailed}},
+		{name: "done before the confirmation", wantKept: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(&klf200.Node{ID: 1})
+			session := &winkSession{nodeID: 1, seconds: 5, confirmed: tt.confirmed, timer: time.NewTimer(time.Hour)}
+			s.winks.sessions = map[uint16]*winkSession{7: session}
+
+			if tt.failed {
+				s.handleRunStatus(7, 1, klf200.RunStatusExecutionFailed, klf200.StatusReply(0x02))
+			}
+			s.finishWink(7, tt.timedOut)
+
+			var got []string
+			for _, e := range s.Events().Recent(0) {
+				got = append(got, e.Type)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+
+			_, kept := s.winks.sessions[7]
+			if kept != tt.wantKept || (tt.wantKept && !session.done) {
+				t.Errorf("session kept = %v (done %v), want %v", kept, session.done, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestHandleRunStatusOtherNode(t *testing.T) {
+	s := newTestService(&klf200.Node{ID: 1})
+	session := &winkSession{nodeID: 1, confirmed: true, timer: time.NewTimer(time.Hour)}
+	s.winks.sessions = map[uint16]*winkSession{7: session}
+
+	s.handleRunStatus(7, 2, klf200.RunStatusExecutionFailed, klf200.StatusReply(0x02))
+	s.handleRunStatus(7, 1, klf200.RunStatusExecutionCompleted, klf200.StatusReply(0x01))
+	if session.failed != nil {
+		t.Errorf("session failed with reply %v, want no failure", *session.failed)
+	}
+}
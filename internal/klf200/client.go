@@ -22,6 +22,9 @@ type Client struct {
 	password string
 	logger   zerolog.Logger
 
+	// Password of a running ChangePassword, its notification must not be mistaken for another client's
+	newPassword string
+
 	conn          *tls.Conn
 	connMu        sync.Mutex
 	connected     atomic.Bool
@@ -30,9 +33,20 @@ type Client struct {
 	sessionID atomic.Uint32
 
 	// Callbacks
-	onNodeUpdate   func(*Node)
-	onSensorUpdate func(SensorStatus)
-	onDisconnect   func(error)
+	onNodeUpdate       func(*Node)
+	onLimitationUpdate func(*LimitationStatus)
+	onStatusUpdate     func(*NodeStatus)
+	onRunStatus        func(sessionID uint16, nodeID uint8, runStatus RunStatus, reply StatusReply)
+	onWinkDone         func(sessionID uint16)
+	onDiscovery        func(*DiscoveryResult)
+	onControllerCopy   func(*ControllerCopyResult)
+	onSystemTable      func(*SystemTableUpdate)
+	onActivationLog    func()
+	onSceneInit        func(*SceneInitResult)
+	onSceneRecorded    func(*SceneRecordResult)
+	onSceneChanged     func(*SceneChange)
+	onPasswordChange   func(password string)
+	onDisconnect       func(error)
 
 	// Read buffer for SLIP framing
 	readBuf bytes.Buffer
@@ -40,12 +54,9 @@ type Client struct {
 
 	// Response channels
 	responseChan chan *Frame
+	exchange     chan struct{} // Held while a request waits for its confirmation
 	stopChan     chan struct{}
 	wg           sync.WaitGroup
-
-	// Sensor status
-	sensorStatus   SensorStatus
-	sensorStatusMu sync.RWMutex
 }
 
 // ClientConfig holds configuration for the KLF-200 client
@@ -64,6 +75,7 @@ func NewClient(cfg ClientConfig) *Client {
 		password:     cfg.Password,
 		logger:       cfg.Logger,
 		responseChan: make(chan *Frame, 100),
+		exchange:     make(chan struct{}, 1),
 		stopChan:     make(chan struct{}),
 	}
 }
@@ -86,9 +98,56 @@ func (c *Client) SetNodeUpdateCallback(cb func(*Node)) {
 	c.onNodeUpdate = cb
 }
 
-// SetSensorUpdateCallback sets the callback for sensor status changes
-func (c *Client) SetSensorUpdateCallback(cb func(SensorStatus)) {
-	c.onSensorUpdate = cb
+// SetLimitationUpdateCallback sets the callback for per-node limitation updates (rain, wind, etc.)
+func (c *Client) SetLimitationUpdateCallback(cb func(*LimitationStatus)) {
+	c.onLimitationUpdate = cb
+}
+
+// SetStatusUpdateCallback sets the callback for status request answers (GW_STATUS_REQUEST_NTF)
+func (c *Client) SetStatusUpdateCallback(cb func(*NodeStatus)) {
+	c.onStatusUpdate = cb
+}
+
+// SetRunStatusCallback sets the callback for the run status of a command session (GW_COMMAND_RUN_STATUS_NTF)
+func (c *Client) SetRunStatusCallback(cb func(sessionID uint16, nodeID uint8, runStatus RunStatus, reply StatusReply)) {
+	c.onRunStatus = cb
+}
+
+// SetWinkCallback sets the callback for finished winks (GW_WINK_SEND_NTF)
+func (c *Client) SetWinkCallback(cb func(sessionID uint16)) {
+	c.onWinkDone = cb
+}
+
+// SetDiscoveryCallback sets the callback for finished node discoveries (GW_CS_DISCOVER_NODES_NTF)
+func (c *Client) SetDiscoveryCallback(cb func(*DiscoveryResult)) {
+	c.onDiscovery = cb
+}
+
+// SetControllerCopyCallback sets the callback for finished or cancelled controller copies
+func (c *Client) SetControllerCopyCallback(cb func(*ControllerCopyResult)) {
+	c.onControllerCopy = cb
+}
+
+// SetSystemTableCallback sets the callback for changes of the system table (GW_CS_SYSTEM_TABLE_UPDATE_NTF)
+func (c *Client) SetSystemTableCallback(cb func(*SystemTableUpdate)) {
+	c.onSystemTable = cb
+}
+
+// SetSceneCallbacks sets the callbacks for scene initialization, recording and changes
+func (c *Client) SetSceneCallbacks(onInit func(*SceneInitResult), onRecorded func(*SceneRecordResult), onChanged func(*SceneChange)) {
+	c.onSceneInit = onInit
+	c.onSceneRecorded = onRecorded
+	c.onSceneChanged = onChanged
+}
+
+// SetActivationLogCallback sets the callback for new activation log lines (GW_ACTIVATION_LOG_UPDATED_NTF)
+func (c *Client) SetActivationLogCallback(cb func()) {
+	c.onActivationLog = cb
+}
+
+// SetPasswordChangeCallback sets the callback for password changes made by other clients (GW_PASSWORD_CHANGE_NTF)
+func (c *Client) SetPasswordChangeCallback(cb func(password string)) {
+	c.onPasswordChange = cb
 }
 
 // SetDisconnectCallback sets the callback for disconnection
@@ -185,6 +244,11 @@ func (c *Client) Authenticate(ctx context.Context) error {
 		Str("password", c.password).
 		Msg("Sending password frame")
 
+	if err := c.begin(ctx); err != nil {
+		return err
+	}
+	defer c.end()
+
 	if err := c.sendRaw(frame); err != nil {
 		return fmt.Errorf("failed to send password: %w", err)
 	}
@@ -216,7 +280,7 @@ func (c *Client) Authenticate(ctx context.Context) error {
 	return nil
 }
 
-// enableHouseStatusMonitor enables notifications for position changes
+// enableHouseStatusMonitor enables notifications for position changes; the caller holds the exchange
 func (c *Client) enableHouseStatusMonitor(ctx context.Context) error {
 	frame := BuildHouseStatusMonitorEnableRequest()
 	if err := c.sendRaw(frame); err != nil {
@@ -236,6 +300,11 @@ func (c *Client) GetAllNodes(ctx context.Context) ([]*Node, error) {
 	c.logger.Debug().Msg("Getting all nodes")
 
 	frame := BuildGetAllNodesRequest()
+	if err := c.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer c.end()
+
 	if err := c.sendRaw(frame); err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -249,7 +318,7 @@ func (c *Client) GetAllNodes(ctx context.Context) ([]*Node, error) {
 	// Collect node notifications
 	var nodes []*Node
 	for {
-		resp, err := c.waitForResponse(ctx, 0, 5*time.Second) // Accept any response
+		resp, err := c.waitForResponses(ctx, 5*time.Second, GW_GET_ALL_NODES_INFORMATION_NTF, GW_GET_ALL_NODES_INFORMATION_FINISHED_NTF)
 		if err != nil {
 			return nodes, nil // Timeout means no more nodes
 		}
@@ -272,28 +341,187 @@ func (c *Client) GetAllNodes(ctx context.Context) ([]*Node, error) {
 	}
 }
 
+// GetNodeInformation retrieves the information of a single node from the KLF-200
+func (c *Client) GetNodeInformation(ctx context.Context, nodeID uint8) (*Node, error) {
+	if !c.authenticated.Load() {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	c.logger.Debug().Uint8("node", nodeID).Msg("Getting node information")
+
+	if err := c.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer c.end()
+
+	if err := c.sendRaw(BuildGetNodeInformationRequest(nodeID)); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	resp, err := c.waitForResponse(ctx, GW_GET_NODE_INFORMATION_CFM, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get confirmation: %w", err)
+	}
+
+	status, _, err := ParseGetNodeInformationConfirm(resp.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	switch status {
+	case StatusOK:
+	case StatusErrorInvalidIndex:
+		return nil, ErrNodeNotFound
+	default:
+		return nil, fmt.Errorf("node information request rejected (status %d)", status)
+	}
+
+	for {
+		resp, err := c.waitForResponse(ctx, GW_GET_NODE_INFORMATION_NTF, 5*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node information: %w", err)
+		}
+
+		node, err := ParseNodeInformation(resp.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node information: %w", err)
+		}
+		if node.ID != nodeID {
+			continue // Answer to an earlier request
+		}
+		node.LastUpdate = time.Now()
+		return node, nil
+	}
+}
+
+// SetNodeName renames a node
+func (c *Client) SetNodeName(ctx context.Context, nodeID uint8, name string) error {
+	if len(name) > MaxNodeNameLength {
+		return fmt.Errorf("name too long: %d bytes, max %d", len(name), MaxNodeNameLength)
+	}
+
+	c.logger.Info().Uint8("node", nodeID).Str("name", name).Msg("Setting node name")
+	return c.setNode(ctx, BuildSetNodeNameRequest(nodeID, name), GW_SET_NODE_NAME_CFM)
+}
+
+// SetNodeVelocity sets the default velocity of a node
+func (c *Client) SetNodeVelocity(ctx context.Context, nodeID uint8, velocity Velocity) error {
+	c.logger.Info().Uint8("node", nodeID).Uint8("velocity", uint8(velocity)).Msg("Setting node velocity")
+	return c.setNode(ctx, BuildSetNodeVelocityRequest(nodeID, velocity), GW_SET_NODE_VELOCITY_CFM)
+}
+
+// SetNodeOrderAndPlacement sets the position of a node in the product list and its room/group
+func (c *Client) SetNodeOrderAndPlacement(ctx context.Context, nodeID uint8, order uint16, placement uint8) error {
+	c.logger.Info().Uint8("node", nodeID).Uint16("order", order).Uint8("placement", placement).Msg("Setting node order and placement")
+	return c.setNode(ctx, BuildSetNodeOrderAndPlacementRequest(nodeID, order, placement), GW_SET_NODE_ORDER_AND_PLACEMENT_CFM)
+}
+
+// setNode sends a node configuration request and checks its confirmation
+func (c *Client) setNode(ctx context.Context, frame []byte, confirm CommandID) error {
+	if !c.authenticated.Load() {
+		return fmt.Errorf("not authenticated")
+	}
+
+	if err := c.begin(ctx); err != nil {
+		return err
+	}
+	defer c.end()
+
+	if err := c.sendRaw(frame); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	resp, err := c.waitForResponse(ctx, confirm, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to get confirmation: %w", err)
+	}
+
+	status, _, err := ParseSetNodeConfirm(resp.Data)
+	if err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	switch status {
+	case StatusOK:
+		return nil
+	case StatusErrorInvalidIndex:
+		return ErrNodeNotFound
+	default:
+		return fmt.Errorf("request rejected (status %d)", status)
+	}
+}
+
 // SetPosition sets the position of a node (0-100%)
 func (c *Client) SetPosition(ctx context.Context, nodeID uint8, percent float64) error {
+	return c.SetPositionWithPriority(ctx, nodeID, percent, PriorityUserLevel2)
+}
+
+// SetPositionWithPriority sets the position of a node (0-100%) with the given command priority
+func (c *Client) SetPositionWithPriority(ctx context.Context, nodeID uint8, percent float64, priority Priority) error {
+	return c.sendCommand(ctx, []uint8{nodeID}, PercentToPosition(percent), nil, priority, nil)
+}
+
+// SetPositions moves up to MaxCommandNodes nodes to the same position with one command.
+// tilt (0-100%) sets the slat orientation of venetian and louver blinds, nil keeps it.
+func (c *Client) SetPositions(ctx context.Context, nodeIDs []uint8, percent float64, tilt *float64) error {
+	return c.SetPositionsWithPriority(ctx, nodeIDs, percent, tilt, PriorityUserLevel2)
+}
+
+// SetPositionsWithPriority moves up to MaxCommandNodes nodes like SetPositions with the given command priority
+func (c *Client) SetPositionsWithPriority(ctx context.Context, nodeIDs []uint8, percent float64, tilt *float64, priority Priority) error {
+	if len(nodeIDs) == 0 || len(nodeIDs) > MaxCommandNodes {
+		return fmt.Errorf("a command addresses 1-%d nodes", MaxCommandNodes)
+	}
+
+	var functionalParameters []uint16
+	if tilt != nil {
+		functionalParameters = make([]uint16, TiltParameter)
+		for i := range functionalParameters {
+			functionalParameters[i] = PositionIgnore
+		}
+		functionalParameters[TiltParameter-1] = PercentToPosition(*tilt)
+	}
+	return c.sendCommand(ctx, nodeIDs, PercentToPosition(percent), functionalParameters, priority, nil)
+}
+
+// SetPriorityLock sends a command with a priority level lock to the nodes.
+// position is the raw main parameter, PositionCurrent locks the nodes without moving them.
+func (c *Client) SetPriorityLock(ctx context.Context, nodeIDs []uint8, position uint16, priority Priority, lock PriorityLevelLock) error {
+	if len(nodeIDs) == 0 || len(nodeIDs) > MaxCommandNodes {
+		return fmt.Errorf("a command addresses 1-%d nodes", MaxCommandNodes)
+	}
+
+	c.logger.Debug().
+		Interface("nodes", nodeIDs).
+		Uint16("pli", lock.Info()).
+		Uint8("time", lock.LockTime).
+		Msg("Setting priority level lock")
+
+	return c.sendCommand(ctx, nodeIDs, position, nil, priority, &lock)
+}
+
+// sendCommand sends GW_COMMAND_SEND_REQ and waits for the confirmation
+func (c *Client) sendCommand(ctx context.Context, nodeIDs []uint8, position uint16, functionalParameters []uint16,
+	priority Priority, lock *PriorityLevelLock) error {
+
 	if !c.authenticated.Load() {
 		return fmt.Errorf("not authenticated")
 	}
 
-	position := PercentToPosition(percent)
 	sessionID := uint16(c.sessionID.Add(1))
 
 	c.logger.Debug().
-		Uint8("node", nodeID).
-		Float64("percent", percent).
+		Interface("nodes", nodeIDs).
 		Uint16("position", position).
+		Uint8("priority", uint8(priority)).
 		Msg("Setting position")
 
 	frame := BuildCommandSendRequest(
 		sessionID,
 		1, // User originated
-		PriorityUserLevel2,
-		[]uint8{nodeID},
+		priority,
+		nodeIDs,
 		position,
-		nil,
+		functionalParameters,
+		lock,
 	)
 
 	c.logger.Debug().
@@ -301,57 +529,51 @@ func (c *Client) SetPosition(ctx context.Context, nodeID uint8, percent float64)
 		Int("len", len(frame)).
 		Msg("Sending command frame")
 
+	if err := c.begin(ctx); err != nil {
+		return err
+	}
+	defer c.end()
+
 	if err := c.sendRaw(frame); err != nil {
 		return fmt.Errorf("failed to send command: %w", err)
 	}
 
-	// Wait for confirmation (GW_COMMAND_SEND_CFM) or error (GW_ERROR_NTF)
-	// Skip async notifications like GW_NODE_STATE_POSITION_CHANGED_NTF
+	// Wait for the confirmation (GW_COMMAND_SEND_CFM) of this session or an error (GW_ERROR_NTF)
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	for {
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("command timeout")
-		case resp := <-c.responseChan:
-			switch resp.Command {
-			case GW_ERROR_NTF:
-				errorCode := uint8(0)
-				if len(resp.Data) > 0 {
-					errorCode = resp.Data[0]
-				}
-				c.logger.Error().
-					Uint8("errorCode", errorCode).
-					Msg("KLF-200 returned error")
-				return fmt.Errorf("KLF-200 error: code %d", errorCode)
-
-			case GW_COMMAND_SEND_CFM:
-				sessionID, status, err := ParseCommandSendConfirm(resp.Data)
-				c.logger.Debug().
-					Uint16("sessionID", sessionID).
-					Uint8("status", uint8(status)).
-					Hex("data", resp.Data).
-					Msg("Received command confirmation")
-				if err != nil {
-					return fmt.Errorf("failed to parse response: %w", err)
-				}
-				// Status 0 = accepted, Status 1 = accepted but busy (command still executes)
-				if status > 1 {
-					return fmt.Errorf("command failed with status: %d", status)
-				}
-				if status == 1 {
-					c.logger.Debug().Msg("Command accepted (node busy)")
-				} else {
-					c.logger.Debug().Msg("Command confirmed")
-				}
-				return nil
-
-			default:
-				// Handle async notifications (position changes, etc.)
-				c.handleAsyncFrame(resp)
+		resp, err := c.waitForResponses(ctx, 5*time.Second, GW_COMMAND_SEND_CFM)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("command timeout")
 			}
+			c.logger.Error().Err(err).Msg("KLF-200 returned error")
+			return err
 		}
+
+		confirmed, status, err := ParseCommandSendConfirm(resp.Data)
+		c.logger.Debug().
+			Uint16("sessionID", confirmed).
+			Uint8("status", uint8(status)).
+			Hex("data", resp.Data).
+			Msg("Received command confirmation")
+		if err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+		if confirmed != sessionID {
+			continue // Late confirmation of an earlier command
+		}
+		// Status 0 = accepted, Status 1 = accepted but busy (command still executes)
+		if status > 1 {
+			return fmt.Errorf("command failed with status: %d", status)
+		}
+		if status == 1 {
+			c.logger.Debug().Msg("Command accepted (node busy)")
+		} else {
+			c.logger.Debug().Msg("Command confirmed")
+		}
+		return nil
 	}
 }
 
@@ -367,184 +589,843 @@ func (c *Client) Close(ctx context.Context, nodeID uint8) error {
 
 // Stop stops a node's movement
 func (c *Client) Stop(ctx context.Context, nodeID uint8) error {
+	c.logger.Debug().Uint8("node", nodeID).Msg("Stopping node")
+
+	// Use current position to stop
+	return c.sendCommand(ctx, []uint8{nodeID}, PositionCurrent, nil, PriorityUserLevel2, nil)
+}
+
+// RequestLimitationStatus queries the limitation status of nodes.
+// The KLF-200 answers with one GW_LIMITATION_STATUS_NTF per node, which is
+// reported through the limitation update callback.
+func (c *Client) RequestLimitationStatus(ctx context.Context, nodeIDs []uint8) error {
 	if !c.authenticated.Load() {
 		return fmt.Errorf("not authenticated")
 	}
 
 	sessionID := uint16(c.sessionID.Add(1))
 
-	c.logger.Debug().Uint8("node", nodeID).Msg("Stopping node")
+	c.logger.Debug().Interface("nodes", nodeIDs).Msg("Requesting limitation status")
 
-	// Use current position to stop
-	frame := BuildCommandSendRequest(
-		sessionID,
-		1,
-		PriorityUserLevel2,
-		[]uint8{nodeID},
-		PositionCurrent, // Keep current = stop
-		nil,
-	)
+	frame := BuildGetLimitationStatusRequest(sessionID, nodeIDs, 0) // 0 = min limitation
+	if err := c.begin(ctx); err != nil {
+		return err
+	}
+	defer c.end()
 
 	if err := c.sendRaw(frame); err != nil {
-		return fmt.Errorf("failed to send command: %w", err)
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	// Wait for confirmation
+	if _, err := c.waitForResponse(ctx, GW_GET_LIMITATION_STATUS_CFM, 5*time.Second); err != nil {
+		return fmt.Errorf("failed to get confirmation: %w", err)
+	}
+
+	// Give the notifications time to arrive; the session end is not guaranteed on all firmware versions
+	if err := c.waitForSession(ctx, sessionID, 2*time.Second); err != nil {
+		c.logger.Debug().Msg("No session finished notification for limitation status request")
 	}
 
 	return nil
 }
 
-// GetLimitationStatus queries the limitation status for nodes (sensor data)
-func (c *Client) GetLimitationStatus(ctx context.Context, nodeIDs []uint8) ([]*LimitationStatus, error) {
+// RequestStatus queries the status of nodes (max 20 per request).
+// The answers are reported through the status update callback.
+func (c *Client) RequestStatus(ctx context.Context, nodeIDs []uint8, statusType StatusType) error {
+	return c.requestStatus(ctx, nodeIDs, statusType, 0, false)
+}
+
+// RequestParameterStatus queries the current position of nodes (max 20 per request) including
+// the given functional parameters (1-16). It returns after the answers have been reported
+// through the status update callback.
+func (c *Client) RequestParameterStatus(ctx context.Context, nodeIDs []uint8, params ...int) error {
+	var fpi uint16
+	for _, fp := range params {
+		if fp < 1 || fp > 16 {
+			return fmt.Errorf("functional parameter %d out of range 1-16", fp)
+		}
+		fpi |= 0x8000 >> (fp - 1)
+	}
+	return c.requestStatus(ctx, nodeIDs, StatusTypeCurrentPosition, fpi, true)
+}
+
+// requestStatus sends a status request. With wait it also waits for the end of the session,
+// the status notifications are handled on the read loop before it.
+func (c *Client) requestStatus(ctx context.Context, nodeIDs []uint8, statusType StatusType, fpi uint16, wait bool) error {
 	if !c.authenticated.Load() {
-		return nil, fmt.Errorf("not authenticated")
+		return fmt.Errorf("not authenticated")
 	}
 
 	sessionID := uint16(c.sessionID.Add(1))
 
-	c.logger.Debug().Interface("nodes", nodeIDs).Msg("Getting limitation status")
+	c.logger.Debug().
+		Interface("nodes", nodeIDs).
+		Uint8("statusType", uint8(statusType)).
+		Uint16("fpi", fpi).
+		Msg("Requesting node status")
+
+	frame := BuildStatusRequest(sessionID, nodeIDs, statusType, uint8(fpi>>8), uint8(fpi))
+	if err := c.begin(ctx); err != nil {
+		return err
+	}
+	defer c.end()
 
-	// Request both min and max limitations
-	frame := BuildGetLimitationStatusRequest(sessionID, nodeIDs, 0) // 0 = min limitation
 	if err := c.sendRaw(frame); err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return fmt.Errorf("failed to send request: %w", err)
 	}
 
-	// Wait for confirmation
-	_, err := c.waitForResponse(ctx, GW_GET_LIMITATION_STATUS_CFM, 5*time.Second)
+	resp, err := c.waitForResponse(ctx, GW_STATUS_REQUEST_CFM, 5*time.Second)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get confirmation: %w", err)
+		return fmt.Errorf("failed to get confirmation: %w", err)
 	}
 
-	// Collect limitation notifications
-	var limitations []*LimitationStatus
-	for {
-		resp, err := c.waitForResponse(ctx, 0, 2*time.Second)
-		if err != nil {
-			// Timeout means no more notifications
-			break
+	_, accepted, err := ParseStatusRequestConfirm(resp.Data)
+	if err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !accepted {
+		return fmt.Errorf("status request rejected by KLF-200")
+	}
+
+	if wait {
+		// Battery powered nodes can take a few seconds to answer
+		if err := c.waitForSession(ctx, sessionID, 10*time.Second); err != nil {
+			return fmt.Errorf("no status answer: %w", err)
 		}
+	}
+	return nil
+}
 
-		if resp.Command == GW_LIMITATION_STATUS_NTF {
-			status, err := ParseLimitationStatusNotification(resp.Data)
-			if err != nil {
-				c.logger.Warn().Err(err).Msg("Failed to parse limitation status")
-				continue
-			}
+// SetLimitation sets a limitation (min/max raw position) on nodes for the given limitation time.
+// Use PositionIgnore to leave a bound unchanged.
+func (c *Client) SetLimitation(ctx context.Context, nodeIDs []uint8, originator Originator, priority Priority,
+	minValue, maxValue uint16, limitationTime uint8) error {
+
+	if !c.authenticated.Load() {
+		return fmt.Errorf("not authenticated")
+	}
 
-			c.logger.Debug().
-				Uint8("nodeID", status.NodeID).
-				Uint8("origin", uint8(status.LimitationOrigin)).
-				Str("originStr", status.LimitationOrigin.String()).
-				Uint16("minValue", status.MinValue).
-				Uint16("maxValue", status.MaxValue).
-				Msg("Limitation status received")
+	sessionID := uint16(c.sessionID.Add(1))
 
-			limitations = append(limitations, status)
+	c.logger.Debug().
+		Interface("nodes", nodeIDs).
+		Str("originator", originator.String()).
+		Uint16("min", minValue).
+		Uint16("max", maxValue).
+		Uint8("time", limitationTime).
+		Msg("Setting limitation")
+
+	frame := BuildSetLimitationRequest(sessionID, originator, priority, nodeIDs, minValue, maxValue, limitationTime)
+	if err := c.begin(ctx); err != nil {
+		return err
+	}
+	defer c.end()
 
-			// Update sensor status based on limitation origin
-			c.updateSensorStatus(status)
-		}
+	if err := c.sendRaw(frame); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	resp, err := c.waitForResponse(ctx, GW_SET_LIMITATION_CFM, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to get confirmation: %w", err)
+	}
+
+	_, accepted, err := ParseSetLimitationConfirm(resp.Data)
+	if err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !accepted {
+		return fmt.Errorf("limitation rejected by KLF-200")
 	}
 
-	return limitations, nil
+	return nil
+}
+
+// ClearLimitation removes all limitations set by the originator on nodes
+func (c *Client) ClearLimitation(ctx context.Context, nodeIDs []uint8, originator Originator, priority Priority) error {
+	return c.SetLimitation(ctx, nodeIDs, originator, priority, PositionIgnore, PositionIgnore, LimitationTimeClearAll)
+}
+
+// NewSessionID returns a session ID for a request sent later, so that its notifications
+// can be matched before the request is confirmed
+func (c *Client) NewSessionID() uint16 {
+	return uint16(c.sessionID.Add(1))
 }
 
-// updateSensorStatus updates the internal sensor status based on limitation data
-func (c *Client) updateSensorStatus(status *LimitationStatus) {
-	c.sensorStatusMu.Lock()
+// Wink makes the nodes give their identification signal (e.g. a short movement or beep).
+// winkTime is in seconds, see the WinkTime constants. sessionID (see NewSessionID) is
+// reported with the run status and wink notifications.
+func (c *Client) Wink(ctx context.Context, sessionID uint16, nodeIDs []uint8, winkTime uint8) error {
+	if !c.authenticated.Load() {
+		return fmt.Errorf("not authenticated")
+	}
+
+	c.logger.Info().
+		Interface("nodes", nodeIDs).
+		Uint8("time", winkTime).
+		Uint16("sessionID", sessionID).
+		Msg("Sending wink")
 
-	oldRain := c.sensorStatus.RainDetected
-	oldWind := c.sensorStatus.WindDetected
+	frame := BuildWinkSendRequest(sessionID, OriginatorUser, PriorityUserLevel2, winkTime != WinkTimeStop, winkTime, nodeIDs)
+	if err := c.begin(ctx); err != nil {
+		return err
+	}
+	defer c.end()
 
-	c.sensorStatus.LastUpdate = time.Now()
+	if err := c.sendRaw(frame); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
 
-	switch status.LimitationOrigin {
-	case LimitationTypeRain:
-		c.sensorStatus.RainDetected = true
-	case LimitationTypeWind:
-		c.sensorStatus.WindDetected = true
+	resp, err := c.waitForResponse(ctx, GW_WINK_SEND_CFM, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to get confirmation: %w", err)
 	}
 
-	// If no limitation, sensors are clear
-	if status.LimitationOrigin == LimitationTypeNone {
-		c.sensorStatus.RainDetected = false
-		c.sensorStatus.WindDetected = false
+	_, accepted, err := ParseWinkSendConfirm(resp.Data)
+	if err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !accepted {
+		return fmt.Errorf("wink rejected by KLF-200")
 	}
 
-	changed := oldRain != c.sensorStatus.RainDetected || oldWind != c.sensorStatus.WindDetected
-	statusCopy := c.sensorStatus
-	c.sensorStatusMu.Unlock()
+	return nil
+}
 
-	if changed && c.onSensorUpdate != nil {
-		c.onSensorUpdate(statusCopy)
+// GetVersion reads the firmware and hardware version of the KLF-200
+func (c *Client) GetVersion(ctx context.Context) (*GatewayVersion, error) {
+	resp, err := c.request(ctx, EncodeFrame(GW_GET_VERSION_REQ, nil), GW_GET_VERSION_CFM)
+	if err != nil {
+		return nil, err
 	}
+	return ParseGetVersionConfirm(resp.Data)
 }
 
-// GetSensorStatus returns the current sensor status
-func (c *Client) GetSensorStatus() SensorStatus {
-	c.sensorStatusMu.RLock()
-	defer c.sensorStatusMu.RUnlock()
-	return c.sensorStatus
+// GetProtocolVersion reads the API version of the KLF-200
+func (c *Client) GetProtocolVersion(ctx context.Context) (*ProtocolVersion, error) {
+	resp, err := c.request(ctx, EncodeFrame(GW_GET_PROTOCOL_VERSION_REQ, nil), GW_GET_PROTOCOL_VERSION_CFM)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetProtocolVersionConfirm(resp.Data)
 }
 
-// RefreshSensorStatus queries all nodes for limitation status to update sensor readings
-// Returns nil even on timeout - the sensor status will keep its last known values
-func (c *Client) RefreshSensorStatus(ctx context.Context, nodeIDs []uint8) error {
-	_, err := c.GetLimitationStatus(ctx, nodeIDs)
+// GetState reads the operating state of the KLF-200
+func (c *Client) GetState(ctx context.Context) (*GatewayState, error) {
+	resp, err := c.request(ctx, EncodeFrame(GW_GET_STATE_REQ, nil), GW_GET_STATE_CFM)
 	if err != nil {
-		// Log the error but don't fail - limitation status may not be supported
-		// by all KLF-200 firmware versions or may timeout
-		c.logger.Warn().Err(err).Msg("Sensor status refresh failed, keeping previous values")
-		// Update last update time to indicate we tried
-		c.sensorStatusMu.Lock()
-		c.sensorStatus.LastUpdate = time.Now()
-		c.sensorStatusMu.Unlock()
+		return nil, err
 	}
-	return nil
+	return ParseGetStateConfirm(resp.Data)
 }
 
-// sendRaw sends raw bytes to the KLF-200
-func (c *Client) sendRaw(data []byte) error {
-	c.connMu.Lock()
-	defer c.connMu.Unlock()
+// SetUTC sets the clock of the KLF-200
+func (c *Client) SetUTC(ctx context.Context, t time.Time) error {
+	_, err := c.request(ctx, BuildSetUTCRequest(t), GW_SET_UTC_CFM)
+	return err
+}
 
-	if c.conn == nil {
-		return fmt.Errorf("not connected")
+// SetTimeZone sets the time zone and daylight saving rules of the KLF-200 clock
+func (c *Client) SetTimeZone(ctx context.Context, timeZone string) error {
+	if len(timeZone) > MaxTimeZoneLength {
+		return fmt.Errorf("time zone too long: %d bytes, max %d", len(timeZone), MaxTimeZoneLength)
 	}
 
-	_, err := c.conn.Write(data)
-	return err
+	resp, err := c.request(ctx, BuildSetTimeZoneRequest(timeZone), GW_RTC_SET_TIME_ZONE_CFM)
+	if err != nil {
+		return err
+	}
+	if len(resp.Data) < 1 {
+		return ErrFrameTooShort
+	}
+	if resp.Data[0] != 1 {
+		return fmt.Errorf("time zone rejected by KLF-200")
+	}
+	return nil
 }
 
-// waitForResponse waits for a specific response or any response if cmd is 0
-func (c *Client) waitForResponse(ctx context.Context, cmd CommandID, timeout time.Duration) (*Frame, error) {
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+// GetActivationLogHeader returns the capacity and the number of lines of the activation log
+func (c *Client) GetActivationLogHeader(ctx context.Context) (maxLines, lines uint16, err error) {
+	resp, err := c.request(ctx, EncodeFrame(GW_GET_ACTIVATION_LOG_HEADER_REQ, nil), GW_GET_ACTIVATION_LOG_HEADER_CFM)
+	if err != nil {
+		return 0, 0, err
+	}
+	return ParseActivationLogHeaderConfirm(resp.Data)
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case frame := <-c.responseChan:
-			if cmd == 0 || frame.Command == cmd {
-				return frame, nil
-			}
-			// Put back other responses (or handle them)
-			c.handleAsyncFrame(frame)
-		}
+// GetActivationLogLine reads a single line of the activation log (0 = oldest)
+func (c *Client) GetActivationLogLine(ctx context.Context, line uint16) (*ActivationLogLine, error) {
+	resp, err := c.request(ctx, BuildGetActivationLogLineRequest(line), GW_GET_ACTIVATION_LOG_LINE_CFM)
+	if err != nil {
+		return nil, err
 	}
+	return ParseActivationLogLine(resp.Data)
 }
 
-// handleAsyncFrame handles frames that were not expected
-func (c *Client) handleAsyncFrame(frame *Frame) {
-	switch frame.Command {
-	case GW_NODE_STATE_POSITION_CHANGED_NTF:
-		nodeID, state, position, target, err := ParseNodeStatePositionChangedFull(frame.Data)
+// GetActivationLogSince reads all activation log lines newer than since (oldest first)
+func (c *Client) GetActivationLogSince(ctx context.Context, since time.Time) ([]*ActivationLogLine, error) {
+	if !c.authenticated.Load() {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	if err := c.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer c.end()
+
+	if err := c.sendRaw(BuildGetMultipleActivationLogLinesRequest(since)); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	// The lines arrive as notifications, the confirmation follows the last one
+	lines := make([]*ActivationLogLine, 0)
+	for {
+		resp, err := c.waitForResponses(ctx, 10*time.Second,
+			GW_GET_MULTIPLE_ACTIVATION_LOG_LINES_NTF, GW_GET_MULTIPLE_ACTIVATION_LOG_LINES_CFM)
 		if err != nil {
-			c.logger.Warn().Err(err).Msg("Failed to parse position change")
-			return
+			return nil, fmt.Errorf("failed to get activation log: %w", err)
 		}
-		c.logger.Debug().
-			Uint8("nodeID", nodeID).
+
+		if resp.Command == GW_GET_MULTIPLE_ACTIVATION_LOG_LINES_CFM {
+			return lines, nil
+		}
+		line, err := ParseActivationLogLine(resp.Data)
+		if err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to parse activation log line")
+			continue
+		}
+		lines = append(lines, line)
+	}
+}
+
+// ChangePassword changes the KLF-200 password. On success the new password is
+// used for the next authentication.
+func (c *Client) ChangePassword(ctx context.Context, newPassword string) error {
+	if newPassword == "" || len(newPassword) > MaxPasswordLength {
+		return fmt.Errorf("password must be 1-%d bytes", MaxPasswordLength)
+	}
+
+	c.connMu.Lock()
+	current := c.password
+	c.newPassword = newPassword
+	c.connMu.Unlock()
+
+	c.logger.Info().Msg("Changing KLF-200 password")
+
+	resp, err := c.request(ctx, BuildPasswordChangeRequest(current, newPassword), GW_PASSWORD_CHANGE_CFM)
+	var ok bool
+	if err == nil {
+		ok, err = ParsePasswordConfirm(resp.Data)
+	}
+
+	// The new password is set before the pending one is cleared, so its notification
+	// always matches one of them
+	c.connMu.Lock()
+	if ok {
+		c.password = newPassword
+	}
+	c.newPassword = ""
+	c.connMu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("password change rejected by KLF-200")
+	}
+
+	c.logger.Info().Msg("KLF-200 password changed")
+	return nil
+}
+
+// GetSceneList reads the scenes stored in the KLF-200
+func (c *Client) GetSceneList(ctx context.Context) ([]Scene, error) {
+	if !c.authenticated.Load() {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	// The list follows the confirmation as notifications
+	if err := c.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer c.end()
+
+	resp, err := c.roundTrip(ctx, EncodeFrame(GW_GET_SCENE_LIST_REQ, nil), GW_GET_SCENE_LIST_CFM)
+	if err != nil {
+		return nil, err
+	}
+
+	scenes := make([]Scene, 0)
+	if len(resp.Data) < 1 {
+		return nil, ErrFrameTooShort
+	}
+	if resp.Data[0] == 0 {
+		return scenes, nil
+	}
+
+	for {
+		resp, err := c.waitForResponse(ctx, GW_GET_SCENE_LIST_NTF, 10*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get scene list: %w", err)
+		}
+
+		part, remaining, err := ParseSceneListNotification(resp.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse scene list: %w", err)
+		}
+		scenes = append(scenes, part...)
+		if remaining == 0 {
+			return scenes, nil
+		}
+	}
+}
+
+// InitializeScene starts recording a scene. The KLF-200 reads the state of all nodes,
+// the result is passed to the scene initialization callback. Nodes operated
+// afterwards are stored with RecordScene.
+func (c *Client) InitializeScene(ctx context.Context) error {
+	c.logger.Info().Msg("Initializing scene recording")
+
+	resp, err := c.request(ctx, EncodeFrame(GW_INITIALIZE_SCENE_REQ, nil), GW_INITIALIZE_SCENE_CFM)
+	if err != nil {
+		return err
+	}
+	status, err := ParseSceneStatusConfirm(resp.Data)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("scene initialization rejected: no products paired")
+	case 2:
+		return fmt.Errorf("scene initialization rejected: no space for more scenes")
+	default:
+		return fmt.Errorf("scene initialization rejected (status %d)", status)
+	}
+}
+
+// CancelInitializeScene cancels a scene recording started with InitializeScene
+func (c *Client) CancelInitializeScene(ctx context.Context) error {
+	c.logger.Info().Msg("Cancelling scene recording")
+
+	resp, err := c.request(ctx, EncodeFrame(GW_INITIALIZE_SCENE_CANCEL_REQ, nil), GW_INITIALIZE_SCENE_CANCEL_CFM)
+	if err != nil {
+		return err
+	}
+	if status, err := ParseSceneStatusConfirm(resp.Data); err != nil {
+		return err
+	} else if status != 0 {
+		return fmt.Errorf("cancelling scene recording failed (status %d)", status)
+	}
+	return nil
+}
+
+// RecordScene stores the nodes operated since InitializeScene as a new scene.
+// The new scene ID is passed to the scene recorded callback.
+func (c *Client) RecordScene(ctx context.Context, name string) error {
+	if name == "" || len(name) > MaxSceneNameLength {
+		return fmt.Errorf("scene name must be 1-%d bytes", MaxSceneNameLength)
+	}
+
+	c.logger.Info().Str("name", name).Msg("Recording scene")
+
+	resp, err := c.request(ctx, BuildRecordSceneRequest(name), GW_RECORD_SCENE_CFM)
+	if err != nil {
+		return err
+	}
+	if status, err := ParseSceneStatusConfirm(resp.Data); err != nil {
+		return err
+	} else if status != 0 {
+		return fmt.Errorf("scene recording rejected (status %d)", status)
+	}
+	return nil
+}
+
+// DeleteScene deletes a scene from the KLF-200
+func (c *Client) DeleteScene(ctx context.Context, sceneID uint8) error {
+	c.logger.Info().Uint8("sceneID", sceneID).Msg("Deleting scene")
+
+	resp, err := c.request(ctx, BuildDeleteSceneRequest(sceneID), GW_DELETE_SCENE_CFM)
+	if err != nil {
+		return err
+	}
+	status, err := ParseSceneStatusConfirm(resp.Data)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case 0:
+		return nil
+	case 1:
+		return ErrSceneNotFound
+	default:
+		return fmt.Errorf("deleting scene failed (status %d)", status)
+	}
+}
+
+// RenameScene changes the name of a scene
+func (c *Client) RenameScene(ctx context.Context, sceneID uint8, name string) error {
+	if name == "" || len(name) > MaxSceneNameLength {
+		return fmt.Errorf("scene name must be 1-%d bytes", MaxSceneNameLength)
+	}
+
+	c.logger.Info().Uint8("sceneID", sceneID).Str("name", name).Msg("Renaming scene")
+
+	resp, err := c.request(ctx, BuildRenameSceneRequest(sceneID, name), GW_RENAME_SCENE_CFM)
+	if err != nil {
+		return err
+	}
+	status, err := ParseSceneStatusConfirm(resp.Data)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case 0:
+		return nil
+	case 1:
+		return ErrSceneNotFound
+	case 2:
+		return fmt.Errorf("scene name %q is already used", name)
+	default:
+		return fmt.Errorf("renaming scene failed (status %d)", status)
+	}
+}
+
+// GetContactInputLinks reads the actions linked to the contact inputs of the KLF-200
+func (c *Client) GetContactInputLinks(ctx context.Context) ([]ContactInputLink, error) {
+	resp, err := c.request(ctx, EncodeFrame(GW_GET_CONTACT_INPUT_LINK_LIST_REQ, nil), GW_GET_CONTACT_INPUT_LINK_LIST_CFM)
+	if err != nil {
+		return nil, err
+	}
+	return ParseContactInputLinkListConfirm(resp.Data)
+}
+
+// SetContactInputLink links an action to a contact input
+func (c *Client) SetContactInputLink(ctx context.Context, link ContactInputLink) error {
+	c.logger.Info().
+		Uint8("input", link.InputID).
+		Str("assignment", link.Assignment.String()).
+		Uint8("action", link.ActionID).
+		Msg("Setting contact input link")
+
+	resp, err := c.request(ctx, BuildSetContactInputLinkRequest(link), GW_SET_CONTACT_INPUT_LINK_CFM)
+	if err != nil {
+		return err
+	}
+	if len(resp.Data) < 1 {
+		return ErrFrameTooShort
+	}
+	if resp.Data[0] != 0 {
+		return fmt.Errorf("setting contact input link rejected (status %d)", resp.Data[0])
+	}
+	return nil
+}
+
+// RemoveContactInputLink removes the action linked to a contact input
+func (c *Client) RemoveContactInputLink(ctx context.Context, inputID uint8) error {
+	c.logger.Info().Uint8("input", inputID).Msg("Removing contact input link")
+
+	resp, err := c.request(ctx, BuildRemoveContactInputLinkRequest(inputID), GW_REMOVE_CONTACT_INPUT_LINK_CFM)
+	if err != nil {
+		return err
+	}
+	if len(resp.Data) < 1 {
+		return ErrFrameTooShort
+	}
+	if resp.Data[0] != 0 {
+		return fmt.Errorf("removing contact input link rejected (status %d)", resp.Data[0])
+	}
+	return nil
+}
+
+// GetNetworkSetup reads the LAN configuration of the KLF-200
+func (c *Client) GetNetworkSetup(ctx context.Context) (*NetworkSetup, error) {
+	resp, err := c.request(ctx, EncodeFrame(GW_GET_NETWORK_SETUP_REQ, nil), GW_GET_NETWORK_SETUP_CFM)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNetworkSetupConfirm(resp.Data)
+}
+
+// SetNetworkSetup changes the LAN configuration of the KLF-200
+func (c *Client) SetNetworkSetup(ctx context.Context, setup NetworkSetup) error {
+	c.logger.Info().
+		Str("ip", setup.IP.String()).
+		Str("mask", setup.Mask.String()).
+		Str("gateway", setup.Gateway.String()).
+		Bool("dhcp", setup.DHCP).
+		Msg("Setting network setup")
+
+	_, err := c.request(ctx, BuildSetNetworkSetupRequest(setup), GW_SET_NETWORK_SETUP_CFM)
+	return err
+}
+
+// Reboot restarts the KLF-200. The connection is closed afterwards.
+func (c *Client) Reboot(ctx context.Context) error {
+	c.logger.Info().Msg("Rebooting KLF-200")
+
+	if _, err := c.request(ctx, EncodeFrame(GW_REBOOT_REQ, nil), GW_REBOOT_CFM); err != nil {
+		return err
+	}
+	return c.Disconnect()
+}
+
+// request sends a request frame and waits for its confirmation
+func (c *Client) request(ctx context.Context, frame []byte, confirm CommandID) (*Frame, error) {
+	if !c.authenticated.Load() {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	if err := c.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer c.end()
+
+	return c.roundTrip(ctx, frame, confirm)
+}
+
+// roundTrip sends a request frame and waits for its confirmation; the caller holds the exchange
+func (c *Client) roundTrip(ctx context.Context, frame []byte, confirm CommandID) (*Frame, error) {
+	if err := c.sendRaw(frame); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	resp, err := c.waitForResponse(ctx, confirm, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get confirmation: %w", err)
+	}
+	return resp, nil
+}
+
+// GetSystemTable reads the actuators known to the KLF-200
+func (c *Client) GetSystemTable(ctx context.Context) ([]SystemTableEntry, error) {
+	if !c.authenticated.Load() {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	if err := c.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer c.end()
+
+	if err := c.sendRaw(BuildGetSystemTableRequest()); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if _, err := c.waitForResponse(ctx, GW_CS_GET_SYSTEMTABLE_DATA_CFM, 5*time.Second); err != nil {
+		return nil, fmt.Errorf("failed to get confirmation: %w", err)
+	}
+
+	entries := make([]SystemTableEntry, 0)
+	for {
+		resp, err := c.waitForResponse(ctx, GW_CS_GET_SYSTEMTABLE_DATA_NTF, 10*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get system table: %w", err)
+		}
+
+		part, remaining, err := ParseSystemTableNotification(resp.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse system table: %w", err)
+		}
+		entries = append(entries, part...)
+		if remaining == 0 {
+			return entries, nil
+		}
+	}
+}
+
+// DiscoverNodes starts searching for new io-homecontrol products. nodeType limits the
+// search to one actuator type (0 = all). The search takes up to several minutes,
+// its result is passed to the discovery callback.
+func (c *Client) DiscoverNodes(ctx context.Context, nodeType uint8) error {
+	if !c.authenticated.Load() {
+		return fmt.Errorf("not authenticated")
+	}
+
+	c.logger.Info().Uint8("nodeType", nodeType).Msg("Starting node discovery")
+
+	if err := c.begin(ctx); err != nil {
+		return err
+	}
+	defer c.end()
+
+	if err := c.sendRaw(BuildDiscoverNodesRequest(nodeType)); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if _, err := c.waitForResponse(ctx, GW_CS_DISCOVER_NODES_CFM, 5*time.Second); err != nil {
+		return fmt.Errorf("failed to get confirmation: %w", err)
+	}
+	return nil
+}
+
+// RemoveNodes removes products from the system table of the KLF-200
+func (c *Client) RemoveNodes(ctx context.Context, nodeIDs []uint8) error {
+	if !c.authenticated.Load() {
+		return fmt.Errorf("not authenticated")
+	}
+
+	c.logger.Info().Interface("nodes", nodeIDs).Msg("Removing nodes")
+
+	if err := c.begin(ctx); err != nil {
+		return err
+	}
+	defer c.end()
+
+	if err := c.sendRaw(BuildRemoveNodesRequest(nodeIDs)); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	resp, err := c.waitForResponse(ctx, GW_CS_REMOVE_NODES_CFM, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to get confirmation: %w", err)
+	}
+	if len(resp.Data) < 1 {
+		return ErrFrameTooShort
+	}
+	if resp.Data[0] != 1 {
+		return fmt.Errorf("nodes not removed by KLF-200")
+	}
+	return nil
+}
+
+// ControllerCopy starts copying the system key and actuators from or to another controller.
+// The result is passed to the controller copy callback.
+func (c *Client) ControllerCopy(ctx context.Context, mode ControllerCopyMode) error {
+	if !c.authenticated.Load() {
+		return fmt.Errorf("not authenticated")
+	}
+
+	c.logger.Info().Uint8("mode", uint8(mode)).Msg("Starting controller copy")
+
+	if err := c.begin(ctx); err != nil {
+		return err
+	}
+	defer c.end()
+
+	if err := c.sendRaw(BuildControllerCopyRequest(mode)); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if _, err := c.waitForResponse(ctx, GW_CS_CONTROLLER_COPY_CFM, 5*time.Second); err != nil {
+		return fmt.Errorf("failed to get confirmation: %w", err)
+	}
+	return nil
+}
+
+// reportLimitation passes a limitation status to the limitation callback
+func (c *Client) reportLimitation(status *LimitationStatus) {
+	if c.onLimitationUpdate != nil {
+		c.onLimitationUpdate(status)
+	}
+}
+
+// sendRaw sends raw bytes to the KLF-200
+func (c *Client) sendRaw(data []byte) error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	_, err := c.conn.Write(data)
+	return err
+}
+
+// waitForSession waits for the GW_SESSION_FINISHED_NTF of a session; the caller holds the exchange
+func (c *Client) waitForSession(ctx context.Context, sessionID uint16, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		resp, err := c.waitForResponse(ctx, GW_SESSION_FINISHED_NTF, timeout)
+		if err != nil {
+			return err
+		}
+		if finished, err := ParseSessionFinishedNotification(resp.Data); err == nil && finished == sessionID {
+			return nil
+		}
+	}
+}
+
+// begin starts a request/confirmation exchange. Confirmations do not reference their request
+// and all of them arrive on the response channel, so only one exchange is in flight at a time.
+// Frames left over from an earlier exchange (e.g. after a timeout) are discarded.
+func (c *Client) begin(ctx context.Context) error {
+	select {
+	case c.exchange <- struct{}{}:
+	case <-ctx.Done():
+		return fmt.Errorf("KLF-200 busy: %w", ctx.Err())
+	}
+
+	for {
+		select {
+		case frame := <-c.responseChan:
+			c.logger.Debug().Uint16("cmd", uint16(frame.Command)).Msg("Discarding stale frame")
+		default:
+			return nil
+		}
+	}
+}
+
+// end finishes the exchange started with begin
+func (c *Client) end() {
+	<-c.exchange
+}
+
+// waitForResponse waits for a specific response
+func (c *Client) waitForResponse(ctx context.Context, cmd CommandID, timeout time.Duration) (*Frame, error) {
+	return c.waitForResponses(ctx, timeout, cmd)
+}
+
+// waitForResponses waits for one of the given responses. Other frames are handled as
+// notifications, a GW_ERROR_NTF answers the pending request.
+func (c *Client) waitForResponses(ctx context.Context, timeout time.Duration, cmds ...CommandID) (*Frame, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case frame := <-c.responseChan:
+			for _, cmd := range cmds {
+				if frame.Command == cmd {
+					return frame, nil
+				}
+			}
+			if frame.Command == GW_ERROR_NTF {
+				return nil, errorNotification(frame)
+			}
+			c.handleAsyncFrame(frame)
+		}
+	}
+}
+
+// errorNotification converts a GW_ERROR_NTF into an error
+func errorNotification(frame *Frame) error {
+	errorCode := uint8(0)
+	if len(frame.Data) > 0 {
+		errorCode = frame.Data[0]
+	}
+	return fmt.Errorf("KLF-200 error: code %d", errorCode)
+}
+
+// handleAsyncFrame handles frames that were not expected
+func (c *Client) handleAsyncFrame(frame *Frame) {
+	switch frame.Command {
+	case GW_NODE_STATE_POSITION_CHANGED_NTF:
+		nodeID, state, position, target, err := ParseNodeStatePositionChangedFull(frame.Data)
+		if err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to parse position change")
+			return
+		}
+		c.logger.Debug().
+			Uint8("nodeID", nodeID).
 			Uint8("state", uint8(state)).
 			Uint16("position", position).
 			Uint16("target", target).
@@ -576,46 +1457,53 @@ func (c *Client) handleAsyncFrame(frame *Frame) {
 			Uint8("statusReply", uint8(statusReply)).
 			Msg("Command run status notification")
 
-		// Check for sensor-related limitations
-		c.sensorStatusMu.Lock()
-		oldRain := c.sensorStatus.RainDetected
-		oldWind := c.sensorStatus.WindDetected
-		c.sensorStatus.LastUpdate = time.Now()
-		switch statusReply {
-		case StatusReplyLimitationByRain:
-			c.sensorStatus.RainDetected = true
-			c.logger.Info().Msg("Rain sensor triggered - rain detected")
-		case StatusReplyLimitationByWind:
-			c.sensorStatus.WindDetected = true
-			c.logger.Info().Msg("Wind sensor triggered - wind detected")
-		}
-		sensorChanged := oldRain != c.sensorStatus.RainDetected || oldWind != c.sensorStatus.WindDetected
-		sensorCopy := c.sensorStatus
-		c.sensorStatusMu.Unlock()
-
-		if sensorChanged && c.onSensorUpdate != nil {
-			c.onSensorUpdate(sensorCopy)
-		}
-
-		// Update state based on run status
-		var state NodeState
-		switch runStatus {
-		case RunStatusExecutionCompleted:
-			state = NodeStateDone
-		case RunStatusExecutionFailed:
-			state = NodeStateErrorWhileExecution
-		case RunStatusExecutionActive:
-			state = NodeStateExecuting
+		// A limitation reply tells which sensor (rain, wind, ...) blocked the command
+		if origin := StatusReplyToLimitationType(statusReply); origin != LimitationTypeUnknown {
+			c.logger.Info().
+				Uint8("nodeID", nodeID).
+				Str("origin", origin.String()).
+				Msg("Command limited")
+			c.reportLimitation(&LimitationStatus{
+				NodeID:           nodeID,
+				MinValue:         PositionIgnore,
+				MaxValue:         PositionIgnore,
+				LimitationOrigin: origin,
+				LimitationTime:   LimitationTimeKeep,
+			})
 		}
+
+		if c.onRunStatus != nil {
+			c.onRunStatus(sessionID, nodeID, runStatus, statusReply)
+		}
+
+		// Update state based on run status, the notification carries no position
+		state := runStatus.NodeState()
 		if c.onNodeUpdate != nil {
 			c.onNodeUpdate(&Node{
-				ID:         nodeID,
-				State:      state,
-				StateStr:   state.String(),
-				LastUpdate: time.Now(),
+				ID:              nodeID,
+				State:           state,
+				StateStr:        state.String(),
+				CurrentPosition: PositionIgnore,
+				TargetPosition:  PositionIgnore,
+				LastUpdate:      time.Now(),
 			})
 		}
 
+	case GW_STATUS_REQUEST_NTF:
+		status, err := ParseStatusRequestNotification(frame.Data)
+		if err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to parse status notification")
+			return
+		}
+		c.logger.Debug().
+			Uint8("nodeID", status.NodeID).
+			Uint8("statusType", uint8(status.StatusType)).
+			Uint8("runStatus", uint8(status.RunStatus)).
+			Msg("Node status notification")
+		if c.onStatusUpdate != nil {
+			c.onStatusUpdate(status)
+		}
+
 	case GW_LIMITATION_STATUS_NTF:
 		status, err := ParseLimitationStatusNotification(frame.Data)
 		if err != nil {
@@ -625,15 +1513,141 @@ func (c *Client) handleAsyncFrame(frame *Frame) {
 		c.logger.Debug().
 			Uint8("nodeID", status.NodeID).
 			Str("origin", status.LimitationOrigin.String()).
+			Uint16("minValue", status.MinValue).
+			Uint16("maxValue", status.MaxValue).
+			Uint8("time", status.LimitationTime).
 			Msg("Limitation status notification")
-		c.updateSensorStatus(status)
+		c.reportLimitation(status)
+
+	case GW_WINK_SEND_NTF:
+		sessionID, err := ParseWinkSendNotification(frame.Data)
+		if err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to parse wink notification")
+			return
+		}
+		c.logger.Debug().Uint16("sessionID", sessionID).Msg("Wink finished notification")
+		if c.onWinkDone != nil {
+			c.onWinkDone(sessionID)
+		}
+
+	case GW_CS_DISCOVER_NODES_NTF:
+		result, err := ParseDiscoverNodesNotification(frame.Data)
+		if err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to parse discovery result")
+			return
+		}
+		c.logger.Info().
+			Interface("added", result.Added).
+			Str("status", result.StatusStr).
+			Msg("Node discovery finished")
+		if c.onDiscovery != nil {
+			c.onDiscovery(result)
+		}
+
+	case GW_CS_CONTROLLER_COPY_NTF:
+		result, err := ParseControllerCopyNotification(frame.Data)
+		if err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to parse controller copy result")
+			return
+		}
+		c.logger.Info().Uint8("status", result.Status).Msg("Controller copy finished")
+		if c.onControllerCopy != nil {
+			c.onControllerCopy(result)
+		}
+
+	case GW_CS_CONTROLLER_COPY_CANCEL_NTF:
+		c.logger.Info().Msg("Controller copy cancelled")
+		if c.onControllerCopy != nil {
+			c.onControllerCopy(&ControllerCopyResult{Status: 2})
+		}
+
+	case GW_CS_SYSTEM_TABLE_UPDATE_NTF:
+		update, err := ParseSystemTableUpdateNotification(frame.Data)
+		if err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to parse system table update")
+			return
+		}
+		c.logger.Info().
+			Interface("added", update.Added).
+			Interface("removed", update.Removed).
+			Msg("System table updated")
+		if c.onSystemTable != nil {
+			c.onSystemTable(update)
+		}
+
+	case GW_INITIALIZE_SCENE_NTF:
+		result, err := ParseInitializeSceneNotification(frame.Data)
+		if err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to parse scene initialization")
+			return
+		}
+		c.logger.Info().
+			Uint8("status", result.Status).
+			Interface("failed", result.FailedNodes).
+			Msg("Scene initialized")
+		if c.onSceneInit != nil {
+			c.onSceneInit(result)
+		}
+
+	case GW_RECORD_SCENE_NTF:
+		result, err := ParseRecordSceneNotification(frame.Data)
+		if err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to parse scene recording")
+			return
+		}
+		c.logger.Info().Bool("ok", result.OK).Uint8("sceneID", result.SceneID).Msg("Scene recorded")
+		if c.onSceneRecorded != nil {
+			c.onSceneRecorded(result)
+		}
+
+	case GW_SCENE_INFORMATION_CHANGED_NTF:
+		change, err := ParseSceneInformationChangedNotification(frame.Data)
+		if err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to parse scene change")
+			return
+		}
+		c.logger.Debug().Uint8("sceneID", change.SceneID).Bool("deleted", change.Deleted).Msg("Scene changed")
+		if c.onSceneChanged != nil {
+			c.onSceneChanged(change)
+		}
+
+	case GW_PASSWORD_CHANGE_NTF:
+		password, err := ParsePasswordChangeNotification(frame.Data)
+		if err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to parse password change")
+			return
+		}
+		c.connMu.Lock()
+		own := password == c.password || password == c.newPassword
+		if !own {
+			// Another client changed the password; keep using the new one for reconnects
+			c.password = password
+		}
+		c.connMu.Unlock()
+		if own {
+			c.logger.Debug().Msg("Password change notification for our own change")
+			return
+		}
+		c.logger.Warn().Msg("KLF-200 password was changed by another client")
+		if c.onPasswordChange != nil {
+			c.onPasswordChange(password)
+		}
+
+	case GW_ACTIVATION_LOG_UPDATED_NTF:
+		c.logger.Debug().Msg("Activation log updated notification")
+		if c.onActivationLog != nil {
+			c.onActivationLog()
+		}
 	}
 }
 
 // isAsyncNotification returns true if the frame is an async notification that should be handled immediately
 func (c *Client) isAsyncNotification(cmd CommandID) bool {
 	switch cmd {
-	case GW_NODE_STATE_POSITION_CHANGED_NTF, GW_COMMAND_RUN_STATUS_NTF, GW_LIMITATION_STATUS_NTF:
+	case GW_NODE_STATE_POSITION_CHANGED_NTF, GW_COMMAND_RUN_STATUS_NTF, GW_LIMITATION_STATUS_NTF,
+		GW_STATUS_REQUEST_NTF, GW_WINK_SEND_NTF, GW_CS_DISCOVER_NODES_NTF, GW_CS_CONTROLLER_COPY_NTF,
+		GW_CS_CONTROLLER_COPY_CANCEL_NTF, GW_CS_SYSTEM_TABLE_UPDATE_NTF, GW_ACTIVATION_LOG_UPDATED_NTF,
+		GW_PASSWORD_CHANGE_NTF, GW_INITIALIZE_SCENE_NTF, GW_RECORD_SCENE_NTF, GW_SCENE_INFORMATION_CHANGED_NTF:
 		return true
 	default:
 		return false
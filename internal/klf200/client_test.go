@@ -0,0 +1,41 @@
+package klf200
+
+import (
+	"testing"
+)
+
+func TestPasswordChangeNotification(t *testing.T) {
+	tests := []struct {
+		name         string
+		pending      string // Password of a running ChangePassword
+		notified     string
+		wantPassword string
+		wantCallback bool
+	}{
+		{name: "own change confirmed", notified: "current", wantPassword: "current"},
+		{name: "own change pending", pending: "changed", notified: "changed", wantPassword: "current"},
+		{name: "other client", notified: "other", wantPassword: "other", wantCallback: true},
+		{name: "other client while changing", pending: "changed", notified: "other", wantPassword: "other", wantCallback: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(ClientConfig{Password: "current"})
+			c.newPassword = tt.pending
+
+			var called string
+			c.SetPasswordChangeCallback(func(password string) { called = password })
+
+			data := make([]byte, MaxPasswordLength)
+			copy(data, tt.notified)
+			c.handleAsyncFrame(&Frame{Command: GW_PASSWORD_CHANGE_NTF, Data: data})
+
+			if c.password != tt.wantPassword {
+				t.Errorf("password = %q, want %q", c.password, tt.wantPassword)
+			}
+			if (called != "") != tt.wantCallback || (tt.wantCallback && called != tt.notified) {
+				t.Errorf("callback got %q, want callback %v", called, tt.wantCallback)
+			}
+		})
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net"
+	"time"
 )
 
 // SLIP protocol constants
@@ -25,6 +28,8 @@ var (
 	ErrInvalidFrame    = errors.New("invalid frame")
 	ErrChecksumMismatch = errors.New("checksum mismatch")
 	ErrFrameTooShort   = errors.New("frame too short")
+	ErrNodeNotFound    = errors.New("node not found")
+	ErrSceneNotFound   = errors.New("scene not found")
 )
 
 // EncodeFrame creates a SLIP-encoded frame from command and data
@@ -169,12 +174,28 @@ func DecodeFrame(raw []byte) (*Frame, error) {
 // BuildPasswordEnterRequest creates a password authentication request
 // If the password starts with "base64:", the remainder will be decoded
 func BuildPasswordEnterRequest(password string) []byte {
+	return EncodeFrame(GW_PASSWORD_ENTER_REQ, encodePassword(password))
+}
+
+// BuildPasswordChangeRequest creates a request to change the password
+// Frame structure:
+// - CurrentPassword: 32 bytes @ 0
+// - NewPassword: 32 bytes @ 32
+func BuildPasswordChangeRequest(current, newPassword string) []byte {
+	data := make([]byte, 64)
+	copy(data[0:32], encodePassword(current))
+	copy(data[32:64], encodePassword(newPassword))
+	return EncodeFrame(GW_PASSWORD_CHANGE_REQ, data)
+}
+
+// encodePassword encodes a password as 32 bytes, padded with zeros
+func encodePassword(password string) []byte {
 	// Password is max 32 bytes, padded with zeros
-	data := make([]byte, 32)
+	data := make([]byte, MaxPasswordLength)
 
 	// Only decode as Base64 if explicitly prefixed with "base64:"
 	if len(password) > 7 && password[:7] == "base64:" {
-		if decoded, err := base64.StdEncoding.DecodeString(password[7:]); err == nil && len(decoded) <= 32 {
+		if decoded, err := base64.StdEncoding.DecodeString(password[7:]); err == nil && len(decoded) <= MaxPasswordLength {
 			copy(data, decoded)
 		} else {
 			copy(data, []byte(password))
@@ -183,7 +204,15 @@ func BuildPasswordEnterRequest(password string) []byte {
 		copy(data, []byte(password))
 	}
 
-	return EncodeFrame(GW_PASSWORD_ENTER_REQ, data)
+	return data
+}
+
+// ParsePasswordChangeNotification parses GW_PASSWORD_CHANGE_NTF (new password, 32 bytes, zero padded)
+func ParsePasswordChangeNotification(data []byte) (string, error) {
+	if len(data) < MaxPasswordLength {
+		return "", ErrFrameTooShort
+	}
+	return string(bytes.TrimRight(data[:MaxPasswordLength], "\x00")), nil
 }
 
 // BuildGetAllNodesRequest creates a request to get all node information
@@ -207,8 +236,9 @@ func BuildGetAllNodesRequest() []byte {
 // - PriorityLevelInfo: 2 bytes (index 63-64)
 // - LockTime: 1 byte (index 65)
 // Total: 66 bytes
+// lock is nil to leave the priority level locks of the nodes unchanged.
 func BuildCommandSendRequest(sessionID uint16, commandOriginator uint8, priorityLevel Priority,
-	nodeIDs []uint8, mainParameter uint16, functionalParameters []uint16) []byte {
+	nodeIDs []uint8, mainParameter uint16, functionalParameters []uint16, lock *PriorityLevelLock) []byte {
 
 	buf := new(bytes.Buffer)
 
@@ -224,11 +254,15 @@ func BuildCommandSendRequest(sessionID uint16, commandOriginator uint8, priority
 	// Parameter active flags (1 byte) - bit 0 = main parameter
 	buf.WriteByte(0x01)
 
-	// FPI1 - Functional parameter indicator 1 (1 byte)
-	buf.WriteByte(0x00)
-
-	// FPI2 - Functional parameter indicator 2 (1 byte)
-	buf.WriteByte(0x00)
+	// FPI1/FPI2 - Functional parameter indicators (bit 7 of FPI1 = FP1 ... bit 0 of FPI2 = FP16),
+	// set for every functional parameter that is not ignored
+	var fpi uint16
+	for i, fp := range functionalParameters {
+		if i < 16 && fp != PositionIgnore {
+			fpi |= 0x8000 >> i
+		}
+	}
+	binary.Write(buf, binary.BigEndian, fpi)
 
 	// Main parameter (2 bytes) - position value
 	binary.Write(buf, binary.BigEndian, mainParameter)
@@ -254,14 +288,15 @@ func BuildCommandSendRequest(sessionID uint16, commandOriginator uint8, priority
 		buf.WriteByte(0)
 	}
 
-	// Priority level lock (1 byte) - 0 = no lock
-	buf.WriteByte(0x00)
-
-	// Priority level info (2 bytes)
-	buf.Write([]byte{0x00, 0x00})
-
-	// Lock time (1 byte) - 0 = no lock
-	buf.WriteByte(0x00)
+	// Priority level lock (1 byte) - 0 = leave the locks unchanged, 1 = apply the priority level info
+	// Priority level info (2 bytes) and lock time (1 byte)
+	if lock == nil {
+		buf.Write([]byte{0x00, 0x00, 0x00, 0x00})
+	} else {
+		buf.WriteByte(0x01)
+		binary.Write(buf, binary.BigEndian, lock.Info())
+		buf.WriteByte(lock.LockTime)
+	}
 
 	return EncodeFrame(GW_COMMAND_SEND_REQ, buf.Bytes())
 }
@@ -272,7 +307,14 @@ func BuildHouseStatusMonitorEnableRequest() []byte {
 }
 
 // BuildStatusRequest creates a status request for specific nodes
-func BuildStatusRequest(sessionID uint16, nodeIDs []uint8) []byte {
+// Frame structure:
+// - SessionID: 2 bytes
+// - IndexArrayCount: 1 byte
+// - IndexArray: 20 bytes (node IDs, padded with 0)
+// - StatusType: 1 byte
+// - FPI1: 1 byte (functional parameters 1-8 requested)
+// - FPI2: 1 byte (functional parameters 9-16 requested)
+func BuildStatusRequest(sessionID uint16, nodeIDs []uint8, statusType StatusType, fpi1, fpi2 uint8) []byte {
 	buf := new(bytes.Buffer)
 
 	// Session ID
@@ -289,16 +331,439 @@ func BuildStatusRequest(sessionID uint16, nodeIDs []uint8) []byte {
 		buf.WriteByte(0)
 	}
 
-	// Status type (0 = request target position)
-	buf.WriteByte(0x00)
+	// Status type
+	buf.WriteByte(byte(statusType))
 
-	// Functional parameter (0 = main info)
-	buf.WriteByte(0x00)
+	// Functional parameter indicators
+	buf.WriteByte(fpi1)
+	buf.WriteByte(fpi2)
 
 	return EncodeFrame(GW_STATUS_REQUEST_REQ, buf.Bytes())
 }
 
+// ParseStatusRequestConfirm parses the confirmation of a status request
+// Frame structure:
+// - SessionID: 2 bytes @ 0
+// - Status: 1 byte @ 2 (0 = rejected, 1 = accepted)
+func ParseStatusRequestConfirm(data []byte) (sessionID uint16, accepted bool, err error) {
+	if len(data) < 3 {
+		return 0, false, ErrFrameTooShort
+	}
+
+	sessionID = binary.BigEndian.Uint16(data[0:2])
+	accepted = data[2] == 1
+
+	return sessionID, accepted, nil
+}
+
+// ParseStatusRequestNotification parses GW_STATUS_REQUEST_NTF
+// Frame structure:
+// - SessionID: 2 bytes @ 0
+// - StatusID: 1 byte @ 2
+// - NodeID: 1 byte @ 3
+// - RunStatus: 1 byte @ 4
+// - StatusReply: 1 byte @ 5
+// - StatusType: 1 byte @ 6
+// Status type 0-2:
+// - StatusCount: 1 byte @ 7
+// - ParameterData: StatusCount x (ParameterID 1 byte, Value 2 bytes) @ 8
+// Status type 3 (main info):
+// - TargetPosition: 2 bytes @ 7
+// - CurrentPosition: 2 bytes @ 9
+// - RemainingTime: 2 bytes @ 11
+// - LastMasterExecutionAddress: 4 bytes @ 13
+// - LastCommandOriginator: 1 byte @ 17
+func ParseStatusRequestNotification(data []byte) (*NodeStatus, error) {
+	if len(data) < 8 {
+		return nil, ErrFrameTooShort
+	}
+
+	status := &NodeStatus{
+		SessionID:   binary.BigEndian.Uint16(data[0:2]),
+		NodeID:      data[3],
+		RunStatus:   RunStatus(data[4]),
+		StatusReply: StatusReply(data[5]),
+		StatusType:  StatusType(data[6]),
+	}
+
+	if status.StatusType == StatusTypeMainInfo {
+		if len(data) < 18 {
+			return nil, ErrFrameTooShort
+		}
+		status.TargetPosition = binary.BigEndian.Uint16(data[7:9])
+		status.CurrentPosition = binary.BigEndian.Uint16(data[9:11])
+		status.RemainingTime = binary.BigEndian.Uint16(data[11:13])
+		status.LastMasterExecutionAddress = binary.BigEndian.Uint32(data[13:17])
+		status.LastCommandOriginator = Originator(data[17])
+		return status, nil
+	}
+
+	count := int(data[7])
+	if len(data) < 8+count*3 {
+		return nil, ErrFrameTooShort
+	}
+	status.Parameters = make(map[uint8]uint16, count)
+	for i := 0; i < count; i++ {
+		offset := 8 + i*3
+		status.Parameters[data[offset]] = binary.BigEndian.Uint16(data[offset+1 : offset+3])
+	}
+
+	return status, nil
+}
+
+// BuildGetNodeInformationRequest creates a request for the information of a single node
+func BuildGetNodeInformationRequest(nodeID uint8) []byte {
+	return EncodeFrame(GW_GET_NODE_INFORMATION_REQ, []byte{nodeID})
+}
+
+// ParseGetNodeInformationConfirm parses the confirmation of a node information request
+// Frame structure:
+// - Status: 1 byte @ 0 (0 = OK, 1 = rejected, 2 = invalid node index)
+// - NodeID: 1 byte @ 1
+func ParseGetNodeInformationConfirm(data []byte) (status ResponseStatus, nodeID uint8, err error) {
+	if len(data) < 2 {
+		return 0, 0, ErrFrameTooShort
+	}
+	return ResponseStatus(data[0]), data[1], nil
+}
+
+// ParseGetVersionConfirm parses GW_GET_VERSION_CFM
+// Frame structure:
+// - SoftwareVersion: 6 bytes @ 0
+// - HardwareVersion: 1 byte @ 6
+// - ProductGroup: 1 byte @ 7
+// - ProductType: 1 byte @ 8
+func ParseGetVersionConfirm(data []byte) (*GatewayVersion, error) {
+	if len(data) < 9 {
+		return nil, ErrFrameTooShort
+	}
+	return &GatewayVersion{
+		Software:     fmt.Sprintf("%d.%d.%d.%d.%d.%d", data[0], data[1], data[2], data[3], data[4], data[5]),
+		Hardware:     data[6],
+		ProductGroup: data[7],
+		ProductType:  data[8],
+	}, nil
+}
+
+// ParseGetProtocolVersionConfirm parses GW_GET_PROTOCOL_VERSION_CFM
+// Frame structure:
+// - MajorVersion: 2 bytes @ 0
+// - MinorVersion: 2 bytes @ 2
+func ParseGetProtocolVersionConfirm(data []byte) (*ProtocolVersion, error) {
+	if len(data) < 4 {
+		return nil, ErrFrameTooShort
+	}
+	return &ProtocolVersion{
+		Major: binary.BigEndian.Uint16(data[0:2]),
+		Minor: binary.BigEndian.Uint16(data[2:4]),
+	}, nil
+}
+
+// ParseGetStateConfirm parses GW_GET_STATE_CFM
+// Frame structure:
+// - GatewayState: 1 byte @ 0
+// - SubState: 1 byte @ 1
+// - StateData: 4 bytes @ 2 (reserved)
+func ParseGetStateConfirm(data []byte) (*GatewayState, error) {
+	if len(data) < 2 {
+		return nil, ErrFrameTooShort
+	}
+	return &GatewayState{
+		State:       data[0],
+		StateStr:    gatewayStateName(data[0]),
+		SubState:    data[1],
+		SubStateStr: gatewaySubStateName(data[1]),
+	}, nil
+}
+
+// ParseActivationLogHeaderConfirm parses GW_GET_ACTIVATION_LOG_HEADER_CFM
+// Frame structure:
+// - MaxLineCount: 2 bytes @ 0
+// - LineCount: 2 bytes @ 2
+func ParseActivationLogHeaderConfirm(data []byte) (maxLines, lines uint16, err error) {
+	if len(data) < 4 {
+		return 0, 0, ErrFrameTooShort
+	}
+	return binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4]), nil
+}
+
+// BuildGetActivationLogLineRequest builds a GW_GET_ACTIVATION_LOG_LINE_REQ frame
+func BuildGetActivationLogLineRequest(line uint16) []byte {
+	data := make([]byte, 2)
+	binary.BigEndian.PutUint16(data, line)
+	return EncodeFrame(GW_GET_ACTIVATION_LOG_LINE_REQ, data)
+}
+
+// BuildGetMultipleActivationLogLinesRequest builds a GW_GET_MULTIPLE_ACTIVATION_LOG_LINES_REQ frame.
+// The KLF-200 answers with all lines newer than since.
+func BuildGetMultipleActivationLogLinesRequest(since time.Time) []byte {
+	data := make([]byte, 4)
+	if since.After(time.Unix(0, 0)) {
+		binary.BigEndian.PutUint32(data, uint32(since.Unix()))
+	}
+	return EncodeFrame(GW_GET_MULTIPLE_ACTIVATION_LOG_LINES_REQ, data)
+}
+
+// ParseActivationLogLine parses an activation log line
+// (GW_GET_ACTIVATION_LOG_LINE_CFM and GW_GET_MULTIPLE_ACTIVATION_LOG_LINES_NTF)
+// Frame structure:
+// - TimeStamp: 4 bytes @ 0 (UNIX time)
+// - SessionID: 2 bytes @ 4
+// - StatusOwner: 1 byte @ 6
+// - CommandOriginator: 1 byte @ 7
+// - NodeID: 1 byte @ 8
+// - NodeParameter: 1 byte @ 9
+// - RunStatus: 1 byte @ 10
+// - StatusReply: 1 byte @ 11
+// - InformationCode: 4 bytes @ 12
+func ParseActivationLogLine(data []byte) (*ActivationLogLine, error) {
+	if len(data) < 16 {
+		return nil, ErrFrameTooShort
+	}
+
+	line := &ActivationLogLine{
+		Time:            time.Unix(int64(binary.BigEndian.Uint32(data[0:4])), 0),
+		SessionID:       binary.BigEndian.Uint16(data[4:6]),
+		StatusOwner:     Originator(data[6]),
+		Originator:      Originator(data[7]),
+		NodeID:          data[8],
+		NodeParameter:   data[9],
+		RunStatus:       RunStatus(data[10]),
+		StatusReply:     StatusReply(data[11]),
+		InformationCode: binary.BigEndian.Uint32(data[12:16]),
+	}
+	line.OriginatorStr = line.Originator.String()
+	return line, nil
+}
+
+// ParseNetworkSetupConfirm parses GW_GET_NETWORK_SETUP_CFM
+// Frame structure:
+// - IPAddress: 4 bytes @ 0
+// - Mask: 4 bytes @ 4
+// - DefGW: 4 bytes @ 8
+// - DHCP: 1 byte @ 12 (0 = static, 1 = DHCP)
+func ParseNetworkSetupConfirm(data []byte) (*NetworkSetup, error) {
+	if len(data) < 13 {
+		return nil, ErrFrameTooShort
+	}
+	return &NetworkSetup{
+		IP:      net.IPv4(data[0], data[1], data[2], data[3]),
+		Mask:    net.IPv4(data[4], data[5], data[6], data[7]),
+		Gateway: net.IPv4(data[8], data[9], data[10], data[11]),
+		DHCP:    data[12] != 0,
+	}, nil
+}
+
+// BuildSetNetworkSetupRequest builds a GW_SET_NETWORK_SETUP_REQ frame (same layout as the confirmation above).
+// The addresses are ignored by the KLF-200 if DHCP is enabled.
+func BuildSetNetworkSetupRequest(setup NetworkSetup) []byte {
+	data := make([]byte, 13)
+	copy(data[0:4], setup.IP.To4())
+	copy(data[4:8], setup.Mask.To4())
+	copy(data[8:12], setup.Gateway.To4())
+	if setup.DHCP {
+		data[12] = 1
+	}
+	return EncodeFrame(GW_SET_NETWORK_SETUP_REQ, data)
+}
+
+// BuildSetUTCRequest builds a GW_SET_UTC_REQ frame (UNIX timestamp, 4 bytes)
+func BuildSetUTCRequest(t time.Time) []byte {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, uint32(t.Unix()))
+	return EncodeFrame(GW_SET_UTC_REQ, data)
+}
+
+// BuildSetTimeZoneRequest builds a GW_RTC_SET_TIME_ZONE_REQ frame
+// Frame structure:
+// - TimeZoneString: 64 bytes @ 0 (null-terminated, e.g. ":GMT+1:GMT+2:0060:(1996)040102-0:110102-0")
+func BuildSetTimeZoneRequest(timeZone string) []byte {
+	data := make([]byte, 64)
+	copy(data[:MaxTimeZoneLength], timeZone)
+	return EncodeFrame(GW_RTC_SET_TIME_ZONE_REQ, data)
+}
+
+// BuildGetSystemTableRequest builds a GW_CS_GET_SYSTEMTABLE_DATA_REQ frame
+func BuildGetSystemTableRequest() []byte {
+	return EncodeFrame(GW_CS_GET_SYSTEMTABLE_DATA_REQ, nil)
+}
+
+// ParseSystemTableNotification parses GW_CS_GET_SYSTEMTABLE_DATA_NTF
+// Frame structure:
+// - NumberOfEntry: 1 byte @ 0
+// - Entries: 11 bytes each
+//   - SystemTableIndex: 1 byte
+//   - ActuatorAddress: 3 bytes
+//   - ActuatorType: 2 bytes (10 bits type, 6 bits subtype)
+//   - Flags: 1 byte (bit 0-1 power mode, bit 2 io-membership, bit 3 RF support, bit 6-7 turnaround time)
+//   - ioManufacturerID: 1 byte
+//   - BackboneReferenceNumber: 3 bytes
+// - RemainingNumberOfEntry: 1 byte
+func ParseSystemTableNotification(data []byte) (entries []SystemTableEntry, remaining uint8, err error) {
+	if len(data) < 2 {
+		return nil, 0, ErrFrameTooShort
+	}
+
+	count := int(data[0])
+	if len(data) < 2+11*count {
+		return nil, 0, ErrFrameTooShort
+	}
+
+	for i := 0; i < count; i++ {
+		e := data[1+11*i : 12+11*i]
+		entry := SystemTableEntry{
+			Index:          e[0],
+			Address:        uint32(e[1])<<16 | uint32(e[2])<<8 | uint32(e[3]),
+			NodeType:       NodeType(binary.BigEndian.Uint16(e[4:6])),
+			PowerMode:      PowerMode(e[6] & 0x03),
+			IOMembership:   e[6]&0x04 != 0,
+			RFSupport:      e[6]&0x08 != 0,
+			TurnaroundTime: e[6] >> 6,
+			Manufacturer:   e[7],
+			BackboneRef:    uint32(e[8])<<16 | uint32(e[9])<<8 | uint32(e[10]),
+		}
+		entry.NodeTypeStr = entry.NodeType.String()
+		entries = append(entries, entry)
+	}
+
+	return entries, data[1+11*count], nil
+}
+
+// BuildDiscoverNodesRequest builds a GW_CS_DISCOVER_NODES_REQ frame.
+// nodeType limits the discovery to one actuator type, 0 discovers all types.
+func BuildDiscoverNodesRequest(nodeType uint8) []byte {
+	return EncodeFrame(GW_CS_DISCOVER_NODES_REQ, []byte{nodeType})
+}
+
+// ParseDiscoverNodesNotification parses GW_CS_DISCOVER_NODES_NTF
+// Frame structure:
+// - AddedNode: 26 bytes @ 0 (bit array)
+// - RfConnectionErrorNode: 26 bytes @ 26
+// - ioKeyErrorExistingNode: 26 bytes @ 52
+// - RemovedNode: 26 bytes @ 78
+// - OpenNode: 26 bytes @ 104
+// - DiscoverStatus: 1 byte @ 130
+func ParseDiscoverNodesNotification(data []byte) (*DiscoveryResult, error) {
+	if len(data) < 5*NodeBitArraySize+1 {
+		return nil, ErrFrameTooShort
+	}
+
+	array := func(i int) []uint8 {
+		return ParseNodeBitArray(data[i*NodeBitArraySize : (i+1)*NodeBitArraySize])
+	}
+
+	result := &DiscoveryResult{
+		Added:      array(0),
+		RFError:    array(1),
+		IOKeyError: array(2),
+		Removed:    array(3),
+		Open:       array(4),
+		Status:     DiscoverStatus(data[5*NodeBitArraySize]),
+	}
+	result.StatusStr = result.Status.String()
+	return result, nil
+}
+
+// BuildRemoveNodesRequest builds a GW_CS_REMOVE_NODES_REQ frame
+func BuildRemoveNodesRequest(nodeIDs []uint8) []byte {
+	return EncodeFrame(GW_CS_REMOVE_NODES_REQ, BuildNodeBitArray(nodeIDs))
+}
+
+// BuildControllerCopyRequest builds a GW_CS_CONTROLLER_COPY_REQ frame
+func BuildControllerCopyRequest(mode ControllerCopyMode) []byte {
+	return EncodeFrame(GW_CS_CONTROLLER_COPY_REQ, []byte{byte(mode)})
+}
+
+// ParseControllerCopyNotification parses GW_CS_CONTROLLER_COPY_NTF
+// Frame structure:
+// - ControllerCopyMode: 1 byte @ 0
+// - ControllerCopyStatus: 1 byte @ 1
+func ParseControllerCopyNotification(data []byte) (*ControllerCopyResult, error) {
+	if len(data) < 2 {
+		return nil, ErrFrameTooShort
+	}
+	return &ControllerCopyResult{
+		Mode:   ControllerCopyMode(data[0]),
+		Status: data[1],
+		OK:     data[1] == 0,
+	}, nil
+}
+
+// ParseSystemTableUpdateNotification parses GW_CS_SYSTEM_TABLE_UPDATE_NTF
+// Frame structure:
+// - AddedNode: 26 bytes @ 0 (bit array)
+// - RemovedNode: 26 bytes @ 26 (bit array)
+func ParseSystemTableUpdateNotification(data []byte) (*SystemTableUpdate, error) {
+	if len(data) < 2*NodeBitArraySize {
+		return nil, ErrFrameTooShort
+	}
+	return &SystemTableUpdate{
+		Added:   ParseNodeBitArray(data[:NodeBitArraySize]),
+		Removed: ParseNodeBitArray(data[NodeBitArraySize : 2*NodeBitArraySize]),
+	}, nil
+}
+
+// BuildNodeBitArray encodes node IDs as bit array (bit n of byte n/8 is node n)
+func BuildNodeBitArray(nodeIDs []uint8) []byte {
+	array := make([]byte, NodeBitArraySize)
+	for _, id := range nodeIDs {
+		if int(id)/8 < NodeBitArraySize {
+			array[id/8] |= 1 << (id % 8)
+		}
+	}
+	return array
+}
+
+// ParseNodeBitArray decodes a node bit array into node IDs
+func ParseNodeBitArray(array []byte) []uint8 {
+	ids := make([]uint8, 0)
+	for i, b := range array {
+		for bit := 0; bit < 8; bit++ {
+			if b&(1<<bit) != 0 {
+				ids = append(ids, uint8(i*8+bit))
+			}
+		}
+	}
+	return ids
+}
+
+// BuildSetNodeNameRequest builds a GW_SET_NODE_NAME_REQ frame
+// Frame structure:
+// - NodeID: 1 byte @ 0
+// - Name: 64 bytes @ 1 (null-terminated UTF-8)
+func BuildSetNodeNameRequest(nodeID uint8, name string) []byte {
+	data := make([]byte, 65)
+	data[0] = nodeID
+	copy(data[1:1+MaxNodeNameLength], name)
+	return EncodeFrame(GW_SET_NODE_NAME_REQ, data)
+}
+
+// BuildSetNodeVelocityRequest builds a GW_SET_NODE_VELOCITY_REQ frame
+func BuildSetNodeVelocityRequest(nodeID uint8, velocity Velocity) []byte {
+	return EncodeFrame(GW_SET_NODE_VELOCITY_REQ, []byte{nodeID, byte(velocity)})
+}
+
+// BuildSetNodeOrderAndPlacementRequest builds a GW_SET_NODE_ORDER_AND_PLACEMENT_REQ frame
+// Frame structure:
+// - NodeID: 1 byte @ 0
+// - Order: 2 bytes @ 1
+// - Placement: 1 byte @ 3
+func BuildSetNodeOrderAndPlacementRequest(nodeID uint8, order uint16, placement uint8) []byte {
+	data := make([]byte, 4)
+	data[0] = nodeID
+	binary.BigEndian.PutUint16(data[1:3], order)
+	data[3] = placement
+	return EncodeFrame(GW_SET_NODE_ORDER_AND_PLACEMENT_REQ, data)
+}
+
+// ParseSetNodeConfirm parses the confirmation of a set node name, velocity or order request.
+// They share the layout of ParseGetNodeInformationConfirm.
+func ParseSetNodeConfirm(data []byte) (status ResponseStatus, nodeID uint8, err error) {
+	return ParseGetNodeInformationConfirm(data)
+}
+
 // ParsePasswordConfirm parses password confirmation response
+// (GW_PASSWORD_ENTER_CFM and GW_PASSWORD_CHANGE_CFM, status 0 = success)
 func ParsePasswordConfirm(data []byte) (bool, error) {
 	if len(data) < 1 {
 		return false, ErrFrameTooShort
@@ -337,6 +802,10 @@ func ParseNodeInformation(data []byte) (*Node, error) {
 		ID: data[0],
 	}
 
+	// Order (2 bytes at offset 1) and placement (1 byte at offset 3)
+	node.Order = binary.BigEndian.Uint16(data[1:3])
+	node.Placement = data[3]
+
 	// Name (64 bytes at offset 4, null-terminated UTF-8)
 	nameEnd := 4
 	for i := 4; i < 68 && data[i] != 0; i++ {
@@ -352,6 +821,18 @@ func ParseNodeInformation(data []byte) (*Node, error) {
 	node.NodeTypeStr = node.NodeType.String()
 	node.Inverted = node.NodeType.IsInvertedType()
 
+	// Product group, type, variation, power mode and build number (1 byte each at offset 71-75)
+	node.ProductGroup = data[71]
+	node.ProductType = data[72]
+	node.Variation = NodeVariation(data[73])
+	node.VariationStr = node.Variation.String()
+	node.PowerMode = PowerMode(data[74])
+	node.PowerModeStr = node.PowerMode.String()
+	node.BuildNumber = data[75]
+
+	// Serial number (8 bytes at offset 76)
+	node.Serial = hex.EncodeToString(data[76:84])
+
 	// State (1 byte at offset 84)
 	node.State = NodeState(data[84])
 	node.StateStr = node.State.String()
@@ -364,6 +845,38 @@ func ParseNodeInformation(data []byte) (*Node, error) {
 	node.TargetPosition = binary.BigEndian.Uint16(data[87:89])
 	node.TargetPercent = PositionToPercent(node.TargetPosition)
 
+	// The remaining fields are missing in truncated records
+	if len(data) < 104 {
+		return node, nil
+	}
+
+	// Functional parameters FP1-FP4 (2 bytes each at offset 89)
+	for i := range node.FunctionalParams {
+		node.FunctionalParams[i] = binary.BigEndian.Uint16(data[89+2*i : 91+2*i])
+	}
+
+	// Remaining time (2 bytes at offset 97)
+	node.RemainingTime = binary.BigEndian.Uint16(data[97:99])
+
+	// Timestamp (4 bytes at offset 99, UNIX time)
+	if ts := binary.BigEndian.Uint32(data[99:103]); ts != 0 {
+		t := time.Unix(int64(ts), 0)
+		node.Timestamp = &t
+	}
+
+	// Alias array (up to 5 entries of type and value, 2 bytes each, at offset 104)
+	count := int(data[103])
+	if count > 5 {
+		count = 5
+	}
+	for i := 0; i < count && 108+4*i <= len(data); i++ {
+		offset := 104 + 4*i
+		node.Aliases = append(node.Aliases, NodeAlias{
+			Type:  binary.BigEndian.Uint16(data[offset : offset+2]),
+			Value: binary.BigEndian.Uint16(data[offset+2 : offset+4]),
+		})
+	}
+
 	return node, nil
 }
 
@@ -497,6 +1010,125 @@ func ParseLimitationStatusNotification(data []byte) (*LimitationStatus, error) {
 	return status, nil
 }
 
+// BuildSetLimitationRequest creates a request to set or clear a limitation on nodes
+// Frame structure:
+// - SessionID: 2 bytes
+// - CommandOriginator: 1 byte
+// - PriorityLevel: 1 byte
+// - IndexArrayCount: 1 byte
+// - IndexArray: 20 bytes (node IDs, padded with 0)
+// - ParameterID: 1 byte (0 = main parameter)
+// - LimitationValueMin: 2 bytes
+// - LimitationValueMax: 2 bytes
+// - LimitationTime: 1 byte
+func BuildSetLimitationRequest(sessionID uint16, originator Originator, priority Priority,
+	nodeIDs []uint8, minValue, maxValue uint16, limitationTime uint8) []byte {
+
+	buf := new(bytes.Buffer)
+
+	// Session ID (2 bytes)
+	binary.Write(buf, binary.BigEndian, sessionID)
+
+	// Command originator and priority level (1 byte each)
+	buf.WriteByte(byte(originator))
+	buf.WriteByte(byte(priority))
+
+	// Index array count (1 byte)
+	buf.WriteByte(byte(len(nodeIDs)))
+
+	// Node IDs (max 20, padded with 0)
+	for _, id := range nodeIDs {
+		buf.WriteByte(id)
+	}
+	for i := len(nodeIDs); i < 20; i++ {
+		buf.WriteByte(0)
+	}
+
+	// Parameter ID (1 byte) - 0 = main parameter
+	buf.WriteByte(0x00)
+
+	// Limitation min/max values (2 bytes each)
+	binary.Write(buf, binary.BigEndian, minValue)
+	binary.Write(buf, binary.BigEndian, maxValue)
+
+	// Limitation time (1 byte)
+	buf.WriteByte(limitationTime)
+
+	return EncodeFrame(GW_SET_LIMITATION_REQ, buf.Bytes())
+}
+
+// BuildWinkSendRequest builds a GW_WINK_SEND_REQ frame
+// Frame structure:
+// - SessionID: 2 bytes @ 0
+// - CommandOriginator: 1 byte @ 2
+// - PriorityLevel: 1 byte @ 3
+// - WinkState: 1 byte @ 4 (0 = disable, 1 = enable)
+// - WinkTime: 1 byte @ 5 (seconds, see WinkTime constants)
+// - IndexArrayCount: 1 byte @ 6
+// - IndexArray: 20 bytes @ 7
+func BuildWinkSendRequest(sessionID uint16, originator Originator, priority Priority,
+	enable bool, winkTime uint8, nodeIDs []uint8) []byte {
+
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, sessionID)
+	buf.WriteByte(byte(originator))
+	buf.WriteByte(byte(priority))
+	if enable {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	buf.WriteByte(winkTime)
+
+	// Node IDs (max 20, padded with 0)
+	buf.WriteByte(byte(len(nodeIDs)))
+	for _, id := range nodeIDs {
+		buf.WriteByte(id)
+	}
+	for i := len(nodeIDs); i < 20; i++ {
+		buf.WriteByte(0)
+	}
+
+	return EncodeFrame(GW_WINK_SEND_REQ, buf.Bytes())
+}
+
+// ParseWinkSendConfirm parses the confirmation of a wink request.
+// It has the same layout as the set limitation confirmation.
+func ParseWinkSendConfirm(data []byte) (sessionID uint16, accepted bool, err error) {
+	return ParseSetLimitationConfirm(data)
+}
+
+// ParseWinkSendNotification parses GW_WINK_SEND_NTF, sent when the wink has finished
+func ParseWinkSendNotification(data []byte) (sessionID uint16, err error) {
+	if len(data) < 2 {
+		return 0, ErrFrameTooShort
+	}
+	return binary.BigEndian.Uint16(data[0:2]), nil
+}
+
+// ParseSessionFinishedNotification parses GW_SESSION_FINISHED_NTF, sent when all nodes of a session are done
+func ParseSessionFinishedNotification(data []byte) (sessionID uint16, err error) {
+	if len(data) < 2 {
+		return 0, ErrFrameTooShort
+	}
+	return binary.BigEndian.Uint16(data[0:2]), nil
+}
+
+// ParseSetLimitationConfirm parses the confirmation of a set limitation request
+// Frame structure:
+// - SessionID: 2 bytes @ 0
+// - Status: 1 byte @ 2 (0 = rejected, 1 = accepted)
+func ParseSetLimitationConfirm(data []byte) (sessionID uint16, accepted bool, err error) {
+	if len(data) < 3 {
+		return 0, false, ErrFrameTooShort
+	}
+
+	sessionID = binary.BigEndian.Uint16(data[0:2])
+	accepted = data[2] == 1
+
+	return sessionID, accepted, nil
+}
+
 // StatusReplyToLimitationType converts a StatusReply limitation code to LimitationType
 func StatusReplyToLimitationType(reply StatusReply) LimitationType {
 	switch reply {
@@ -514,3 +1146,178 @@ func StatusReplyToLimitationType(reply StatusReply) LimitationType {
 		return LimitationTypeUnknown
 	}
 }
+
+// ParseSceneListNotification parses GW_GET_SCENE_LIST_NTF
+// Frame structure:
+// - NumberOfObject: 1 byte @ 0
+// - Objects: 65 bytes each
+//   - SceneID: 1 byte
+//   - Name: 64 bytes (null-terminated UTF-8)
+// - RemainingNumberOfObject: 1 byte
+func ParseSceneListNotification(data []byte) (scenes []Scene, remaining uint8, err error) {
+	if len(data) < 2 {
+		return nil, 0, ErrFrameTooShort
+	}
+
+	count := int(data[0])
+	if len(data) < 2+65*count {
+		return nil, 0, ErrFrameTooShort
+	}
+
+	for i := 0; i < count; i++ {
+		o := data[1+65*i : 66+65*i]
+		scenes = append(scenes, Scene{ID: o[0], Name: parseName(o[1:65])})
+	}
+
+	return scenes, data[1+65*count], nil
+}
+
+// ParseInitializeSceneNotification parses GW_INITIALIZE_SCENE_NTF
+// Frame structure:
+// - Status: 1 byte @ 0 (0 = OK, 1 = partly OK, 2 = error)
+// - FailedNodes: 26 bytes @ 1 (bit array)
+func ParseInitializeSceneNotification(data []byte) (*SceneInitResult, error) {
+	if len(data) < 1+NodeBitArraySize {
+		return nil, ErrFrameTooShort
+	}
+	return &SceneInitResult{
+		Status:      data[0],
+		FailedNodes: ParseNodeBitArray(data[1 : 1+NodeBitArraySize]),
+	}, nil
+}
+
+// BuildRecordSceneRequest builds a GW_RECORD_SCENE_REQ frame (name: 64 bytes)
+func BuildRecordSceneRequest(name string) []byte {
+	data := make([]byte, 64)
+	copy(data[:MaxSceneNameLength], name)
+	return EncodeFrame(GW_RECORD_SCENE_REQ, data)
+}
+
+// ParseRecordSceneNotification parses GW_RECORD_SCENE_NTF
+// Frame structure:
+// - Status: 1 byte @ 0 (0 = OK)
+// - SceneID: 1 byte @ 1
+func ParseRecordSceneNotification(data []byte) (*SceneRecordResult, error) {
+	if len(data) < 2 {
+		return nil, ErrFrameTooShort
+	}
+	return &SceneRecordResult{OK: data[0] == 0, SceneID: data[1]}, nil
+}
+
+// BuildDeleteSceneRequest builds a GW_DELETE_SCENE_REQ frame
+func BuildDeleteSceneRequest(sceneID uint8) []byte {
+	return EncodeFrame(GW_DELETE_SCENE_REQ, []byte{sceneID})
+}
+
+// BuildRenameSceneRequest builds a GW_RENAME_SCENE_REQ frame
+// Frame structure:
+// - SceneID: 1 byte @ 0
+// - Name: 64 bytes @ 1
+func BuildRenameSceneRequest(sceneID uint8, name string) []byte {
+	data := make([]byte, 65)
+	data[0] = sceneID
+	copy(data[1:1+MaxSceneNameLength], name)
+	return EncodeFrame(GW_RENAME_SCENE_REQ, data)
+}
+
+// ParseSceneStatusConfirm parses the status byte of the scene confirmations.
+// 0 = OK; the meaning of other values depends on the request.
+func ParseSceneStatusConfirm(data []byte) (uint8, error) {
+	if len(data) < 1 {
+		return 0, ErrFrameTooShort
+	}
+	return data[0], nil
+}
+
+// ParseSceneInformationChangedNotification parses GW_SCENE_INFORMATION_CHANGED_NTF
+// Frame structure:
+// - ChangeType: 1 byte @ 0 (0 = deleted, 1 = modified)
+// - SceneID: 1 byte @ 1
+func ParseSceneInformationChangedNotification(data []byte) (*SceneChange, error) {
+	if len(data) < 2 {
+		return nil, ErrFrameTooShort
+	}
+	return &SceneChange{Deleted: data[0] == 0, SceneID: data[1]}, nil
+}
+
+// parseName returns a null-terminated UTF-8 name
+func parseName(data []byte) string {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+	return string(data)
+}
+
+// contactInputLinkSize is the size of a contact input link record
+const contactInputLinkSize = 15
+
+// Contact input link record (15 bytes):
+// - ContactInputID: 1 byte @ 0
+// - ContactInputAssignment: 1 byte @ 1
+// - ActionID: 1 byte @ 2 (scene or node ID)
+// - CommandOriginator: 1 byte @ 3
+// - PriorityLevel: 1 byte @ 4
+// - ParameterID: 1 byte @ 5 (0 = main parameter)
+// - Position: 2 bytes @ 6
+// - Velocity: 1 byte @ 8
+// - LockPriorityLevel: 1 byte @ 9
+// - PriorityLevelInformation: 2 bytes @ 10
+// - LockTime: 1 byte @ 12
+// - SuccessOutputID: 1 byte @ 13
+// - ErrorOutputID: 1 byte @ 14
+
+// ParseContactInputLinkListConfirm parses GW_GET_CONTACT_INPUT_LINK_LIST_CFM
+// Frame structure:
+// - ContactInputObjectCount: 1 byte @ 0
+// - ContactInputObjects: 15 bytes each
+func ParseContactInputLinkListConfirm(data []byte) ([]ContactInputLink, error) {
+	if len(data) < 1 {
+		return nil, ErrFrameTooShort
+	}
+
+	count := int(data[0])
+	if len(data) < 1+contactInputLinkSize*count {
+		return nil, ErrFrameTooShort
+	}
+
+	links := make([]ContactInputLink, 0, count)
+	for i := 0; i < count; i++ {
+		o := data[1+contactInputLinkSize*i : 1+contactInputLinkSize*(i+1)]
+		link := ContactInputLink{
+			InputID:         o[0],
+			Assignment:      ContactInputAssignment(o[1]),
+			ActionID:        o[2],
+			Priority:        Priority(o[4]),
+			Position:        binary.BigEndian.Uint16(o[6:8]),
+			Velocity:        Velocity(o[8]),
+			SuccessOutputID: o[13],
+			ErrorOutputID:   o[14],
+		}
+		link.AssignmentStr = link.Assignment.String()
+		link.PositionPercent = PositionToPercent(link.Position)
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// BuildSetContactInputLinkRequest builds a GW_SET_CONTACT_INPUT_LINK_REQ frame (one link record)
+func BuildSetContactInputLinkRequest(link ContactInputLink) []byte {
+	data := make([]byte, contactInputLinkSize)
+	data[0] = link.InputID
+	data[1] = byte(link.Assignment)
+	data[2] = link.ActionID
+	data[3] = 1 // User originated
+	data[4] = byte(link.Priority)
+	data[5] = 0 // Main parameter
+	binary.BigEndian.PutUint16(data[6:8], link.Position)
+	data[8] = byte(link.Velocity)
+	// No priority level lock (LockPriorityLevel, PriorityLevelInformation and LockTime = 0)
+	data[13] = link.SuccessOutputID
+	data[14] = link.ErrorOutputID
+	return EncodeFrame(GW_SET_CONTACT_INPUT_LINK_REQ, data)
+}
+
+// BuildRemoveContactInputLinkRequest builds a GW_REMOVE_CONTACT_INPUT_LINK_REQ frame
+func BuildRemoveContactInputLinkRequest(inputID uint8) []byte {
+	return EncodeFrame(GW_REMOVE_CONTACT_INPUT_LINK_REQ, []byte{inputID})
+}
@@ -0,0 +1,935 @@
+package klf200
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStatusRequestNotification(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want *NodeStatus
+		err  error
+	}{
+		{
+			name: "main info",
+			data: []byte{
+				0x12, 0x34, // Session ID
+				0x00,       // Status ID
+				0x05,       // Node ID
+				0x02,       // Run status: active
+				0x01,       // Status reply: OK
+				0x03,       // Status type: main info
+				0xC8, 0x00, // Target position
+				0x64, 0x00, // Current position
+				0x00, 0x0A, // Remaining time
+				0x01, 0x02, 0x03, 0x04, // Last master execution address
+				0x01, // Last command originator: user
+			},
+			want: &NodeStatus{
+				SessionID:                  0x1234,
+				NodeID:                     5,
+				RunStatus:                  RunStatusExecutionActive,
+				StatusReply:                StatusReplyCommandCompletedOk,
+				StatusType:                 StatusTypeMainInfo,
+				TargetPosition:             0xC800,
+				CurrentPosition:            0x6400,
+				RemainingTime:              10,
+				LastMasterExecutionAddress: 0x01020304,
+				LastCommandOriginator:      OriginatorUser,
+			},
+		},
+		{
+			name: "current position with functional parameters",
+			data: []byte{
+				0x00, 0x07, 0x00, 0x02, 0x00, 0x01,
+				0x01,             // Status type: current position
+				0x02,             // Parameter count
+				0x00, 0x32, 0x00, // Main parameter
+				0x03, 0xC8, 0x00, // FP3
+			},
+			want: &NodeStatus{
+				SessionID:   7,
+				NodeID:      2,
+				RunStatus:   RunStatusExecutionCompleted,
+				StatusReply: StatusReplyCommandCompletedOk,
+				StatusType:  StatusTypeCurrentPosition,
+				Parameters:  map[uint8]uint16{0: 0x3200, 3: 0xC800},
+			},
+		},
+		{
+			name: "no parameters",
+			data: []byte{0x00, 0x01, 0x00, 0x03, 0x00, 0x01, 0x02, 0x00},
+			want: &NodeStatus{
+				SessionID:   1,
+				NodeID:      3,
+				RunStatus:   RunStatusExecutionCompleted,
+				StatusReply: StatusReplyCommandCompletedOk,
+				StatusType:  StatusTypeRemainingTime,
+				Parameters:  map[uint8]uint16{},
+			},
+		},
+		{
+			name: "too short",
+			data: []byte{0x00, 0x01, 0x00, 0x03},
+			err:  ErrFrameTooShort,
+		},
+		{
+			name: "main info truncated",
+			data: []byte{0x00, 0x01, 0x00, 0x03, 0x00, 0x01, 0x03, 0xC8, 0x00},
+			err:  ErrFrameTooShort,
+		},
+		{
+			name: "parameters truncated",
+			data: []byte{0x00, 0x01, 0x00, 0x03, 0x00, 0x01, 0x01, 0x02, 0x00, 0x32, 0x00},
+			err:  ErrFrameTooShort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStatusRequestNotification(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// nodeInformationRecord builds a GW_GET_NODE_INFORMATION_NTF record of the given length
+func nodeInformationRecord(length int) []byte {
+	data := make([]byte, length)
+	data[0] = 4             // Node ID
+	data[1], data[2] = 0, 3 // Order
+	data[3] = 2             // Placement
+	copy(data[4:], "Dachfenster")
+	data[68] = byte(VelocitySilent)
+	data[69], data[70] = 0x01, 0x01 // Node type: window opener
+	data[71] = 14                   // Product group
+	data[72] = 3                    // Product type
+	data[73] = byte(NodeVariationKip)
+	data[74] = byte(PowerModeAlwaysAlive)
+	data[75] = 42 // Build number
+	copy(data[76:84], []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08})
+	data[84] = byte(NodeStateDone)
+	data[85], data[86] = 0x64, 0x00 // Current position 50%
+	data[87], data[88] = 0xC8, 0x00 // Target position 100%
+	if length >= 104 {
+		data[93], data[94] = 0x32, 0x00 // FP3
+		data[97], data[98] = 0x00, 0x05 // Remaining time
+		data[99], data[100], data[101], data[102] = 0x65, 0x00, 0x00, 0x00
+		data[103] = 1 // Alias count
+	}
+	if length >= 108 {
+		data[104], data[105], data[106], data[107] = 0xD8, 0x03, 0xBA, 0x00
+	}
+	return data
+}
+
+func TestParseNodeInformation(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		wantErr   bool
+		remaining uint16
+		fp3       uint16
+		timestamp bool
+		aliases   []NodeAlias
+	}{
+		{
+			name: "truncated record",
+			data: nodeInformationRecord(89),
+		},
+		{
+			name:      "full record without alias",
+			data:      nodeInformationRecord(104),
+			remaining: 5,
+			fp3:       0x3200,
+			timestamp: true,
+		},
+		{
+			name:      "full record with alias",
+			data:      nodeInformationRecord(124),
+			remaining: 5,
+			fp3:       0x3200,
+			timestamp: true,
+			aliases:   []NodeAlias{{Type: 0xD803, Value: 0xBA00}},
+		},
+		{
+			name:    "too short",
+			data:    nodeInformationRecord(89)[:88],
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := ParseNodeInformation(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if node.ID != 4 || node.Order != 3 || node.Placement != 2 {
+				t.Errorf("id/order/placement = %d/%d/%d, want 4/3/2", node.ID, node.Order, node.Placement)
+			}
+			if node.Name != "Dachfenster" {
+				t.Errorf("name = %q, want %q", node.Name, "Dachfenster")
+			}
+			if node.NodeType != NodeTypeWindowOpener || node.NodeTypeStr != NodeTypeWindowOpener.String() {
+				t.Errorf("node type = %v (%q), want window opener", node.NodeType, node.NodeTypeStr)
+			}
+			if node.Velocity != VelocitySilent || node.Variation != NodeVariationKip || node.BuildNumber != 42 {
+				t.Errorf("velocity/variation/build = %v/%v/%d", node.Velocity, node.Variation, node.BuildNumber)
+			}
+			if node.Serial != "0102030405060708" {
+				t.Errorf("serial = %q", node.Serial)
+			}
+			if node.State != NodeStateDone || node.PositionPercent != 50 || node.TargetPercent != 100 {
+				t.Errorf("state/position/target = %v/%v/%v", node.State, node.PositionPercent, node.TargetPercent)
+			}
+			if node.RemainingTime != tt.remaining || node.FunctionalParams[2] != tt.fp3 {
+				t.Errorf("remaining/fp3 = %d/%#x, want %d/%#x", node.RemainingTime, node.FunctionalParams[2], tt.remaining, tt.fp3)
+			}
+			if (node.Timestamp != nil) != tt.timestamp {
+				t.Errorf("timestamp = %v, want set = %v", node.Timestamp, tt.timestamp)
+			}
+			if !reflect.DeepEqual(node.Aliases, tt.aliases) {
+				t.Errorf("aliases = %+v, want %+v", node.Aliases, tt.aliases)
+			}
+		})
+	}
+}
+
+func TestBuildSetLimitationRequest(t *testing.T) {
+	frame, err := DecodeFrame(BuildSetLimitationRequest(0x1234, OriginatorUser, PriorityUserLevel2, []uint8{3, 7}, 0x0000, 0x6400, 5))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if frame.Command != GW_SET_LIMITATION_REQ {
+		t.Fatalf("command = %v, want GW_SET_LIMITATION_REQ", frame.Command)
+	}
+
+	want := make([]byte, 31)
+	copy(want, []byte{0x12, 0x34, byte(OriginatorUser), byte(PriorityUserLevel2), 2, 3, 7})
+	// Parameter ID 0 at 25, then min, max and the limitation time
+	copy(want[26:], []byte{0x00, 0x00, 0x64, 0x00, 5})
+	if !reflect.DeepEqual(frame.Data, want) {
+		t.Errorf("data = % X, want % X", frame.Data, want)
+	}
+}
+
+func TestParseLimitationStatusNotification(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want *LimitationStatus
+		err  error
+	}{
+		{
+			name: "rain limitation",
+			data: []byte{0x00, 0x09, 0x03, 0x00, 0x00, 0x00, 0x64, 0x00, 0x01, 0x04},
+			want: &LimitationStatus{
+				NodeID:           3,
+				MinValue:         0x0000,
+				MaxValue:         0x6400,
+				LimitationOrigin: LimitationTypeRain,
+				LimitationTime:   4,
+			},
+		},
+		{
+			name: "ignored values and unlimited time",
+			data: []byte{0x00, 0x01, 0x07, 0x00, 0xD4, 0x00, 0xD4, 0x00, 0x02, 0xFD},
+			want: &LimitationStatus{
+				NodeID:           7,
+				MinValue:         PositionIgnore,
+				MaxValue:         PositionIgnore,
+				LimitationOrigin: LimitationTypeWind,
+				LimitationTime:   253,
+			},
+		},
+		{
+			name: "too short",
+			data: []byte{0x00, 0x01, 0x07, 0x00, 0xD4, 0x00, 0xD4, 0x00, 0x02},
+			err:  ErrFrameTooShort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLimitationStatusNotification(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// sceneRecord builds a scene entry of GW_GET_SCENE_LIST_NTF
+func sceneRecord(id uint8, name string) []byte {
+	record := make([]byte, 65)
+	record[0] = id
+	copy(record[1:], name)
+	return record
+}
+
+func TestParseSceneListNotification(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		want      []Scene
+		remaining uint8
+		err       error
+	}{
+		{
+			name:      "two scenes, more to come",
+			data:      append(append(append([]byte{2}, sceneRecord(0, "Alle zu")...), sceneRecord(5, "Lüften")...), 3),
+			want:      []Scene{{ID: 0, Name: "Alle zu"}, {ID: 5, Name: "Lüften"}},
+			remaining: 3,
+		},
+		{
+			name: "empty list",
+			data: []byte{0, 0},
+		},
+		{
+			name: "too short",
+			data: []byte{1},
+			err:  ErrFrameTooShort,
+		},
+		{
+			name: "record truncated",
+			data: append([]byte{1}, sceneRecord(1, "Nacht")[:40]...),
+			err:  ErrFrameTooShort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenes, remaining, err := ParseSceneListNotification(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(scenes, tt.want) || remaining != tt.remaining {
+				t.Errorf("got %+v, %d remaining, want %+v, %d remaining", scenes, remaining, tt.want, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestBuildRenameSceneRequest(t *testing.T) {
+	frame, err := DecodeFrame(BuildRenameSceneRequest(7, "Morgen"))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if frame.Command != GW_RENAME_SCENE_REQ || len(frame.Data) != 65 {
+		t.Fatalf("command %v with %d bytes, want GW_RENAME_SCENE_REQ with 65 bytes", frame.Command, len(frame.Data))
+	}
+	if frame.Data[0] != 7 || parseName(frame.Data[1:]) != "Morgen" {
+		t.Errorf("scene %d %q, want 7 %q", frame.Data[0], parseName(frame.Data[1:]), "Morgen")
+	}
+}
+
+func TestContactInputLinkRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		link ContactInputLink
+	}{
+		{
+			name: "node link",
+			link: ContactInputLink{
+				InputID:         1,
+				Assignment:      ContactInputNode,
+				ActionID:        4,
+				Priority:        PriorityUserLevel2,
+				Position:        0x6400,
+				Velocity:        VelocitySilent,
+				SuccessOutputID: 2,
+				ErrorOutputID:   3,
+			},
+		},
+		{
+			name: "scene link",
+			link: ContactInputLink{
+				InputID:    5,
+				Assignment: ContactInputScene,
+				ActionID:   9,
+				Priority:   PriorityUserLevel2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := DecodeFrame(BuildSetContactInputLinkRequest(tt.link))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if frame.Command != GW_SET_CONTACT_INPUT_LINK_REQ {
+				t.Fatalf("command = %v, want GW_SET_CONTACT_INPUT_LINK_REQ", frame.Command)
+			}
+
+			// The list confirmation uses the same record layout
+			links, err := ParseContactInputLinkListConfirm(append([]byte{1}, frame.Data...))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			want := tt.link
+			want.AssignmentStr = want.Assignment.String()
+			want.PositionPercent = PositionToPercent(want.Position)
+			if len(links) != 1 || !reflect.DeepEqual(links[0], want) {
+				t.Errorf("got %+v, want %+v", links, want)
+			}
+		})
+	}
+}
+
+func TestParseContactInputLinkListConfirm(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantLen int
+		err     error
+	}{
+		{name: "empty list", data: []byte{0}},
+		{name: "two links", data: append([]byte{2}, make([]byte, 2*contactInputLinkSize)...), wantLen: 2},
+		{name: "missing count", data: []byte{}, err: ErrFrameTooShort},
+		{name: "record truncated", data: append([]byte{1}, make([]byte, contactInputLinkSize-1)...), err: ErrFrameTooShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links, err := ParseContactInputLinkListConfirm(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if len(links) != tt.wantLen {
+				t.Errorf("got %d links, want %d", len(links), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestParseGetNodeInformationConfirm(t *testing.T) {
+	status, nodeID, err := ParseGetNodeInformationConfirm([]byte{2, 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != 2 || nodeID != 7 {
+		t.Errorf("got status %d node %d, want 2 and 7", status, nodeID)
+	}
+
+	if _, _, err := ParseGetNodeInformationConfirm([]byte{0}); !errors.Is(err, ErrFrameTooShort) {
+		t.Errorf("short frame error = %v, want ErrFrameTooShort", err)
+	}
+}
+
+func TestBuildSetNodeRequests(t *testing.T) {
+	longName := strings.Repeat("a", MaxNodeNameLength)
+
+	name := make([]byte, 65)
+	name[0] = 4
+	copy(name[1:], "Kitchen")
+	longNameData := make([]byte, 65)
+	longNameData[0] = 4
+	copy(longNameData[1:], longName)
+
+	tests := []struct {
+		name        string
+		frame       []byte
+		wantCommand CommandID
+		wantData    []byte
+	}{
+		{name: "name", frame: BuildSetNodeNameRequest(4, "Kitchen"), wantCommand: GW_SET_NODE_NAME_REQ, wantData: name},
+		// The last byte stays the null terminator
+		{name: "longest name", frame: BuildSetNodeNameRequest(4, longName+"b"), wantCommand: GW_SET_NODE_NAME_REQ, wantData: longNameData},
+		{name: "velocity", frame: BuildSetNodeVelocityRequest(4, VelocityFast), wantCommand: GW_SET_NODE_VELOCITY_REQ, wantData: []byte{4, byte(VelocityFast)}},
+		{name: "order and placement", frame: BuildSetNodeOrderAndPlacementRequest(4, 0x0102, 3), wantCommand: GW_SET_NODE_ORDER_AND_PLACEMENT_REQ, wantData: []byte{4, 0x01, 0x02, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := DecodeFrame(tt.frame)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if frame.Command != tt.wantCommand {
+				t.Errorf("command = %v, want %v", frame.Command, tt.wantCommand)
+			}
+			if !reflect.DeepEqual(frame.Data, tt.wantData) {
+				t.Errorf("data = % X, want % X", frame.Data, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestBuildWinkSendRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		enable   bool
+		winkTime uint8
+		nodeIDs  []uint8
+		wantHead []byte
+	}{
+		{name: "enable", enable: true, winkTime: 10, nodeIDs: []uint8{5}, wantHead: []byte{0x00, 0x2A, byte(OriginatorUser), byte(PriorityUserLevel2), 1, 10, 1, 5}},
+		{name: "manufacturer default", enable: true, winkTime: WinkTimeManufacturerDefault, nodeIDs: []uint8{5, 6}, wantHead: []byte{0x00, 0x2A, byte(OriginatorUser), byte(PriorityUserLevel2), 1, 254, 2, 5, 6}},
+		{name: "disable", winkTime: WinkTimeStop, nodeIDs: []uint8{5}, wantHead: []byte{0x00, 0x2A, byte(OriginatorUser), byte(PriorityUserLevel2), 0, 0, 1, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := DecodeFrame(BuildWinkSendRequest(0x002A, OriginatorUser, PriorityUserLevel2, tt.enable, tt.winkTime, tt.nodeIDs))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if frame.Command != GW_WINK_SEND_REQ {
+				t.Errorf("command = %v, want GW_WINK_SEND_REQ", frame.Command)
+			}
+
+			// The node index array is always padded to 20 entries
+			want := make([]byte, 27)
+			copy(want, tt.wantHead)
+			if !reflect.DeepEqual(frame.Data, want) {
+				t.Errorf("data = % X, want % X", frame.Data, want)
+			}
+		})
+	}
+}
+
+func TestParseWinkSendNotification(t *testing.T) {
+	sessionID, err := ParseWinkSendNotification([]byte{0x12, 0x34})
+	if err != nil || sessionID != 0x1234 {
+		t.Errorf("got session %#04x, error %v, want 0x1234", sessionID, err)
+	}
+	if _, err := ParseWinkSendNotification([]byte{0x12}); !errors.Is(err, ErrFrameTooShort) {
+		t.Errorf("short frame error = %v, want ErrFrameTooShort", err)
+	}
+}
+
+func TestParseSystemTableNotification(t *testing.T) {
+	data := []byte{
+		2,
+		// Index 0: address 0x123456, type 0x0101, flags (power mode 1, io-membership, turnaround 2), manufacturer 1, backbone 0xABCDEF
+		0, 0x12, 0x34, 0x56, 0x01, 0x01, 0x80 | 0x04 | 0x01, 1, 0xAB, 0xCD, 0xEF,
+		// Index 5: RF support only
+		5, 0x00, 0x00, 0x01, 0x00, 0x40, 0x08, 2, 0x00, 0x00, 0x01,
+		3, // Remaining entries
+	}
+
+	entries, remaining, err := ParseSystemTableNotification(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if remaining != 3 {
+		t.Errorf("remaining = %d, want 3", remaining)
+	}
+
+	want := []SystemTableEntry{
+		{Index: 0, Address: 0x123456, NodeType: 0x0101, PowerMode: 1, IOMembership: true, TurnaroundTime: 2, Manufacturer: 1, BackboneRef: 0xABCDEF},
+		{Index: 5, Address: 0x000001, NodeType: 0x0040, RFSupport: true, Manufacturer: 2, BackboneRef: 0x000001},
+	}
+	for i := range want {
+		want[i].NodeTypeStr = want[i].NodeType.String()
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries = %+v, want %+v", entries, want)
+	}
+
+	for _, short := range [][]byte{{0}, {1, 0, 0, 0}, data[:len(data)-1]} {
+		if _, _, err := ParseSystemTableNotification(short); !errors.Is(err, ErrFrameTooShort) {
+			t.Errorf("ParseSystemTableNotification(% X) error = %v, want ErrFrameTooShort", short, err)
+		}
+	}
+}
+
+func TestParseDiscoverNodesNotification(t *testing.T) {
+	data := make([]byte, 5*NodeBitArraySize+1)
+	copy(data[0:], BuildNodeBitArray([]uint8{3, 4}))
+	copy(data[NodeBitArraySize:], BuildNodeBitArray([]uint8{9}))
+	copy(data[4*NodeBitArraySize:], BuildNodeBitArray([]uint8{200}))
+	data[5*NodeBitArraySize] = byte(DiscoverStatusPartialOK)
+
+	result, err := ParseDiscoverNodesNotification(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &DiscoveryResult{
+		Added:      []uint8{3, 4},
+		RFError:    []uint8{9},
+		IOKeyError: []uint8{},
+		Removed:    []uint8{},
+		Open:       []uint8{200},
+		Status:     DiscoverStatusPartialOK,
+		StatusStr:  "Partial OK",
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+
+	if _, err := ParseDiscoverNodesNotification(data[:5*NodeBitArraySize]); !errors.Is(err, ErrFrameTooShort) {
+		t.Errorf("short frame error = %v, want ErrFrameTooShort", err)
+	}
+}
+
+func TestNodeBitArray(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodeIDs []uint8
+		want    map[int]byte // Non-zero bytes of the array
+		wantIDs []uint8
+	}{
+		{name: "empty", wantIDs: []uint8{}},
+		{name: "first byte", nodeIDs: []uint8{0, 1, 7}, want: map[int]byte{0: 0x83}, wantIDs: []uint8{0, 1, 7}},
+		{name: "several bytes", nodeIDs: []uint8{8, 17, 199}, want: map[int]byte{1: 0x01, 2: 0x02, 24: 0x80}, wantIDs: []uint8{8, 17, 199}},
+		{name: "last index", nodeIDs: []uint8{207}, want: map[int]byte{25: 0x80}, wantIDs: []uint8{207}},
+		{name: "out of range is dropped", nodeIDs: []uint8{208, 255}, wantIDs: []uint8{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			array := BuildNodeBitArray(tt.nodeIDs)
+			want := make([]byte, NodeBitArraySize)
+			for i, b := range tt.want {
+				want[i] = b
+			}
+			if !reflect.DeepEqual(array, want) {
+				t.Errorf("BuildNodeBitArray(%v) = % X, want % X", tt.nodeIDs, array, want)
+			}
+			if ids := ParseNodeBitArray(array); !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("ParseNodeBitArray() = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestPasswordChangeRoundTrip(t *testing.T) {
+	frame, err := DecodeFrame(BuildPasswordChangeRequest("velux123", "new-secret"))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if frame.Command != GW_PASSWORD_CHANGE_REQ || len(frame.Data) != 2*MaxPasswordLength {
+		t.Fatalf("got %v with %d bytes, want GW_PASSWORD_CHANGE_REQ with %d", frame.Command, len(frame.Data), 2*MaxPasswordLength)
+	}
+
+	// The notification carries the new password in the same 32 byte layout
+	for i, want := range []string{"velux123", "new-secret"} {
+		got, err := ParsePasswordChangeNotification(frame.Data[i*MaxPasswordLength:])
+		if err != nil || got != want {
+			t.Errorf("password %d = %q, %v, want %q", i, got, err, want)
+		}
+	}
+
+	if _, err := ParsePasswordChangeNotification(make([]byte, MaxPasswordLength-1)); !errors.Is(err, ErrFrameTooShort) {
+		t.Errorf("short frame error = %v, want ErrFrameTooShort", err)
+	}
+}
+
+func TestBuildCommandSendRequestLock(t *testing.T) {
+	lock := NewPriorityLevelLock(PriorityComfortLevel1, LockModeLock, 3)
+
+	tests := []struct {
+		name string
+		lock *PriorityLevelLock
+		want []byte // PriorityLevelLock, PriorityLevelInfo and LockTime
+	}{
+		{name: "locks unchanged", want: []byte{0x00, 0x00, 0x00, 0x00}},
+		{name: "lock", lock: &lock, want: []byte{0x01, 0xFF, 0x55, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := DecodeFrame(BuildCommandSendRequest(1, 1, PriorityComfortLevel1, []uint8{2}, 0, nil, tt.lock))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(frame.Data) != 66 {
+				t.Fatalf("payload is %d bytes, want 66", len(frame.Data))
+			}
+			if got := frame.Data[62:66]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lock bytes = % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGetVersionConfirm(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want *GatewayVersion
+		err  error
+	}{
+		{
+			name: "version",
+			data: []byte{0, 2, 0, 0, 71, 0, 6, 14, 3},
+			want: &GatewayVersion{Software: "0.2.0.0.71.0", Hardware: 6, ProductGroup: 14, ProductType: 3},
+		},
+		{name: "too short", data: []byte{0, 2, 0, 0, 71, 0, 6, 14}, err: ErrFrameTooShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGetVersionConfirm(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGetProtocolVersionConfirm(t *testing.T) {
+	got, err := ParseGetProtocolVersionConfirm([]byte{0x00, 0x03, 0x00, 0x0E})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.String() != "3.14" {
+		t.Errorf("got %s, want 3.14", got)
+	}
+	if _, err := ParseGetProtocolVersionConfirm([]byte{0x00, 0x03, 0x00}); !errors.Is(err, ErrFrameTooShort) {
+		t.Errorf("error = %v, want %v", err, ErrFrameTooShort)
+	}
+}
+
+func TestParseGetStateConfirm(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want *GatewayState
+		err  error
+	}{
+		{
+			name: "gateway with actuators, idle",
+			data: []byte{2, 0x00, 0, 0, 0, 0},
+			want: &GatewayState{State: 2, StateStr: "Gateway Mode, With Actuator", SubState: 0, SubStateStr: "Idle"},
+		},
+		{
+			name: "unknown state",
+			data: []byte{9, 0x02},
+			want: &GatewayState{State: 9, StateStr: "Unknown", SubState: 2, SubStateStr: "Scene Configuration"},
+		},
+		{name: "too short", data: []byte{2}, err: ErrFrameTooShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGetStateConfirm(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSetUTCRequest(t *testing.T) {
+	frame, err := DecodeFrame(BuildSetUTCRequest(time.Unix(0x65432100, 0)))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []byte{0x65, 0x43, 0x21, 0x00}
+	if frame.Command != GW_SET_UTC_REQ || !reflect.DeepEqual(frame.Data, want) {
+		t.Errorf("got %v % X, want GW_SET_UTC_REQ % X", frame.Command, frame.Data, want)
+	}
+}
+
+func TestBuildSetTimeZoneRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeZone string
+		want     string
+	}{
+		{name: "central europe", timeZone: ":GMT+1:GMT+2:0060:(1994)040102-0:110102-0", want: ":GMT+1:GMT+2:0060:(1994)040102-0:110102-0"},
+		{name: "too long is cut", timeZone: strings.Repeat("x", 70), want: strings.Repeat("x", MaxTimeZoneLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := DecodeFrame(BuildSetTimeZoneRequest(tt.timeZone))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if frame.Command != GW_RTC_SET_TIME_ZONE_REQ || len(frame.Data) != 64 {
+				t.Fatalf("command %v with %d bytes, want GW_RTC_SET_TIME_ZONE_REQ with 64 bytes", frame.Command, len(frame.Data))
+			}
+			// The last byte always terminates the string
+			if got := strings.TrimRight(string(frame.Data), "\x00"); got != tt.want || frame.Data[63] != 0 {
+				t.Errorf("time zone %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseActivationLogLine(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want *ActivationLogLine
+		err  error
+	}{
+		{
+			name: "rain sensor command",
+			data: []byte{
+				0x65, 0x00, 0x00, 0x00, // Timestamp
+				0x00, 0x2A, // Session ID
+				0x01,                   // Status owner: user
+				0x02,                   // Originator: rain
+				0x03,                   // Node ID
+				0x00,                   // Node parameter
+				0x00,                   // Run status: completed
+				0x01,                   // Status reply: OK
+				0x00, 0x00, 0x01, 0x00, // Information code
+			},
+			want: &ActivationLogLine{
+				Time:            time.Unix(0x65000000, 0),
+				SessionID:       42,
+				StatusOwner:     OriginatorUser,
+				Originator:      OriginatorRain,
+				OriginatorStr:   OriginatorRain.String(),
+				NodeID:          3,
+				RunStatus:       RunStatusExecutionCompleted,
+				StatusReply:     StatusReplyCommandCompletedOk,
+				InformationCode: 0x100,
+			},
+		},
+		{
+			name: "too short",
+			data: make([]byte, 15),
+			err:  ErrFrameTooShort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseActivationLogLine(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseActivationLogHeaderConfirm(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		maxLines uint16
+		lines    uint16
+		err      error
+	}{
+		{name: "header", data: []byte{0x00, 0xFA, 0x00, 0x12}, maxLines: 250, lines: 18},
+		{name: "too short", data: []byte{0x00, 0xFA, 0x00}, err: ErrFrameTooShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxLines, lines, err := ParseActivationLogHeaderConfirm(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if maxLines != tt.maxLines || lines != tt.lines {
+				t.Errorf("got %d/%d, want %d/%d", maxLines, lines, tt.maxLines, tt.lines)
+			}
+		})
+	}
+}
+
+func TestBuildGetMultipleActivationLogLinesRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		since time.Time
+		want  []byte
+	}{
+		{name: "all lines", since: time.Time{}, want: []byte{0, 0, 0, 0}},
+		{name: "since timestamp", since: time.Unix(0x65000010, 0), want: []byte{0x65, 0x00, 0x00, 0x10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := DecodeFrame(BuildGetMultipleActivationLogLinesRequest(tt.since))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if frame.Command != GW_GET_MULTIPLE_ACTIVATION_LOG_LINES_REQ || !reflect.DeepEqual(frame.Data, tt.want) {
+				t.Errorf("got %v % X, want % X", frame.Command, frame.Data, tt.want)
+			}
+		})
+	}
+}
+
+func TestNetworkSetupRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup NetworkSetup
+	}{
+		{
+			name: "static address",
+			setup: NetworkSetup{
+				IP:      net.IPv4(192, 168, 1, 50),
+				Mask:    net.IPv4(255, 255, 255, 0),
+				Gateway: net.IPv4(192, 168, 1, 1),
+			},
+		},
+		{
+			name: "dhcp",
+			setup: NetworkSetup{
+				IP:      net.IPv4(0, 0, 0, 0),
+				Mask:    net.IPv4(0, 0, 0, 0),
+				Gateway: net.IPv4(0, 0, 0, 0),
+				DHCP:    true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := DecodeFrame(BuildSetNetworkSetupRequest(tt.setup))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if frame.Command != GW_SET_NETWORK_SETUP_REQ || len(frame.Data) != 13 {
+				t.Fatalf("command %v with %d bytes, want GW_SET_NETWORK_SETUP_REQ with 13 bytes", frame.Command, len(frame.Data))
+			}
+
+			// GW_GET_NETWORK_SETUP_CFM uses the same layout
+			got, err := ParseNetworkSetupConfirm(frame.Data)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !got.IP.Equal(tt.setup.IP) || !got.Mask.Equal(tt.setup.Mask) ||
+				!got.Gateway.Equal(tt.setup.Gateway) || got.DHCP != tt.setup.DHCP {
+				t.Errorf("got %+v, want %+v", got, tt.setup)
+			}
+		})
+	}
+}
+
+func TestParseNetworkSetupConfirmTooShort(t *testing.T) {
+	if _, err := ParseNetworkSetupConfirm(make([]byte, 12)); !errors.Is(err, ErrFrameTooShort) {
+		t.Errorf("error = %v, want %v", err, ErrFrameTooShort)
+	}
+}
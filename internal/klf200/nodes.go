@@ -1,6 +1,7 @@
 package klf200
 
 import (
+	"sort"
 	"sync"
 	"time"
 )
@@ -23,8 +24,18 @@ func (m *NodeManager) SetNodes(nodes []*Node) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	previous := m.nodes
 	m.nodes = make(map[uint8]*Node)
 	for _, node := range nodes {
+		// Node information does not include limitations and locks, keep the known state
+		if old, ok := previous[node.ID]; ok {
+			if node.Limitation == nil {
+				node.Limitation = old.Limitation
+			}
+			if node.Lock == nil {
+				node.Lock = old.Lock
+			}
+		}
 		m.nodes[node.ID] = node
 	}
 }
@@ -57,19 +68,23 @@ func (m *NodeManager) GetAllNodes() []*Node {
 	return nodes
 }
 
-// UpdateNode updates a node's position
+// UpdateNode updates a node's position and state.
+// Special position values (e.g. PositionIgnore of run status updates) keep the known position.
 func (m *NodeManager) UpdateNode(update *Node) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if node, ok := m.nodes[update.ID]; ok {
-		node.CurrentPosition = update.CurrentPosition
-		node.PositionPercent = update.PositionPercent
-		if update.TargetPosition != 0 {
+		if update.CurrentPosition <= PositionMax {
+			node.CurrentPosition = update.CurrentPosition
+			node.PositionPercent = update.PositionPercent
+		}
+		if update.TargetPosition != 0 && update.TargetPosition <= PositionMax {
 			node.TargetPosition = update.TargetPosition
 			node.TargetPercent = update.TargetPercent
 		}
 		node.State = update.State
+		node.RemainingTime = update.RemainingTime
 		if update.StateStr != "" {
 			node.StateStr = update.StateStr
 		}
@@ -77,6 +92,150 @@ func (m *NodeManager) UpdateNode(update *Node) {
 	}
 }
 
+// UpdateFunctionalParams stores the raw functional parameter values (parameter ID 1-4) of a node.
+// Returns false if the node is unknown.
+func (m *NodeManager) UpdateFunctionalParams(id uint8, params map[uint8]uint16) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, ok := m.nodes[id]
+	if !ok {
+		return false
+	}
+	for fp, value := range params {
+		if fp >= 1 && int(fp) <= len(node.FunctionalParams) {
+			node.FunctionalParams[fp-1] = value
+		}
+	}
+	return true
+}
+
+// SetNode adds or replaces a single node, keeping its known limitation and lock state
+func (m *NodeManager) SetNode(node *Node) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.nodes[node.ID]; ok {
+		if node.Limitation == nil {
+			node.Limitation = old.Limitation
+		}
+		if node.Lock == nil {
+			node.Lock = old.Lock
+		}
+	}
+	m.nodes[node.ID] = node
+}
+
+// RemoveNode removes a node. Returns false if the node was unknown.
+func (m *NodeManager) RemoveNode(id uint8) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.nodes[id]; !ok {
+		return false
+	}
+	delete(m.nodes, id)
+	return true
+}
+
+// UpdateLimitation stores the limitation status of a node.
+// Ignored min/max values and limitation time keep the previously known values.
+// Returns false if the node is unknown.
+func (m *NodeManager) UpdateLimitation(status *LimitationStatus) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, ok := m.nodes[status.NodeID]
+	if !ok {
+		return false
+	}
+
+	now := time.Now()
+	limitation := &NodeLimitation{
+		MinValue: PositionMin,
+		MaxValue: PositionMax,
+	}
+	if node.Limitation != nil {
+		*limitation = *node.Limitation
+	}
+
+	limitation.Origin = status.LimitationOrigin
+	limitation.OriginStr = status.LimitationOrigin.String()
+	limitation.LastUpdate = now
+	if status.MinValue <= PositionMax {
+		limitation.MinValue = status.MinValue
+	}
+	if status.MaxValue <= PositionMax {
+		limitation.MaxValue = status.MaxValue
+	}
+	limitation.MinPercent = PositionToPercent(limitation.MinValue)
+	limitation.MaxPercent = PositionToPercent(limitation.MaxValue)
+
+	if status.LimitationTime != LimitationTimeKeep {
+		limitation.Expires = nil
+		if d, ok := LimitationTimeDuration(status.LimitationTime); ok {
+			expires := now.Add(d)
+			limitation.Expires = &expires
+		}
+	}
+
+	node.Limitation = limitation
+	return true
+}
+
+// SetLock stores the priority level lock of a node (nil = released).
+// Returns false if the node is unknown.
+func (m *NodeManager) SetLock(id uint8, lock *NodeLock) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, ok := m.nodes[id]
+	if !ok {
+		return false
+	}
+	node.Lock = lock
+	return true
+}
+
+// SensorStatus derives the house-wide sensor status from the active node limitations.
+// Rain or wind is detected while at least one node is limited by it.
+func (m *NodeManager) SensorStatus() SensorStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	status := SensorStatus{}
+	for _, node := range m.nodes {
+		l := node.Limitation
+		if l == nil {
+			continue
+		}
+		if l.LastUpdate.After(status.LastUpdate) {
+			status.LastUpdate = l.LastUpdate
+		}
+		if !l.Active(now) {
+			continue
+		}
+
+		switch l.Origin {
+		case LimitationTypeRain:
+			status.RainDetected = true
+		case LimitationTypeWind:
+			status.WindDetected = true
+		}
+
+		if status.Limitations == nil {
+			status.Limitations = make(map[string][]uint8)
+		}
+		status.Limitations[l.OriginStr] = append(status.Limitations[l.OriginStr], node.ID)
+	}
+
+	for _, ids := range status.Limitations {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+	return status
+}
+
 // NodeCount returns the number of nodes
 func (m *NodeManager) NodeCount() int {
 	m.mu.RLock()
@@ -0,0 +1,90 @@
+package klf200
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNodeManagerSensorStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		limitations []LimitationStatus
+		wantRain    bool
+		wantWind    bool
+		wantNodes   map[string][]uint8
+	}{
+		{
+			name: "no limitations",
+		},
+		{
+			name: "rain on two nodes",
+			limitations: []LimitationStatus{
+				{NodeID: 2, MinValue: PositionIgnore, MaxValue: 0, LimitationOrigin: LimitationTypeRain, LimitationTime: 253},
+				{NodeID: 1, MinValue: PositionIgnore, MaxValue: 0, LimitationOrigin: LimitationTypeRain, LimitationTime: 253},
+			},
+			wantRain:  true,
+			wantNodes: map[string][]uint8{"Rain": {1, 2}},
+		},
+		{
+			name: "wind and frost",
+			limitations: []LimitationStatus{
+				{NodeID: 1, MinValue: PositionIgnore, MaxValue: PositionIgnore, LimitationOrigin: LimitationTypeWind, LimitationTime: 10},
+				{NodeID: 3, MinValue: PositionIgnore, MaxValue: PositionIgnore, LimitationOrigin: LimitationTypeFrost, LimitationTime: 253},
+			},
+			wantWind:  true,
+			wantNodes: map[string][]uint8{"Wind": {1}, "Frost": {3}},
+		},
+		{
+			name: "unknown node is ignored",
+			limitations: []LimitationStatus{
+				{NodeID: 9, MinValue: PositionIgnore, MaxValue: 0, LimitationOrigin: LimitationTypeRain, LimitationTime: 253},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewNodeManager()
+			m.SetNodes([]*Node{{ID: 1}, {ID: 2}, {ID: 3}})
+			for i := range tt.limitations {
+				m.UpdateLimitation(&tt.limitations[i])
+			}
+
+			status := m.SensorStatus()
+			if status.RainDetected != tt.wantRain || status.WindDetected != tt.wantWind {
+				t.Errorf("rain/wind = %v/%v, want %v/%v", status.RainDetected, status.WindDetected, tt.wantRain, tt.wantWind)
+			}
+			if !reflect.DeepEqual(status.Limitations, tt.wantNodes) {
+				t.Errorf("limitations = %v, want %v", status.Limitations, tt.wantNodes)
+			}
+		})
+	}
+}
+
+func TestNodeManagerSetAndRemoveNode(t *testing.T) {
+	m := NewNodeManager()
+	m.SetNodes([]*Node{{ID: 1, Name: "Kitchen"}})
+	m.UpdateLimitation(&LimitationStatus{NodeID: 1, MinValue: PositionIgnore, MaxValue: 0, LimitationOrigin: LimitationTypeRain, LimitationTime: 253})
+
+	// A re-read node keeps the limitation known from notifications
+	m.SetNode(&Node{ID: 1, Name: "Dining"})
+	node, ok := m.GetNode(1)
+	if !ok || node.Name != "Dining" || node.Limitation == nil {
+		t.Fatalf("node = %+v, want the new name and the kept limitation", node)
+	}
+
+	m.SetNode(&Node{ID: 2})
+	if m.NodeCount() != 2 {
+		t.Errorf("node count = %d, want 2", m.NodeCount())
+	}
+
+	if !m.RemoveNode(2) {
+		t.Error("RemoveNode(2) = false, want true")
+	}
+	if m.RemoveNode(2) {
+		t.Error("removing an unknown node = true, want false")
+	}
+	if _, ok := m.GetNode(2); ok {
+		t.Error("removed node still known")
+	}
+}
@@ -2,6 +2,7 @@ package klf200
 
 import (
 	"fmt"
+	"net"
 	"time"
 )
 
@@ -16,6 +17,26 @@ const (
 	GW_PASSWORD_ENTER_REQ CommandID = 0x3000
 	GW_PASSWORD_ENTER_CFM CommandID = 0x3001
 
+	// Password change
+	GW_PASSWORD_CHANGE_REQ CommandID = 0x3002
+	GW_PASSWORD_CHANGE_CFM CommandID = 0x3003
+	GW_PASSWORD_CHANGE_NTF CommandID = 0x3004
+
+	// Configuration service (pairing)
+	GW_CS_GET_SYSTEMTABLE_DATA_REQ   CommandID = 0x0100
+	GW_CS_GET_SYSTEMTABLE_DATA_CFM   CommandID = 0x0101
+	GW_CS_GET_SYSTEMTABLE_DATA_NTF   CommandID = 0x0102
+	GW_CS_DISCOVER_NODES_REQ         CommandID = 0x0103
+	GW_CS_DISCOVER_NODES_CFM         CommandID = 0x0104
+	GW_CS_DISCOVER_NODES_NTF         CommandID = 0x0105
+	GW_CS_REMOVE_NODES_REQ           CommandID = 0x0106
+	GW_CS_REMOVE_NODES_CFM           CommandID = 0x0107
+	GW_CS_CONTROLLER_COPY_REQ        CommandID = 0x0109
+	GW_CS_CONTROLLER_COPY_CFM        CommandID = 0x010A
+	GW_CS_CONTROLLER_COPY_NTF        CommandID = 0x010B
+	GW_CS_CONTROLLER_COPY_CANCEL_NTF CommandID = 0x010C
+	GW_CS_SYSTEM_TABLE_UPDATE_NTF    CommandID = 0x0112
+
 	// Single node information
 	GW_GET_NODE_INFORMATION_REQ CommandID = 0x0200
 	GW_GET_NODE_INFORMATION_CFM CommandID = 0x0201
@@ -26,6 +47,15 @@ const (
 	GW_GET_ALL_NODES_INFORMATION_NTF         CommandID = 0x0204
 	GW_GET_ALL_NODES_INFORMATION_FINISHED_NTF CommandID = 0x0205
 
+	// Node configuration
+	GW_SET_NODE_NAME_REQ                CommandID = 0x0208
+	GW_SET_NODE_NAME_CFM                CommandID = 0x0209
+	GW_SET_NODE_VELOCITY_REQ            CommandID = 0x020A
+	GW_SET_NODE_VELOCITY_CFM            CommandID = 0x020B
+	GW_NODE_INFORMATION_CHANGED_NTF     CommandID = 0x020C
+	GW_SET_NODE_ORDER_AND_PLACEMENT_REQ CommandID = 0x020D
+	GW_SET_NODE_ORDER_AND_PLACEMENT_CFM CommandID = 0x020E
+
 	// Node information notification
 	GW_GET_NODE_INFORMATION_NTF CommandID = 0x0210
 
@@ -38,6 +68,11 @@ const (
 	// Session
 	GW_SESSION_FINISHED_NTF CommandID = 0x0304
 
+	// Wink (identify a device)
+	GW_WINK_SEND_REQ CommandID = 0x0308
+	GW_WINK_SEND_CFM CommandID = 0x0309
+	GW_WINK_SEND_NTF CommandID = 0x030A
+
 	// Status
 	GW_STATUS_REQUEST_REQ CommandID = 0x0305
 	GW_STATUS_REQUEST_CFM CommandID = 0x0306
@@ -46,6 +81,62 @@ const (
 	// State changes
 	GW_NODE_STATE_POSITION_CHANGED_NTF CommandID = 0x0211
 
+	// Gateway information
+	GW_GET_VERSION_REQ          CommandID = 0x0008
+	GW_GET_VERSION_CFM          CommandID = 0x0009
+	GW_GET_PROTOCOL_VERSION_REQ CommandID = 0x000A
+	GW_GET_PROTOCOL_VERSION_CFM CommandID = 0x000B
+	GW_GET_STATE_REQ            CommandID = 0x000C
+	GW_GET_STATE_CFM            CommandID = 0x000D
+
+	// Scenes
+	GW_INITIALIZE_SCENE_REQ          CommandID = 0x0400
+	GW_INITIALIZE_SCENE_CFM          CommandID = 0x0401
+	GW_INITIALIZE_SCENE_NTF          CommandID = 0x0402
+	GW_INITIALIZE_SCENE_CANCEL_REQ   CommandID = 0x0403
+	GW_INITIALIZE_SCENE_CANCEL_CFM   CommandID = 0x0404
+	GW_RECORD_SCENE_REQ              CommandID = 0x0405
+	GW_RECORD_SCENE_CFM              CommandID = 0x0406
+	GW_RECORD_SCENE_NTF              CommandID = 0x0407
+	GW_DELETE_SCENE_REQ              CommandID = 0x0408
+	GW_DELETE_SCENE_CFM              CommandID = 0x0409
+	GW_RENAME_SCENE_REQ              CommandID = 0x040A
+	GW_RENAME_SCENE_CFM              CommandID = 0x040B
+	GW_GET_SCENE_LIST_REQ            CommandID = 0x040C
+	GW_GET_SCENE_LIST_CFM            CommandID = 0x040D
+	GW_GET_SCENE_LIST_NTF            CommandID = 0x040E
+	GW_SCENE_INFORMATION_CHANGED_NTF CommandID = 0x0419
+
+	// Contact inputs
+	GW_GET_CONTACT_INPUT_LINK_LIST_REQ CommandID = 0x0460
+	GW_GET_CONTACT_INPUT_LINK_LIST_CFM CommandID = 0x0461
+	GW_SET_CONTACT_INPUT_LINK_REQ      CommandID = 0x0462
+	GW_SET_CONTACT_INPUT_LINK_CFM      CommandID = 0x0463
+	GW_REMOVE_CONTACT_INPUT_LINK_REQ   CommandID = 0x0464
+	GW_REMOVE_CONTACT_INPUT_LINK_CFM   CommandID = 0x0465
+
+	// Activation log
+	GW_GET_ACTIVATION_LOG_HEADER_REQ         CommandID = 0x0500
+	GW_GET_ACTIVATION_LOG_HEADER_CFM         CommandID = 0x0501
+	GW_GET_ACTIVATION_LOG_LINE_REQ           CommandID = 0x0504
+	GW_GET_ACTIVATION_LOG_LINE_CFM           CommandID = 0x0505
+	GW_ACTIVATION_LOG_UPDATED_NTF            CommandID = 0x0506
+	GW_GET_MULTIPLE_ACTIVATION_LOG_LINES_REQ CommandID = 0x0507
+	GW_GET_MULTIPLE_ACTIVATION_LOG_LINES_NTF CommandID = 0x0508
+	GW_GET_MULTIPLE_ACTIVATION_LOG_LINES_CFM CommandID = 0x0509
+
+	// Clock
+	GW_SET_UTC_REQ           CommandID = 0x2000
+	GW_SET_UTC_CFM           CommandID = 0x2001
+	GW_RTC_SET_TIME_ZONE_REQ CommandID = 0x2002
+	GW_RTC_SET_TIME_ZONE_CFM CommandID = 0x2003
+
+	// Network setup
+	GW_GET_NETWORK_SETUP_REQ CommandID = 0x00E0
+	GW_GET_NETWORK_SETUP_CFM CommandID = 0x00E1
+	GW_SET_NETWORK_SETUP_REQ CommandID = 0x00E2
+	GW_SET_NETWORK_SETUP_CFM CommandID = 0x00E3
+
 	// Reboot
 	GW_REBOOT_REQ CommandID = 0x0001
 	GW_REBOOT_CFM CommandID = 0x0002
@@ -60,6 +151,10 @@ const (
 	GW_GET_LIMITATION_STATUS_REQ CommandID = 0x0250
 	GW_GET_LIMITATION_STATUS_CFM CommandID = 0x0251
 	GW_LIMITATION_STATUS_NTF     CommandID = 0x0252
+
+	// Set limitation
+	GW_SET_LIMITATION_REQ CommandID = 0x0310
+	GW_SET_LIMITATION_CFM CommandID = 0x0311
 )
 
 // NodeType represents the type of Velux device
@@ -174,6 +269,62 @@ const (
 	RunStatusExecutionActive    RunStatus = 2
 )
 
+func (r RunStatus) String() string {
+	switch r {
+	case RunStatusExecutionCompleted:
+		return "Completed"
+	case RunStatusExecutionFailed:
+		return "Failed"
+	case RunStatusExecutionActive:
+		return "Active"
+	default:
+		return "Unknown"
+	}
+}
+
+// NodeState returns the node state corresponding to a run status
+func (r RunStatus) NodeState() NodeState {
+	switch r {
+	case RunStatusExecutionCompleted:
+		return NodeStateDone
+	case RunStatusExecutionFailed:
+		return NodeStateErrorWhileExecution
+	case RunStatusExecutionActive:
+		return NodeStateExecuting
+	default:
+		return NodeStateNonExecuting
+	}
+}
+
+// StatusType selects the information returned by GW_STATUS_REQUEST_REQ
+type StatusType uint8
+
+const (
+	StatusTypeTargetPosition  StatusType = 0
+	StatusTypeCurrentPosition StatusType = 1
+	StatusTypeRemainingTime   StatusType = 2
+	StatusTypeMainInfo        StatusType = 3
+)
+
+// NodeStatus is the answer of a status request for a single node (GW_STATUS_REQUEST_NTF)
+type NodeStatus struct {
+	SessionID   uint16      `json:"session_id"`
+	NodeID      uint8       `json:"node_id"`
+	RunStatus   RunStatus   `json:"run_status"`
+	StatusReply StatusReply `json:"status_reply"`
+	StatusType  StatusType  `json:"status_type"`
+
+	// Status types target position, current position and remaining time: value per parameter ID (0 = main parameter)
+	Parameters map[uint8]uint16 `json:"parameters,omitempty"`
+
+	// Status type main info
+	TargetPosition             uint16     `json:"target_position"`
+	CurrentPosition            uint16     `json:"current_position"`
+	RemainingTime              uint16     `json:"remaining_time"` // Seconds
+	LastMasterExecutionAddress uint32     `json:"last_master_execution_address"`
+	LastCommandOriginator      Originator `json:"last_command_originator"`
+}
+
 // StatusReply represents the status reply type
 type StatusReply uint8
 
@@ -234,6 +385,265 @@ const (
 	VelocityNotUsed   Velocity = 255
 )
 
+// GatewayVersion is the firmware and hardware version of the KLF-200
+type GatewayVersion struct {
+	Software     string `json:"software"` // e.g. 0.2.0.0.71.0
+	Hardware     uint8  `json:"hardware"`
+	ProductGroup uint8  `json:"product_group"`
+	ProductType  uint8  `json:"product_type"`
+}
+
+// ProtocolVersion is the version of the KLF-200 API
+type ProtocolVersion struct {
+	Major uint16 `json:"major"`
+	Minor uint16 `json:"minor"`
+}
+
+func (v ProtocolVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// GatewayState is the operating state of the KLF-200
+type GatewayState struct {
+	State       uint8  `json:"state"`
+	StateStr    string `json:"state_str"`
+	SubState    uint8  `json:"sub_state"`
+	SubStateStr string `json:"sub_state_str"`
+}
+
+// gatewayStateName returns the name of a GW_GET_STATE gateway state
+func gatewayStateName(state uint8) string {
+	switch state {
+	case 0:
+		return "Test Mode"
+	case 1:
+		return "Gateway Mode, No Actuator"
+	case 2:
+		return "Gateway Mode, With Actuator"
+	case 3:
+		return "Beacon Mode, Not Configured"
+	case 4:
+		return "Beacon Mode, Configured"
+	default:
+		return "Unknown"
+	}
+}
+
+// gatewaySubStateName returns the name of a GW_GET_STATE sub state
+func gatewaySubStateName(subState uint8) string {
+	switch subState {
+	case 0x00:
+		return "Idle"
+	case 0x01:
+		return "Configuration Service"
+	case 0x02:
+		return "Scene Configuration"
+	case 0x03:
+		return "Information Service Configuration"
+	case 0x04:
+		return "Contact Input Configuration"
+	case 0x80:
+		return "Command Handler"
+	case 0x81:
+		return "Activate Group Handler"
+	case 0x82:
+		return "Activate Scene Handler"
+	default:
+		return "Unknown"
+	}
+}
+
+// ActivationLogLine is an entry of the KLF-200 activation log
+type ActivationLogLine struct {
+	Time            time.Time   `json:"time"`
+	SessionID       uint16      `json:"session_id"`
+	StatusOwner     Originator  `json:"status_owner"`
+	Originator      Originator  `json:"originator"`
+	OriginatorStr   string      `json:"originator_str"`
+	NodeID          uint8       `json:"node_id"`
+	NodeParameter   uint8       `json:"node_parameter"`
+	RunStatus       RunStatus   `json:"run_status"`
+	StatusReply     StatusReply `json:"status_reply"`
+	InformationCode uint32      `json:"information_code"`
+}
+
+// MaxPasswordLength is the maximum length of the KLF-200 password in bytes
+const MaxPasswordLength = 32
+
+// NetworkSetup is the LAN configuration of the KLF-200
+type NetworkSetup struct {
+	IP      net.IP `json:"ip"`
+	Mask    net.IP `json:"mask"`
+	Gateway net.IP `json:"gateway"`
+	DHCP    bool   `json:"dhcp"`
+}
+
+// MaxTimeZoneLength is the maximum length of a KLF-200 time zone string
+const MaxTimeZoneLength = 63
+
+// NodeBitArraySize is the size of the node bit arrays of the configuration service (one bit per node index)
+const NodeBitArraySize = 26
+
+// SystemTableEntry is an actuator in the system table of the KLF-200
+type SystemTableEntry struct {
+	Index          uint8     `json:"index"` // Node ID
+	Address        uint32    `json:"address"`
+	NodeType       NodeType  `json:"node_type"`
+	NodeTypeStr    string    `json:"node_type_str"`
+	PowerMode      PowerMode `json:"power_mode"`
+	IOMembership   bool      `json:"io_membership"`
+	RFSupport      bool      `json:"rf_support"`
+	TurnaroundTime uint8     `json:"turnaround_time"` // 0 = 5ms, 1 = 10ms, 2 = 20ms, 3 = 40ms
+	Manufacturer   uint8     `json:"manufacturer"`
+	BackboneRef    uint32    `json:"backbone_reference"`
+}
+
+// DiscoverStatus is the result of a node discovery
+type DiscoverStatus uint8
+
+const (
+	DiscoverStatusOK        DiscoverStatus = 0
+	DiscoverStatusFailed    DiscoverStatus = 5 // Configuration service not ready
+	DiscoverStatusPartialOK DiscoverStatus = 6
+	DiscoverStatusBusy      DiscoverStatus = 7
+)
+
+func (s DiscoverStatus) String() string {
+	switch s {
+	case DiscoverStatusOK:
+		return "OK"
+	case DiscoverStatusFailed:
+		return "Failed"
+	case DiscoverStatusPartialOK:
+		return "Partial OK"
+	case DiscoverStatusBusy:
+		return "Busy"
+	default:
+		return "Unknown"
+	}
+}
+
+// DiscoveryResult is the outcome of GW_CS_DISCOVER_NODES
+type DiscoveryResult struct {
+	Added      []uint8        `json:"added"`
+	RFError    []uint8        `json:"rf_error"`     // Nodes that could not be reached
+	IOKeyError []uint8        `json:"io_key_error"` // Existing nodes with a different system key
+	Removed    []uint8        `json:"removed"`
+	Open       []uint8        `json:"open"` // Nodes not yet bound to a system key
+	Status     DiscoverStatus `json:"status"`
+	StatusStr  string         `json:"status_str"`
+}
+
+// ControllerCopyMode selects the role of the KLF-200 when copying the system key between controllers
+type ControllerCopyMode uint8
+
+const (
+	ControllerCopyTransmit ControllerCopyMode = 0 // KLF-200 sends its configuration
+	ControllerCopyReceive  ControllerCopyMode = 1 // KLF-200 receives the configuration of another controller
+)
+
+// ControllerCopyResult is the outcome of GW_CS_CONTROLLER_COPY
+type ControllerCopyResult struct {
+	Mode   ControllerCopyMode `json:"mode"`
+	Status uint8              `json:"status"` // 0 = OK, 1 = failed, 2 = cancelled, 4 = timeout, 11 = not ready
+	OK     bool               `json:"ok"`
+}
+
+// SystemTableUpdate lists the nodes added to or removed from the system table
+type SystemTableUpdate struct {
+	Added   []uint8 `json:"added"`
+	Removed []uint8 `json:"removed"`
+}
+
+// MaxSceneNameLength is the maximum scene name length in bytes (64 bytes incl. terminating zero)
+const MaxSceneNameLength = 63
+
+// Scene is a scene stored in the KLF-200
+type Scene struct {
+	ID   uint8  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Scene initialization status (GW_INITIALIZE_SCENE_NTF)
+const (
+	SceneInitOK       uint8 = 0
+	SceneInitPartlyOK uint8 = 1
+	SceneInitError    uint8 = 2
+)
+
+// SceneInitResult is the result of a scene initialization. The KLF-200 reads the
+// current state of all nodes; nodes that did not answer are listed in FailedNodes.
+type SceneInitResult struct {
+	Status      uint8   `json:"status"`
+	FailedNodes []uint8 `json:"failed_nodes,omitempty"`
+}
+
+// SceneRecordResult is the result of recording a scene
+type SceneRecordResult struct {
+	OK      bool  `json:"ok"`
+	SceneID uint8 `json:"scene_id"`
+}
+
+// SceneChange is reported when a scene was deleted or modified
+type SceneChange struct {
+	SceneID uint8 `json:"scene_id"`
+	Deleted bool  `json:"deleted"`
+}
+
+// Contact inputs and outputs of the KLF-200 are numbered 1-5
+const (
+	MinContactInputID = 1
+	MaxContactInputID = 5
+)
+
+// ContactInputAssignment is what a contact input triggers
+type ContactInputAssignment uint8
+
+const (
+	ContactInputNotAssigned ContactInputAssignment = 0
+	ContactInputScene       ContactInputAssignment = 1 // ActionID is a scene ID
+	ContactInputNode        ContactInputAssignment = 2 // ActionID is a node ID
+)
+
+// String returns the name of the assignment
+func (a ContactInputAssignment) String() string {
+	switch a {
+	case ContactInputNotAssigned:
+		return "none"
+	case ContactInputScene:
+		return "scene"
+	case ContactInputNode:
+		return "node"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(a))
+	}
+}
+
+// ContactInputLink is the action linked to a contact input of the KLF-200
+type ContactInputLink struct {
+	InputID         uint8                  `json:"input_id"`
+	Assignment      ContactInputAssignment `json:"assignment"`
+	AssignmentStr   string                 `json:"assignment_str"`
+	ActionID        uint8                  `json:"action_id"` // Scene or node ID
+	Priority        Priority               `json:"priority"`
+	Position        uint16                 `json:"position_raw"` // Node target, ignored for scenes
+	PositionPercent float64                `json:"position_percent"`
+	Velocity        Velocity               `json:"velocity"`
+	SuccessOutputID uint8                  `json:"success_output_id"` // Output closed on success, 0 = none
+	ErrorOutputID   uint8                  `json:"error_output_id"`   // Output closed on error, 0 = none
+}
+
+// Wink time values (1-253 are seconds)
+const (
+	WinkTimeStop                uint8 = 0
+	WinkTimeMaxSeconds          uint8 = 253
+	WinkTimeManufacturerDefault uint8 = 254
+	WinkTimeForever             uint8 = 255
+)
+
+// MaxNodeNameLength is the maximum length of a node name in bytes (UTF-8)
+const MaxNodeNameLength = 63
+
 // Priority level for commands
 type Priority uint8
 
@@ -248,6 +658,149 @@ const (
 	PriorityComfortLevel4         Priority = 7
 )
 
+// Originator identifies the source of a command or limitation
+type Originator uint8
+
+const (
+	OriginatorUser              Originator = 1
+	OriginatorRain              Originator = 2
+	OriginatorTimer             Originator = 3
+	OriginatorUPS               Originator = 5
+	OriginatorSAAC              Originator = 8
+	OriginatorWind              Originator = 9
+	OriginatorLoadShedding      Originator = 11
+	OriginatorLocalLight        Originator = 12
+	OriginatorEnvironmentSensor Originator = 13
+	OriginatorEmergency         Originator = 255
+)
+
+func (o Originator) String() string {
+	switch o {
+	case OriginatorUser:
+		return "User"
+	case OriginatorRain:
+		return "Rain"
+	case OriginatorTimer:
+		return "Timer"
+	case OriginatorUPS:
+		return "UPS"
+	case OriginatorSAAC:
+		return "SAAC"
+	case OriginatorWind:
+		return "Wind"
+	case OriginatorLoadShedding:
+		return "Load Shedding"
+	case OriginatorLocalLight:
+		return "Local Light"
+	case OriginatorEnvironmentSensor:
+		return "Environment Sensor"
+	case OriginatorEmergency:
+		return "Emergency"
+	default:
+		return fmt.Sprintf("Unknown (%d)", uint8(o))
+	}
+}
+
+// Special limitation time values (0-252 = (value+1) * 30 seconds)
+const (
+	LimitationTimeUnlimited uint8 = 253 // Limitation stays until cleared
+	LimitationTimeClearAll  uint8 = 254 // Clear all limitations set by the originator
+	LimitationTimeKeep      uint8 = 255 // Keep the current limitation time
+)
+
+// MaxLimitationDuration is the longest limitation time that can be encoded (except unlimited)
+const MaxLimitationDuration = 253 * 30 * time.Second
+
+// LimitationTimeFromDuration encodes a limitation duration (0 = unlimited), rounded up to 30 seconds
+func LimitationTimeFromDuration(d time.Duration) (uint8, error) {
+	if d <= 0 {
+		return LimitationTimeUnlimited, nil
+	}
+	if d > MaxLimitationDuration {
+		return 0, fmt.Errorf("limitation time must not exceed %s (or be unlimited)", MaxLimitationDuration)
+	}
+	steps := (d + 30*time.Second - 1) / (30 * time.Second)
+	return uint8(steps - 1), nil
+}
+
+// LockTimeUnlimited keeps a priority level lock until it is released (0-254 = (value+1) * 30 seconds)
+const LockTimeUnlimited uint8 = 255
+
+// MaxLockDuration is the longest lock time that can be encoded (except unlimited)
+const MaxLockDuration = 255 * 30 * time.Second
+
+// LockTimeFromDuration encodes a lock duration (0 = unlimited), rounded up to 30 seconds
+func LockTimeFromDuration(d time.Duration) (uint8, error) {
+	if d <= 0 {
+		return LockTimeUnlimited, nil
+	}
+	if d > MaxLockDuration {
+		return 0, fmt.Errorf("lock time must not exceed %s (or be unlimited)", MaxLockDuration)
+	}
+	steps := (d + 30*time.Second - 1) / (30 * time.Second)
+	return uint8(steps - 1), nil
+}
+
+// LockTimeDuration decodes a lock time. Returns false for unlimited.
+func LockTimeDuration(t uint8) (time.Duration, bool) {
+	if t == LockTimeUnlimited {
+		return 0, false
+	}
+	return time.Duration(t+1) * 30 * time.Second, true
+}
+
+// LockMode is the lock setting of one priority level in the priority level information (PLI)
+type LockMode uint8
+
+const (
+	LockModeUnlock  LockMode = 0 // Release the lock of the level
+	LockModeLock    LockMode = 1 // Lock the level for other originators
+	LockModeLockAll LockMode = 2 // Lock the level for all originators
+	LockModeKeep    LockMode = 3 // Keep the current lock of the level
+)
+
+// PriorityLevelLock is the priority level lock sent with GW_COMMAND_SEND_REQ
+type PriorityLevelLock struct {
+	Levels   [8]LockMode // Lock mode per priority level 0-7
+	LockTime uint8       // See LockTimeFromDuration
+}
+
+// NewPriorityLevelLock returns a lock applying mode to the priority levels from..7
+// (the lower priorities) and keeping the lock of the higher levels
+func NewPriorityLevelLock(from Priority, mode LockMode, lockTime uint8) PriorityLevelLock {
+	lock := PriorityLevelLock{LockTime: lockTime}
+	for i := range lock.Levels {
+		lock.Levels[i] = LockModeKeep
+		if Priority(i) >= from {
+			lock.Levels[i] = mode
+		}
+	}
+	return lock
+}
+
+// Info encodes the levels as priority level information (2 bits per level, level 0 first)
+func (l PriorityLevelLock) Info() uint16 {
+	var info uint16
+	for i, mode := range l.Levels {
+		info |= uint16(mode&0x03) << (14 - 2*i)
+	}
+	return info
+}
+
+// NodeLock is a priority level lock set on a node by the gateway.
+// The KLF-200 does not report locks, so only locks set through the gateway are known.
+type NodeLock struct {
+	Priority   Priority   `json:"priority"`    // Priority the lock was set with
+	LockedFrom Priority   `json:"locked_from"` // Levels from this one down to comfort level 4 are locked
+	Since      time.Time  `json:"since"`
+	Expires    *time.Time `json:"expires,omitempty"` // nil = until released
+}
+
+// Active returns true if the lock has not expired
+func (l *NodeLock) Active(now time.Time) bool {
+	return l != nil && (l.Expires == nil || now.Before(*l.Expires))
+}
+
 // Special position values
 const (
 	PositionMin     uint16 = 0x0000 // Fully open
@@ -257,6 +810,22 @@ const (
 	PositionIgnore  uint16 = 0xD400 // Ignore this parameter
 )
 
+// MaxCommandNodes is the maximum number of nodes addressed by one command
+const MaxCommandNodes = 20
+
+// TiltParameter is the functional parameter (FP3) holding the slat orientation of venetian and louver blinds
+const TiltParameter = 3
+
+// SupportsTilt returns true for node types with adjustable slats
+func (t NodeType) SupportsTilt() bool {
+	switch t &^ 0x3F { // Ignore the subtype
+	case NodeTypeInteriorVenetianBlind, NodeTypeExteriorVenetianBlind, NodeTypeLouverBlind:
+		return true
+	default:
+		return false
+	}
+}
+
 // Node represents a Velux device
 type Node struct {
 	ID            uint8      `json:"id"`
@@ -272,6 +841,77 @@ type Node struct {
 	Velocity      Velocity   `json:"velocity"`
 	LastUpdate    time.Time  `json:"last_update"`
 	Inverted      bool       `json:"inverted"` // true for window openers (0%=closed, 100%=open)
+	RemainingTime uint16     `json:"remaining_time,omitempty"` // Seconds until the current movement completes
+	Limitation    *NodeLimitation `json:"limitation,omitempty"`
+	Lock          *NodeLock       `json:"lock,omitempty"` // Priority level lock set by the gateway
+
+	// Node information record
+	Order            uint16        `json:"order"`     // Order of the node in the KLF-200 product list
+	Placement        uint8         `json:"placement"` // Room/group index assigned in the KLF-200
+	ProductGroup     uint8         `json:"product_group"`
+	ProductType      uint8         `json:"product_type"`
+	Variation        NodeVariation `json:"variation"`
+	VariationStr     string        `json:"variation_str"`
+	PowerMode        PowerMode     `json:"power_mode"`
+	PowerModeStr     string        `json:"power_mode_str"`
+	BuildNumber      uint8         `json:"build_number"`
+	Serial           string        `json:"serial"` // Hex encoded 8-byte serial number
+	FunctionalParams [4]uint16     `json:"functional_params_raw"`
+	Timestamp        *time.Time    `json:"timestamp,omitempty"` // Time of the last state change reported by the node
+	Aliases          []NodeAlias   `json:"aliases,omitempty"`
+}
+
+// NodeVariation is the product variation of a node
+type NodeVariation uint8
+
+const (
+	NodeVariationNotSet   NodeVariation = 0
+	NodeVariationTopHung  NodeVariation = 1
+	NodeVariationKip      NodeVariation = 2
+	NodeVariationFlatRoof NodeVariation = 3
+	NodeVariationSkyLight NodeVariation = 4
+)
+
+func (v NodeVariation) String() string {
+	switch v {
+	case NodeVariationNotSet:
+		return "Not Set"
+	case NodeVariationTopHung:
+		return "Top Hung"
+	case NodeVariationKip:
+		return "Kip"
+	case NodeVariationFlatRoof:
+		return "Flat Roof"
+	case NodeVariationSkyLight:
+		return "Sky Light"
+	default:
+		return "Unknown"
+	}
+}
+
+// PowerMode tells whether a node is mains powered or runs on battery/solar
+type PowerMode uint8
+
+const (
+	PowerModeAlwaysAlive PowerMode = 0 // Mains powered, always reachable
+	PowerModeLowPower    PowerMode = 1 // Battery or solar powered, sleeps between commands
+)
+
+func (m PowerMode) String() string {
+	switch m {
+	case PowerModeAlwaysAlive:
+		return "Always Alive"
+	case PowerModeLowPower:
+		return "Low Power"
+	default:
+		return "Unknown"
+	}
+}
+
+// NodeAlias is an alias position of a node (e.g. the ventilation position of a window)
+type NodeAlias struct {
+	Type  uint16 `json:"type"`
+	Value uint16 `json:"value"`
 }
 
 // IsInvertedType returns true for node types where position semantics are inverted
@@ -365,7 +1005,35 @@ type LimitationStatus struct {
 	MinValue         uint16         `json:"min_value"`
 	MaxValue         uint16         `json:"max_value"`
 	LimitationOrigin LimitationType `json:"limitation_origin"`
-	LimitationTime   uint8          `json:"limitation_time"` // in seconds
+	LimitationTime   uint8          `json:"limitation_time"` // Encoded, see LimitationTimeDuration
+}
+
+// NodeLimitation is the limitation state of a single node
+type NodeLimitation struct {
+	Origin     LimitationType `json:"origin"`
+	OriginStr  string         `json:"origin_str"`
+	MinValue   uint16         `json:"min_value_raw"`
+	MaxValue   uint16         `json:"max_value_raw"`
+	MinPercent float64        `json:"min_percent"`
+	MaxPercent float64        `json:"max_percent"`
+	Expires    *time.Time     `json:"expires,omitempty"` // nil = until cleared
+	LastUpdate time.Time      `json:"last_update"`
+}
+
+// Active returns true if the limitation has an origin and has not expired
+func (l *NodeLimitation) Active(now time.Time) bool {
+	if l == nil || l.Origin == LimitationTypeNone {
+		return false
+	}
+	return l.Expires == nil || now.Before(*l.Expires)
+}
+
+// LimitationTimeDuration decodes a limitation time. Returns false for unlimited and special values.
+func LimitationTimeDuration(t uint8) (time.Duration, bool) {
+	if t > 252 {
+		return 0, false
+	}
+	return time.Duration(t+1) * 30 * time.Second, true
 }
 
 // SensorStatus represents the current sensor readings
@@ -373,4 +1041,7 @@ type SensorStatus struct {
 	RainDetected bool      `json:"rain_detected"`
 	WindDetected bool      `json:"wind_detected"`
 	LastUpdate   time.Time `json:"last_update"`
+
+	// Active limitations by origin (e.g. "Rain", "Frost") with the affected node IDs
+	Limitations map[string][]uint8 `json:"limitations,omitempty"`
 }
@@ -0,0 +1,119 @@
+package klf200
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimitationTimeDuration(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  uint8
+		want   time.Duration
+		wantOK bool
+	}{
+		{"shortest", 0, 30 * time.Second, true},
+		{"two minutes", 3, 2 * time.Minute, true},
+		{"longest", 252, 7590 * time.Second, true},
+		{"unlimited", 253, 0, false},
+		{"clear all", 254, 0, false},
+		{"keep", LimitationTimeKeep, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := LimitationTimeDuration(tt.value)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("LimitationTimeDuration(%d) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLimitationTimeFromDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       time.Duration
+		want    uint8
+		wantErr bool
+	}{
+		{name: "unlimited", d: 0, want: LimitationTimeUnlimited},
+		{name: "negative is unlimited", d: -time.Minute, want: LimitationTimeUnlimited},
+		{name: "30 seconds", d: 30 * time.Second, want: 0},
+		{name: "rounded up", d: 31 * time.Second, want: 1},
+		{name: "one hour", d: time.Hour, want: 119},
+		{name: "longest", d: MaxLimitationDuration, want: 252},
+		{name: "too long", d: MaxLimitationDuration + time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LimitationTimeFromDuration(tt.d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("LimitationTimeFromDuration(%v) = %d, want %d", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockTimeFromDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       time.Duration
+		want    uint8
+		wantErr bool
+	}{
+		{name: "unlimited", d: 0, want: LockTimeUnlimited},
+		{name: "30 seconds", d: 30 * time.Second, want: 0},
+		{name: "rounded up", d: 31 * time.Second, want: 1},
+		{name: "two hours", d: 2 * time.Hour, want: 239},
+		{name: "longest", d: MaxLockDuration, want: 254},
+		{name: "too long", d: MaxLockDuration + time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LockTimeFromDuration(tt.d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("LockTimeFromDuration(%v) = %d, want %d", tt.d, got, tt.want)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// Decoding gives the duration rounded up to 30 seconds
+			d, limited := LockTimeDuration(got)
+			if limited != (tt.d > 0) || (limited && (d < tt.d || d-tt.d >= 30*time.Second)) {
+				t.Errorf("LockTimeDuration(%d) = %v, %v, want about %v", got, d, limited, tt.d)
+			}
+		})
+	}
+}
+
+func TestPriorityLevelLockInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		lock PriorityLevelLock
+		want uint16
+	}{
+		{name: "keep all", lock: NewPriorityLevelLock(PriorityComfortLevel4+1, LockModeLock, 0), want: 0xFFFF},
+		{name: "lock comfort levels", lock: NewPriorityLevelLock(PriorityComfortLevel1, LockModeLock, 0), want: 0xFF55},
+		{name: "unlock from user level 1", lock: NewPriorityLevelLock(PriorityUserLevel1, LockModeUnlock, 0), want: 0xF000},
+		{name: "lock all levels for everyone", lock: NewPriorityLevelLock(PriorityHumanProtection, LockModeLockAll, 0), want: 0xAAAA},
+		{name: "level 0 first", lock: PriorityLevelLock{Levels: [8]LockMode{LockModeLock}}, want: 0x4000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.lock.Info(); got != tt.want {
+				t.Errorf("Info() = %#04x, want %#04x", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{name: "too few fields", expr: "0 7 * *"},
+		{name: "too many fields", expr: "0 7 * * * *"},
+		{name: "minute out of range", expr: "60 7 * * *"},
+		{name: "hour out of range", expr: "0 24 * * *"},
+		{name: "day of month zero", expr: "0 7 0 * *"},
+		{name: "reversed range", expr: "0 7 * * 5-1"},
+		{name: "invalid step", expr: "*/0 * * * *"},
+		{name: "invalid value", expr: "0 7 * foo *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCron(tt.expr); err == nil {
+				t.Errorf("ParseCron(%q) succeeded, want error", tt.expr)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Monday, 15 January 2024
+	from := time.Date(2024, time.January, 15, 10, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{name: "every minute", expr: "* * * * *", want: time.Date(2024, time.January, 15, 10, 31, 0, 0, time.UTC)},
+		{name: "later today", expr: "45 10 * * *", want: time.Date(2024, time.January, 15, 10, 45, 0, 0, time.UTC)},
+		{name: "tomorrow", expr: "0 7 * * *", want: time.Date(2024, time.January, 16, 7, 0, 0, 0, time.UTC)},
+		{name: "step", expr: "*/15 * * * *", want: time.Date(2024, time.January, 15, 10, 45, 0, 0, time.UTC)},
+		{name: "step from offset", expr: "5/20 * * * *", want: time.Date(2024, time.January, 15, 10, 45, 0, 0, time.UTC)},
+		{name: "list", expr: "0 6,12,18 * * *", want: time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)},
+		{name: "weekday names", expr: "0 8 * * sat,sun", want: time.Date(2024, time.January, 20, 8, 0, 0, 0, time.UTC)},
+		{name: "weekday range", expr: "0 8 * * mon-fri", want: time.Date(2024, time.January, 16, 8, 0, 0, 0, time.UTC)},
+		{name: "sunday as 7", expr: "0 8 * * 7", want: time.Date(2024, time.January, 21, 8, 0, 0, 0, time.UTC)},
+		{name: "month name", expr: "0 0 1 mar *", want: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "leap day", expr: "0 0 29 2 *", want: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "day of month or weekday", expr: "0 9 20 * fri", want: time.Date(2024, time.January, 19, 9, 0, 0, 0, time.UTC)},
+		{name: "never", expr: "0 0 30 2 *", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
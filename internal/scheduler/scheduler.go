@@ -0,0 +1,437 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+)
+
+// maxRunLogEntries is the number of schedule runs kept in memory
+const maxRunLogEntries = 200
+
+// maxWait caps the sleep between checks so clock adjustments are picked up
+const maxWait = time.Minute
+
+// trigger computes the next execution time of a schedule
+type trigger interface {
+	Next(t time.Time) time.Time
+}
+
+// Scheduler executes timed node actions through the gateway service
+type Scheduler struct {
+	gateway *gateway.Service
+	logger  zerolog.Logger
+
+	mu            sync.Mutex
+	entries       map[string]*entry
+	runLog        []RunLogEntry
+	location      config.LocationConfig
+	sunProtection config.SunProtectionConfig
+	sunOnFacade   map[string]bool // Mapping ID -> sun protection active
+
+	reload   chan struct{}
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+type entry struct {
+	schedule config.Schedule
+	trigger  trigger
+	next     time.Time
+}
+
+// RunLogEntry records a single schedule execution
+type RunLogEntry struct {
+	Time         time.Time      `json:"time"`
+	ScheduleID   string         `json:"schedule_id"`
+	ScheduleName string         `json:"schedule_name"`
+	Action       string         `json:"action"`
+	Success      bool           `json:"success"`
+	Results      []TargetResult `json:"results"`
+}
+
+// TargetResult is the outcome of a schedule action for one node
+type TargetResult struct {
+	NodeID  uint8  `json:"node_id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// New creates a new scheduler
+func New(gw *gateway.Service, logger zerolog.Logger) *Scheduler {
+	return &Scheduler{
+		gateway:     gw,
+		logger:      logger.With().Str("component", "scheduler").Logger(),
+		entries:     make(map[string]*entry),
+		sunOnFacade: make(map[string]bool),
+		reload:      make(chan struct{}, 1),
+		stopChan:    make(chan struct{}),
+	}
+}
+
+// Validate checks a schedule including its trigger
+func Validate(schedule config.Schedule, location config.LocationConfig) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	_, err := newTrigger(schedule, location)
+	return err
+}
+
+// newTrigger builds the trigger for a schedule
+func newTrigger(schedule config.Schedule, location config.LocationConfig) (trigger, error) {
+	if schedule.Cron != "" {
+		return ParseCron(schedule.Cron)
+	}
+
+	for _, day := range schedule.Weekdays {
+		if _, ok := weekdayNames[strings.ToLower(day)]; !ok {
+			return nil, fmt.Errorf("invalid weekday %q (use mon, tue, wed, thu, fri, sat, sun)", day)
+		}
+	}
+
+	if schedule.Sun != "" {
+		return newSunTrigger(schedule, location)
+	}
+
+	hour, minute, err := parseTimeOfDay(schedule.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	dow := "*"
+	if len(schedule.Weekdays) > 0 {
+		dow = strings.Join(schedule.Weekdays, ",")
+	}
+
+	return ParseCron(fmt.Sprintf("%d %d * * %s", minute, hour, dow))
+}
+
+// newSunTrigger builds a trigger relative to sunrise, sunset or civil twilight
+func newSunTrigger(schedule config.Schedule, location config.LocationConfig) (trigger, error) {
+	switch schedule.Sun {
+	case SunEventSunrise, SunEventSunset, SunEventCivilDawn, SunEventCivilDusk:
+	default:
+		return nil, fmt.Errorf("invalid sun event %q (use sunrise, sunset, civil_dawn, civil_dusk)", schedule.Sun)
+	}
+	if !location.IsSet() {
+		return nil, fmt.Errorf("location (latitude/longitude) is required for sun triggers")
+	}
+
+	t := &sunTrigger{
+		event:    schedule.Sun,
+		offset:   time.Duration(schedule.OffsetMinutes) * time.Minute,
+		earliest: -1,
+		latest:   -1,
+		lat:      location.Latitude,
+		lon:      location.Longitude,
+	}
+
+	for _, day := range schedule.Weekdays {
+		t.weekdays |= 1 << uint(weekdayNames[strings.ToLower(day)])
+	}
+
+	if schedule.Earliest != "" {
+		hour, minute, err := parseTimeOfDay(schedule.Earliest)
+		if err != nil {
+			return nil, fmt.Errorf("earliest: %w", err)
+		}
+		t.earliest = hour*60 + minute
+	}
+	if schedule.Latest != "" {
+		hour, minute, err := parseTimeOfDay(schedule.Latest)
+		if err != nil {
+			return nil, fmt.Errorf("latest: %w", err)
+		}
+		t.latest = hour*60 + minute
+	}
+	if t.earliest >= 0 && t.latest >= 0 && t.earliest > t.latest {
+		return nil, fmt.Errorf("earliest must not be after latest")
+	}
+
+	return t, nil
+}
+
+// parseTimeOfDay parses "HH:MM"
+func parseTimeOfDay(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q (expected HH:MM)", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// Load replaces all schedules and the sun protection settings. Invalid schedules are logged and skipped.
+func (s *Scheduler) Load(cfg *config.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.location = cfg.Location
+	s.sunProtection = cfg.SunProtection
+	s.sunProtection.MappingIDs = append([]string(nil), cfg.SunProtection.MappingIDs...)
+	if !s.sunProtection.Enabled {
+		s.sunOnFacade = make(map[string]bool)
+	}
+
+	now := time.Now()
+	s.entries = make(map[string]*entry)
+	for _, schedule := range cfg.Schedules {
+		t, err := newTrigger(schedule, cfg.Location)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("schedule", schedule.Name).Msg("Skipping invalid schedule")
+			continue
+		}
+		e := &entry{schedule: schedule, trigger: t}
+		if schedule.Enabled {
+			e.next = t.Next(now)
+		}
+		s.entries[schedule.ID] = e
+	}
+
+	s.logger.Info().Int("count", len(s.entries)).Msg("Schedules loaded")
+
+	select {
+	case s.reload <- struct{}{}:
+	default:
+	}
+}
+
+// Start starts the scheduler loop
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Stop stops the scheduler and waits for running actions to finish
+func (s *Scheduler) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+}
+
+// NextRun returns the next execution time of a schedule (false if disabled or unknown)
+func (s *Scheduler) NextRun(id string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok || e.next.IsZero() {
+		return time.Time{}, false
+	}
+	return e.next, true
+}
+
+// SunInfo is the current sun position and today's sun events at the configured location
+type SunInfo struct {
+	Location config.LocationConfig `json:"location"`
+	Position SunPosition           `json:"position"`
+	Today    SunTimes              `json:"today"`
+}
+
+// SunInfo returns the current sun position and today's sun events (false if no location is configured)
+func (s *Scheduler) SunInfo() (SunInfo, bool) {
+	s.mu.Lock()
+	location := s.location
+	s.mu.Unlock()
+
+	if !location.IsSet() {
+		return SunInfo{}, false
+	}
+
+	now := time.Now()
+	return SunInfo{
+		Location: location,
+		Position: CalcSunPosition(now, location.Latitude, location.Longitude),
+		Today:    CalcSunTimes(now, location.Latitude, location.Longitude),
+	}, true
+}
+
+// RunLog returns the most recent schedule executions, newest first
+func (s *Scheduler) RunLog() []RunLogEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log := make([]RunLogEntry, len(s.runLog))
+	for i, e := range s.runLog {
+		log[len(s.runLog)-1-i] = e
+	}
+	return log
+}
+
+// loop sleeps until the next schedule is due and executes it
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+
+	for {
+		wait := maxWait
+		if next, ok := s.nextDue(); ok {
+			if d := time.Until(next); d < wait {
+				wait = d
+			}
+		}
+		if wait < 0 {
+			wait = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.stopChan:
+			timer.Stop()
+			return
+		case <-s.reload:
+			timer.Stop()
+		case <-timer.C:
+			now := time.Now()
+			s.runDue(now)
+			s.evaluateSunProtection(now)
+		}
+	}
+}
+
+// nextDue returns the earliest next execution time of all enabled schedules
+func (s *Scheduler) nextDue() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var earliest time.Time
+	for _, e := range s.entries {
+		if e.next.IsZero() {
+			continue
+		}
+		if earliest.IsZero() || e.next.Before(earliest) {
+			earliest = e.next
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// runDue executes all schedules whose next execution time has passed
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	var due []config.Schedule
+	for _, e := range s.entries {
+		if e.next.IsZero() || e.next.After(now) {
+			continue
+		}
+		due = append(due, e.schedule)
+		e.next = e.trigger.Next(now)
+	}
+	s.mu.Unlock()
+
+	for _, schedule := range due {
+		s.wg.Add(1)
+		go func(schedule config.Schedule) {
+			defer s.wg.Done()
+			s.execute(schedule)
+		}(schedule)
+	}
+}
+
+// execute runs the schedule action on all target nodes
+func (s *Scheduler) execute(schedule config.Schedule) {
+	logEntry := RunLogEntry{
+		Time:         time.Now(),
+		ScheduleID:   schedule.ID,
+		ScheduleName: schedule.Name,
+		Action:       schedule.Action,
+		Success:      true,
+	}
+
+	nodeIDs, errs := s.resolveTargets(schedule)
+	for _, err := range errs {
+		logEntry.Success = false
+		logEntry.Results = append(logEntry.Results, TargetResult{Error: err.Error()})
+	}
+
+	for _, nodeID := range nodeIDs {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := s.runAction(ctx, schedule, nodeID)
+		cancel()
+
+		result := TargetResult{NodeID: nodeID, Success: err == nil}
+		if err != nil {
+			result.Error = err.Error()
+			logEntry.Success = false
+			s.logger.Warn().Err(err).
+				Str("schedule", schedule.Name).
+				Uint8("node", nodeID).
+				Msg("Schedule action failed")
+		}
+		logEntry.Results = append(logEntry.Results, result)
+	}
+
+	s.logger.Info().
+		Str("schedule", schedule.Name).
+		Str("action", schedule.Action).
+		Int("targets", len(nodeIDs)).
+		Bool("success", logEntry.Success).
+		Msg("Schedule executed")
+
+	s.record(logEntry)
+}
+
+// record appends an entry to the run log
+func (s *Scheduler) record(logEntry RunLogEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runLog = append(s.runLog, logEntry)
+	if len(s.runLog) > maxRunLogEntries {
+		s.runLog = s.runLog[len(s.runLog)-maxRunLogEntries:]
+	}
+}
+
+// resolveTargets returns the node IDs of a schedule including the nodes of its mappings
+func (s *Scheduler) resolveTargets(schedule config.Schedule) ([]uint8, []error) {
+	seen := make(map[uint8]bool)
+	var nodeIDs []uint8
+	var errs []error
+
+	add := func(id uint8) {
+		if !seen[id] {
+			seen[id] = true
+			nodeIDs = append(nodeIDs, id)
+		}
+	}
+
+	for _, id := range schedule.NodeIDs {
+		add(id)
+	}
+
+	mappings := s.gateway.GetMappingManager()
+	for _, id := range schedule.MappingIDs {
+		mapping := mappings.GetByID(id)
+		if mapping == nil {
+			errs = append(errs, fmt.Errorf("mapping %s not found", id))
+			continue
+		}
+		add(mapping.NodeID)
+	}
+
+	sort.Slice(nodeIDs, func(i, j int) bool { return nodeIDs[i] < nodeIDs[j] })
+	return nodeIDs, errs
+}
+
+// runAction executes the schedule action for one node
+func (s *Scheduler) runAction(ctx context.Context, schedule config.Schedule, nodeID uint8) error {
+	ctx = gateway.WithSource(ctx, gateway.SourceSchedule)
+	switch schedule.Action {
+	case config.ScheduleActionPosition:
+		return s.gateway.SetPosition(ctx, nodeID, schedule.Position)
+	case config.ScheduleActionOpen:
+		return s.gateway.Open(ctx, nodeID)
+	case config.ScheduleActionClose:
+		return s.gateway.Close(ctx, nodeID)
+	case config.ScheduleActionStop:
+		return s.gateway.StopNode(ctx, nodeID)
+	default:
+		return fmt.Errorf("unknown action %q", schedule.Action)
+	}
+}
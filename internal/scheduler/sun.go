@@ -0,0 +1,228 @@
+package scheduler
+
+import (
+	"math"
+	"time"
+)
+
+// Sun events usable as schedule triggers
+const (
+	SunEventSunrise   = "sunrise"
+	SunEventSunset    = "sunset"
+	SunEventCivilDawn = "civil_dawn"
+	SunEventCivilDusk = "civil_dusk"
+)
+
+// Zenith angles (degrees) of the sun events, including atmospheric refraction for sunrise/sunset
+const (
+	zenithOfficial = 90.833
+	zenithCivil    = 96.0
+)
+
+// SunPosition is the position of the sun as seen from an observer
+type SunPosition struct {
+	Azimuth   float64 `json:"azimuth"`   // Degrees clockwise from north
+	Elevation float64 `json:"elevation"` // Degrees above the horizon
+}
+
+// SunTimes are the sun events of a single day (zero if the event does not occur, e.g. polar day)
+type SunTimes struct {
+	CivilDawn time.Time `json:"civil_dawn"`
+	Sunrise   time.Time `json:"sunrise"`
+	Sunset    time.Time `json:"sunset"`
+	CivilDusk time.Time `json:"civil_dusk"`
+}
+
+// Calculations follow the NOAA solar calculator (accurate to about one minute).
+
+func rad(deg float64) float64 { return deg * math.Pi / 180 }
+func deg(rad float64) float64 { return rad * 180 / math.Pi }
+
+// julianCentury returns Julian centuries since J2000.0
+func julianCentury(t time.Time) float64 {
+	jd := float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5
+	return (jd - 2451545.0) / 36525.0
+}
+
+// solarParams returns the sun declination (degrees) and the equation of time (minutes)
+func solarParams(t time.Time) (decl, eqTime float64) {
+	jc := julianCentury(t)
+
+	meanLong := math.Mod(280.46646+jc*(36000.76983+jc*0.0003032), 360)
+	meanAnom := 357.52911 + jc*(35999.05029-0.0001537*jc)
+	eccent := 0.016708634 - jc*(0.000042037+0.0000001267*jc)
+
+	eqCenter := math.Sin(rad(meanAnom))*(1.914602-jc*(0.004817+0.000014*jc)) +
+		math.Sin(rad(2*meanAnom))*(0.019993-0.000101*jc) +
+		math.Sin(rad(3*meanAnom))*0.000289
+	trueLong := meanLong + eqCenter
+	omega := 125.04 - 1934.136*jc
+	appLong := trueLong - 0.00569 - 0.00478*math.Sin(rad(omega))
+
+	meanObliq := 23 + (26+(21.448-jc*(46.815+jc*(0.00059-jc*0.001813)))/60)/60
+	obliq := meanObliq + 0.00256*math.Cos(rad(omega))
+
+	decl = deg(math.Asin(math.Sin(rad(obliq)) * math.Sin(rad(appLong))))
+
+	y := math.Pow(math.Tan(rad(obliq/2)), 2)
+	eqTime = 4 * deg(y*math.Sin(2*rad(meanLong))-
+		2*eccent*math.Sin(rad(meanAnom))+
+		4*eccent*y*math.Sin(rad(meanAnom))*math.Cos(2*rad(meanLong))-
+		0.5*y*y*math.Sin(4*rad(meanLong))-
+		1.25*eccent*eccent*math.Sin(2*rad(meanAnom)))
+
+	return decl, eqTime
+}
+
+// CalcSunPosition returns the sun azimuth and elevation at the given time and location
+func CalcSunPosition(t time.Time, lat, lon float64) SunPosition {
+	t = t.UTC()
+	decl, eqTime := solarParams(t)
+
+	minutes := float64(t.Hour()*60+t.Minute()) + float64(t.Second())/60
+	trueSolarTime := math.Mod(minutes+eqTime+4*lon, 1440)
+	if trueSolarTime < 0 {
+		trueSolarTime += 1440
+	}
+
+	hourAngle := trueSolarTime/4 - 180
+
+	cosZenith := math.Sin(rad(lat))*math.Sin(rad(decl)) +
+		math.Cos(rad(lat))*math.Cos(rad(decl))*math.Cos(rad(hourAngle))
+	zenith := deg(math.Acos(clamp(cosZenith, -1, 1)))
+
+	var azimuth float64
+	denom := math.Cos(rad(lat)) * math.Sin(rad(zenith))
+	if math.Abs(denom) > 1e-9 {
+		cosAz := (math.Sin(rad(lat))*math.Cos(rad(zenith)) - math.Sin(rad(decl))) / denom
+		az := deg(math.Acos(clamp(cosAz, -1, 1)))
+		if hourAngle > 0 {
+			azimuth = math.Mod(az+180, 360)
+		} else {
+			azimuth = math.Mod(540-az, 360)
+		}
+	}
+
+	return SunPosition{Azimuth: azimuth, Elevation: 90 - zenith}
+}
+
+// CalcSunTimes returns the sun events for the calendar day of date in its location
+func CalcSunTimes(date time.Time, lat, lon float64) SunTimes {
+	return SunTimes{
+		CivilDawn: sunEventTime(date, lat, lon, zenithCivil, true),
+		Sunrise:   sunEventTime(date, lat, lon, zenithOfficial, true),
+		Sunset:    sunEventTime(date, lat, lon, zenithOfficial, false),
+		CivilDusk: sunEventTime(date, lat, lon, zenithCivil, false),
+	}
+}
+
+// SunEventTime returns the time of a named sun event on the calendar day of date
+func SunEventTime(event string, date time.Time, lat, lon float64) time.Time {
+	switch event {
+	case SunEventSunrise:
+		return sunEventTime(date, lat, lon, zenithOfficial, true)
+	case SunEventSunset:
+		return sunEventTime(date, lat, lon, zenithOfficial, false)
+	case SunEventCivilDawn:
+		return sunEventTime(date, lat, lon, zenithCivil, true)
+	case SunEventCivilDusk:
+		return sunEventTime(date, lat, lon, zenithCivil, false)
+	default:
+		return time.Time{}
+	}
+}
+
+// sunEventTime computes the time the sun crosses the given zenith angle (rising or setting).
+// Returns the zero time if the sun does not cross it on that day.
+func sunEventTime(date time.Time, lat, lon, zenith float64, rising bool) time.Time {
+	// Midnight UTC of the local calendar date, all event times are minutes from there
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	// First approximation at solar noon, then refine at the event time
+	minutes := 720 - 4*lon
+	for i := 0; i < 2; i++ {
+		decl, eqTime := solarParams(day.Add(time.Duration(minutes * float64(time.Minute))))
+
+		cosHA := math.Cos(rad(zenith))/(math.Cos(rad(lat))*math.Cos(rad(decl))) -
+			math.Tan(rad(lat))*math.Tan(rad(decl))
+		if cosHA < -1 || cosHA > 1 {
+			return time.Time{}
+		}
+		hourAngle := deg(math.Acos(cosHA))
+
+		solarNoon := 720 - 4*lon - eqTime
+		if rising {
+			minutes = solarNoon - 4*hourAngle
+		} else {
+			minutes = solarNoon + 4*hourAngle
+		}
+	}
+
+	return day.Add(time.Duration(minutes * float64(time.Minute))).In(date.Location()).Truncate(time.Second)
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
+
+// AngleDiff returns the absolute difference between two compass directions (0-180 degrees)
+func AngleDiff(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+// sunTrigger fires at a sun event with an offset, optionally clamped to a time window
+type sunTrigger struct {
+	event    string
+	offset   time.Duration
+	earliest int // Minutes after midnight, -1 = no clamp
+	latest   int // Minutes after midnight, -1 = no clamp
+	weekdays uint64
+	lat, lon float64
+}
+
+// maxSunSearchDays bounds the search for the next event (polar night/day has none for months)
+const maxSunSearchDays = 370
+
+// Next returns the first trigger time after t
+func (s *sunTrigger) Next(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	for i := 0; i < maxSunSearchDays; i++ {
+		d := day.AddDate(0, 0, i)
+		if s.weekdays != 0 && s.weekdays&(1<<uint(d.Weekday())) == 0 {
+			continue
+		}
+
+		event := SunEventTime(s.event, d, s.lat, s.lon)
+		if event.IsZero() {
+			continue
+		}
+		event = event.Add(s.offset).Truncate(time.Minute)
+
+		if s.earliest >= 0 {
+			if earliest := atMinute(d, s.earliest); event.Before(earliest) {
+				event = earliest
+			}
+		}
+		if s.latest >= 0 {
+			if latest := atMinute(d, s.latest); event.After(latest) {
+				event = latest
+			}
+		}
+
+		if event.After(t) {
+			return event
+		}
+	}
+
+	return time.Time{}
+}
+
+// atMinute returns the time at the given minutes after midnight on the day of d
+func atMinute(d time.Time, minutes int) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), minutes/60, minutes%60, 0, 0, d.Location())
+}
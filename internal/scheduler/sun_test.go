@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var cest = time.FixedZone("CEST", 2*60*60)
+
+func TestCalcSunTimes(t *testing.T) {
+	tests := []struct {
+		name     string
+		date     time.Time
+		lat, lon float64
+		sunrise  time.Time
+		sunset   time.Time
+	}{
+		{
+			name: "zurich summer solstice",
+			date: time.Date(2024, time.June, 21, 12, 0, 0, 0, cest),
+			lat:  47.37, lon: 8.54,
+			sunrise: time.Date(2024, time.June, 21, 5, 29, 0, 0, cest),
+			sunset:  time.Date(2024, time.June, 21, 21, 26, 0, 0, cest),
+		},
+		{
+			name: "equator equinox",
+			date: time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC),
+			lat:  0, lon: 0,
+			sunrise: time.Date(2024, time.March, 20, 6, 4, 0, 0, time.UTC),
+			sunset:  time.Date(2024, time.March, 20, 18, 11, 0, 0, time.UTC),
+		},
+		{
+			name: "polar day",
+			date: time.Date(2024, time.June, 21, 0, 0, 0, 0, time.UTC),
+			lat:  69.65, lon: 18.96,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalcSunTimes(tt.date, tt.lat, tt.lon)
+			assertNear(t, "sunrise", got.Sunrise, tt.sunrise)
+			assertNear(t, "sunset", got.Sunset, tt.sunset)
+			if got.Sunrise.IsZero() != got.CivilDawn.IsZero() && !tt.sunrise.IsZero() {
+				t.Errorf("civil dawn %v without sunrise %v", got.CivilDawn, got.Sunrise)
+			}
+			if !tt.sunrise.IsZero() && !got.CivilDawn.Before(got.Sunrise) {
+				t.Errorf("civil dawn %v not before sunrise %v", got.CivilDawn, got.Sunrise)
+			}
+			if !tt.sunset.IsZero() && !got.CivilDusk.After(got.Sunset) {
+				t.Errorf("civil dusk %v not after sunset %v", got.CivilDusk, got.Sunset)
+			}
+		})
+	}
+}
+
+// assertNear checks an event time within the accuracy of the calculation
+func assertNear(t *testing.T, name string, got, want time.Time) {
+	t.Helper()
+	if want.IsZero() {
+		if !got.IsZero() {
+			t.Errorf("%s = %v, want none", name, got)
+		}
+		return
+	}
+	if d := got.Sub(want); d < -2*time.Minute || d > 2*time.Minute {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func TestCalcSunPosition(t *testing.T) {
+	tests := []struct {
+		name      string
+		time      time.Time
+		lat, lon  float64
+		azimuth   float64
+		elevation float64
+	}{
+		{
+			name: "zurich solar noon",
+			time: time.Date(2024, time.June, 21, 11, 28, 0, 0, time.UTC),
+			lat:  47.37, lon: 8.54,
+			azimuth: 180, elevation: 66.07,
+		},
+		{
+			name: "zurich morning",
+			time: time.Date(2024, time.June, 21, 6, 0, 0, 0, time.UTC),
+			lat:  47.37, lon: 8.54,
+			azimuth: 79.1, elevation: 22.3,
+		},
+		{
+			name: "below the horizon at night",
+			time: time.Date(2024, time.June, 21, 23, 28, 0, 0, time.UTC),
+			lat:  47.37, lon: 8.54,
+			azimuth: 0, elevation: -19.2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalcSunPosition(tt.time, tt.lat, tt.lon)
+			if AngleDiff(got.Azimuth, tt.azimuth) > 1 || math.Abs(got.Elevation-tt.elevation) > 0.5 {
+				t.Errorf("got %+v, want azimuth %.1f elevation %.1f", got, tt.azimuth, tt.elevation)
+			}
+		})
+	}
+}
+
+func TestSunTriggerNext(t *testing.T) {
+	const lat, lon = 47.37, 8.54
+	from := time.Date(2024, time.June, 21, 12, 0, 0, 0, cest)
+
+	tests := []struct {
+		name    string
+		trigger sunTrigger
+		want    time.Time
+	}{
+		{
+			name:    "sunset today",
+			trigger: sunTrigger{event: SunEventSunset, earliest: -1, latest: -1},
+			want:    time.Date(2024, time.June, 21, 21, 26, 0, 0, cest),
+		},
+		{
+			name:    "sunrise tomorrow with offset",
+			trigger: sunTrigger{event: SunEventSunrise, offset: 30 * time.Minute, earliest: -1, latest: -1},
+			want:    time.Date(2024, time.June, 22, 5, 59, 0, 0, cest),
+		},
+		{
+			name:    "sunrise clamped to earliest",
+			trigger: sunTrigger{event: SunEventSunrise, earliest: 7 * 60, latest: -1},
+			want:    time.Date(2024, time.June, 22, 7, 0, 0, 0, cest),
+		},
+		{
+			name:    "sunset clamped to latest",
+			trigger: sunTrigger{event: SunEventSunset, earliest: -1, latest: 21 * 60},
+			want:    time.Date(2024, time.June, 21, 21, 0, 0, 0, cest),
+		},
+		{
+			name:    "weekdays only",
+			trigger: sunTrigger{event: SunEventSunset, earliest: -1, latest: 21 * 60, weekdays: 1 << time.Monday},
+			want:    time.Date(2024, time.June, 24, 21, 0, 0, 0, cest),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.trigger.lat, tt.trigger.lon = lat, lon
+			assertNear(t, "next", tt.trigger.Next(from), tt.want)
+		})
+	}
+}
+
+func TestAngleDiff(t *testing.T) {
+	tests := []struct {
+		a, b, want float64
+	}{
+		{a: 90, b: 180, want: 90},
+		{a: 350, b: 10, want: 20},
+		{a: 10, b: 350, want: 20},
+		{a: 0, b: 180, want: 180},
+		{a: 720, b: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := AngleDiff(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("AngleDiff(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
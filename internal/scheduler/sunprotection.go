@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/gateway"
+)
+
+// sunProtectionID identifies sun protection actions in the run log
+const sunProtectionID = "sun_protection"
+
+// sunTarget is a mapping whose facade is evaluated by sun protection
+type sunTarget struct {
+	mapping config.NodeMapping
+	onSun   bool
+}
+
+// evaluateSunProtection moves blinds when the sun starts or stops shining on their facade
+func (s *Scheduler) evaluateSunProtection(now time.Time) {
+	s.mu.Lock()
+	cfg := s.sunProtection
+	location := s.location
+	s.mu.Unlock()
+
+	if !cfg.Enabled || !location.IsSet() {
+		return
+	}
+
+	sun := CalcSunPosition(now, location.Latitude, location.Longitude)
+
+	selected := make(map[string]bool)
+	for _, id := range cfg.MappingIDs {
+		selected[id] = true
+	}
+
+	var changed []sunTarget
+	s.mu.Lock()
+	for _, mapping := range s.gateway.GetMappingManager().GetAll() {
+		if !mapping.Enabled || mapping.FacadeAzimuth == nil {
+			continue
+		}
+		if len(selected) > 0 && !selected[mapping.ID] {
+			continue
+		}
+
+		onSun := sun.Elevation >= cfg.MinElevation &&
+			AngleDiff(sun.Azimuth, *mapping.FacadeAzimuth) <= cfg.AzimuthTolerance
+		if onSun != s.sunOnFacade[mapping.ID] {
+			changed = append(changed, sunTarget{mapping: mapping, onSun: onSun})
+		}
+	}
+	s.mu.Unlock()
+
+	for _, target := range changed {
+		position := cfg.Position
+		if !target.onSun {
+			if cfg.ReleasePosition == nil {
+				s.setSunOnFacade(target)
+				continue
+			}
+			position = *cfg.ReleasePosition
+		}
+
+		s.logger.Info().
+			Str("mapping", target.mapping.Name).
+			Uint8("node", target.mapping.NodeID).
+			Bool("sun_on_facade", target.onSun).
+			Float64("azimuth", sun.Azimuth).
+			Float64("elevation", sun.Elevation).
+			Float64("position", position).
+			Msg("Sun protection")
+
+		ctx, cancel := context.WithTimeout(gateway.WithSource(context.Background(), gateway.SourceRule), 30*time.Second)
+		err := s.gateway.SetPosition(ctx, target.mapping.NodeID, position)
+		cancel()
+
+		// A failed move keeps the previous state, so the next evaluation retries it
+		result := TargetResult{NodeID: target.mapping.NodeID, Success: err == nil}
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			s.setSunOnFacade(target)
+		}
+		s.record(RunLogEntry{
+			Time:         now,
+			ScheduleID:   sunProtectionID,
+			ScheduleName: "Sun protection: " + target.mapping.Name,
+			Action:       config.ScheduleActionPosition,
+			Success:      err == nil,
+			Results:      []TargetResult{result},
+		})
+	}
+}
+
+// setSunOnFacade records the sun protection state of a mapping once it was applied
+func (s *Scheduler) setSunOnFacade(target sunTarget) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sunOnFacade[target.mapping.ID] = target.onSun
+}
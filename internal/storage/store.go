@@ -0,0 +1,462 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// Bucket names
+var (
+	bucketNodes         = []byte("nodes")
+	bucketNodeHistory   = []byte("node_history")
+	bucketSensorHistory = []byte("sensor_history")
+	bucketActivationLog = []byte("activation_log")
+	bucketMeta          = []byte("meta")
+)
+
+// keyActivationLogImported is the meta key holding the time of the newest imported activation log line
+var keyActivationLogImported = []byte("activation_log_imported")
+
+// keyMaintenance is the meta key holding the active maintenance windows
+var keyMaintenance = []byte("maintenance")
+
+// pruneInterval is how often history older than the retention period is removed
+const pruneInterval = time.Hour
+
+// Store persists the last known node state and the change history in an embedded bbolt database
+type Store struct {
+	db        *bolt.DB
+	retention time.Duration
+	logger    zerolog.Logger
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NodeHistoryEntry is a single recorded position/state change of a node
+type NodeHistoryEntry struct {
+	Time            time.Time        `json:"time"`
+	NodeID          uint8            `json:"node_id"`
+	PositionPercent float64          `json:"position_percent"`
+	TargetPercent   float64          `json:"target_percent"`
+	State           klf200.NodeState `json:"state"`
+	StateStr        string           `json:"state_str"`
+}
+
+// MaintenanceWindow is a period in which automation may not move the nodes
+type MaintenanceWindow struct {
+	NodeID  *uint8    `json:"node_id,omitempty"` // nil = all nodes
+	Reason  string    `json:"reason,omitempty"`
+	Since   time.Time `json:"since"`
+	Expires time.Time `json:"expires"`
+}
+
+// SensorHistoryEntry is a single recorded sensor status change
+type SensorHistoryEntry struct {
+	Time         time.Time `json:"time"`
+	RainDetected bool      `json:"rain_detected"`
+	WindDetected bool      `json:"wind_detected"`
+}
+
+// Open opens (or creates) the database at the configured path
+func Open(cfg config.StorageConfig, logger zerolog.Logger) (*Store, error) {
+	if dir := filepath.Dir(cfg.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create storage directory: %w", err)
+		}
+	}
+
+	db, err := bolt.Open(cfg.Path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketNodes, bucketNodeHistory, bucketSensorHistory, bucketActivationLog, bucketMeta} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	s := &Store{
+		db:        db,
+		retention: cfg.Retention,
+		logger:    logger.With().Str("component", "storage").Logger(),
+		stopChan:  make(chan struct{}),
+	}
+
+	s.logger.Info().Str("path", cfg.Path).Dur("retention", cfg.Retention).Msg("Storage opened")
+
+	if s.retention > 0 {
+		s.wg.Add(1)
+		go s.pruneLoop()
+	}
+
+	return s, nil
+}
+
+// Close stops background pruning and closes the database
+func (s *Store) Close() error {
+	close(s.stopChan)
+	s.wg.Wait()
+	return s.db.Close()
+}
+
+// SaveNode persists the last known state of a node
+func (s *Store) SaveNode(node *klf200.Node) error {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketNodes).Put([]byte{node.ID}, data)
+	})
+}
+
+// SaveNodeWithHistory persists the state of a node and appends a history entry in one transaction
+func (s *Store) SaveNodeWithHistory(node *klf200.Node, entry NodeHistoryEntry) error {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	entryData, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(bucketNodes).Put([]byte{node.ID}, data); err != nil {
+			return err
+		}
+		return putNodeHistory(tx, entry, entryData)
+	})
+}
+
+// DeleteNode removes a persisted node (its history is kept until pruned)
+func (s *Store) DeleteNode(id uint8) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketNodes).Delete([]byte{id})
+	})
+}
+
+// LoadNodes returns all persisted nodes
+func (s *Store) LoadNodes() ([]*klf200.Node, error) {
+	var nodes []*klf200.Node
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketNodes).ForEach(func(k, v []byte) error {
+			var node klf200.Node
+			if err := json.Unmarshal(v, &node); err != nil {
+				s.logger.Warn().Err(err).Uint8("id", k[0]).Msg("Skipping corrupt node record")
+				return nil
+			}
+			nodes = append(nodes, &node)
+			return nil
+		})
+	})
+
+	return nodes, err
+}
+
+// SaveMaintenance replaces the persisted maintenance windows
+func (s *Store) SaveMaintenance(windows []MaintenanceWindow) error {
+	data, err := json.Marshal(windows)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketMeta).Put(keyMaintenance, data)
+	})
+}
+
+// LoadMaintenance returns the persisted maintenance windows
+func (s *Store) LoadMaintenance() ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(bucketMeta).Get(keyMaintenance); v != nil {
+			return json.Unmarshal(v, &windows)
+		}
+		return nil
+	})
+
+	return windows, err
+}
+
+// AppendNodeHistory records a node change
+// Key layout: NodeID(1) | UnixNano(8) | Sequence(8)
+func (s *Store) AppendNodeHistory(entry NodeHistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putNodeHistory(tx, entry, data)
+	})
+}
+
+// putNodeHistory stores a marshaled node history entry
+func putNodeHistory(tx *bolt.Tx, entry NodeHistoryEntry, data []byte) error {
+	b := tx.Bucket(bucketNodeHistory)
+	seq, _ := b.NextSequence()
+
+	key := make([]byte, 17)
+	key[0] = entry.NodeID
+	binary.BigEndian.PutUint64(key[1:9], timeKey(entry.Time))
+	binary.BigEndian.PutUint64(key[9:17], seq)
+
+	return b.Put(key, data)
+}
+
+// AppendSensorHistory records a sensor status change
+// Key layout: UnixNano(8) | Sequence(8)
+func (s *Store) AppendSensorHistory(entry SensorHistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketSensorHistory)
+		seq, _ := b.NextSequence()
+
+		key := make([]byte, 16)
+		binary.BigEndian.PutUint64(key[0:8], timeKey(entry.Time))
+		binary.BigEndian.PutUint64(key[8:16], seq)
+
+		return b.Put(key, data)
+	})
+}
+
+// AppendActivationLog records imported KLF-200 activation log lines and remembers the newest one.
+// Lines already stored are overwritten, so importing overlapping ranges is safe.
+// Key layout: UnixNano(8) | SessionID(2) | NodeID(1) | RunStatus(1)
+func (s *Store) AppendActivationLog(lines []*klf200.ActivationLogLine) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketActivationLog)
+		meta := tx.Bucket(bucketMeta)
+
+		var newest uint64
+		if v := meta.Get(keyActivationLogImported); len(v) == 8 {
+			newest = binary.BigEndian.Uint64(v)
+		}
+
+		for _, line := range lines {
+			data, err := json.Marshal(line)
+			if err != nil {
+				return err
+			}
+
+			key := make([]byte, 12)
+			binary.BigEndian.PutUint64(key[0:8], timeKey(line.Time))
+			binary.BigEndian.PutUint16(key[8:10], line.SessionID)
+			key[10] = line.NodeID
+			key[11] = byte(line.RunStatus)
+			if err := b.Put(key, data); err != nil {
+				return err
+			}
+
+			if t := timeKey(line.Time); t > newest {
+				newest = t
+			}
+		}
+
+		v := make([]byte, 8)
+		binary.BigEndian.PutUint64(v, newest)
+		return meta.Put(keyActivationLogImported, v)
+	})
+}
+
+// ActivationLogImported returns the time of the newest imported activation log line (zero if none)
+func (s *Store) ActivationLogImported() (time.Time, error) {
+	var t time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(bucketMeta).Get(keyActivationLogImported); len(v) == 8 {
+			if n := binary.BigEndian.Uint64(v); n > 0 {
+				t = time.Unix(0, int64(n))
+			}
+		}
+		return nil
+	})
+	return t, err
+}
+
+// ActivationLog returns the imported activation log lines between from and to (inclusive), oldest first
+func (s *Store) ActivationLog(from, to time.Time) ([]*klf200.ActivationLogLine, error) {
+	lines := []*klf200.ActivationLogLine{}
+
+	start := make([]byte, 8)
+	binary.BigEndian.PutUint64(start, timeKey(from))
+	end := timeKey(to)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketActivationLog).Cursor()
+		for k, v := c.Seek(start); k != nil; k, v = c.Next() {
+			if binary.BigEndian.Uint64(k[0:8]) > end {
+				break
+			}
+			var line klf200.ActivationLogLine
+			if err := json.Unmarshal(v, &line); err != nil {
+				continue
+			}
+			lines = append(lines, &line)
+		}
+		return nil
+	})
+
+	return lines, err
+}
+
+// NodeHistory returns the recorded changes of a node between from and to (inclusive), oldest first
+func (s *Store) NodeHistory(nodeID uint8, from, to time.Time) ([]NodeHistoryEntry, error) {
+	entries := []NodeHistoryEntry{}
+
+	start := make([]byte, 9)
+	start[0] = nodeID
+	binary.BigEndian.PutUint64(start[1:9], timeKey(from))
+	end := timeKey(to)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketNodeHistory).Cursor()
+		for k, v := c.Seek(start); k != nil && k[0] == nodeID; k, v = c.Next() {
+			if binary.BigEndian.Uint64(k[1:9]) > end {
+				break
+			}
+			var entry NodeHistoryEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+
+	return entries, err
+}
+
+// SensorHistory returns the recorded sensor changes between from and to (inclusive), oldest first
+func (s *Store) SensorHistory(from, to time.Time) ([]SensorHistoryEntry, error) {
+	entries := []SensorHistoryEntry{}
+
+	start := make([]byte, 8)
+	binary.BigEndian.PutUint64(start, timeKey(from))
+	end := timeKey(to)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketSensorHistory).Cursor()
+		for k, v := c.Seek(start); k != nil; k, v = c.Next() {
+			if binary.BigEndian.Uint64(k[0:8]) > end {
+				break
+			}
+			var entry SensorHistoryEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+
+	return entries, err
+}
+
+// Prune removes all history entries recorded before the given time
+func (s *Store) Prune(before time.Time) (int, error) {
+	cutoff := timeKey(before)
+	removed := 0
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		// Node history is grouped by node ID, so every key has to be checked
+		nodeHistory := tx.Bucket(bucketNodeHistory)
+		var expired [][]byte
+		nodeHistory.ForEach(func(k, _ []byte) error {
+			if binary.BigEndian.Uint64(k[1:9]) < cutoff {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range expired {
+			if err := nodeHistory.Delete(k); err != nil {
+				return err
+			}
+		}
+		removed += len(expired)
+
+		// Sensor history and activation log are ordered by time, stop at the first newer entry
+		for _, name := range [][]byte{bucketSensorHistory, bucketActivationLog} {
+			b := tx.Bucket(name)
+			expired = expired[:0]
+			c := b.Cursor()
+			for k, _ := c.First(); k != nil && binary.BigEndian.Uint64(k[0:8]) < cutoff; k, _ = c.Next() {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			for _, k := range expired {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			removed += len(expired)
+		}
+
+		return nil
+	})
+
+	return removed, err
+}
+
+// timeKey converts a time to its big-endian sortable key part (times before 1970 sort first)
+func timeKey(t time.Time) uint64 {
+	if t.Before(time.Unix(0, 0)) {
+		return 0
+	}
+	return uint64(t.UnixNano())
+}
+
+// pruneLoop periodically removes history older than the retention period
+func (s *Store) pruneLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	s.prune()
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.prune()
+		}
+	}
+}
+
+func (s *Store) prune() {
+	removed, err := s.Prune(time.Now().Add(-s.retention))
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to prune history")
+		return
+	}
+	if removed > 0 {
+		s.logger.Info().Int("removed", removed).Msg("Pruned history")
+	}
+}
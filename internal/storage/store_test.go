@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/stefanbeyeler/loxone2velux/internal/config"
+	"github.com/stefanbeyeler/loxone2velux/internal/klf200"
+)
+
+// openTestStore opens a store in a temporary directory, without background pruning
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(config.StorageConfig{Path: filepath.Join(t.TempDir(), "data", "test.db")}, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStoreNodes(t *testing.T) {
+	s := openTestStore(t)
+
+	base := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	for _, node := range []*klf200.Node{
+		{ID: 1, Name: "Window", PositionPercent: 30},
+		{ID: 2, Name: "Blind", PositionPercent: 100},
+	} {
+		if err := s.SaveNodeWithHistory(node, NodeHistoryEntry{Time: base, NodeID: node.ID, PositionPercent: node.PositionPercent}); err != nil {
+			t.Fatalf("save node %d: %v", node.ID, err)
+		}
+	}
+	// Saving again replaces the node
+	if err := s.SaveNode(&klf200.Node{ID: 1, Name: "Window", PositionPercent: 60}); err != nil {
+		t.Fatalf("save node: %v", err)
+	}
+	if err := s.DeleteNode(2); err != nil {
+		t.Fatalf("delete node: %v", err)
+	}
+
+	nodes, err := s.LoadNodes()
+	if err != nil {
+		t.Fatalf("load nodes: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].ID != 1 || nodes[0].PositionPercent != 60 {
+		t.Fatalf("nodes = %+v, want node 1 at 60%%", nodes)
+	}
+
+	// The history of a deleted node is kept
+	history, err := s.NodeHistory(2, base, base)
+	if err != nil || len(history) != 1 {
+		t.Errorf("history of deleted node = %+v, %v, want one entry", history, err)
+	}
+}
+
+func TestStoreNodeHistory(t *testing.T) {
+	s := openTestStore(t)
+
+	base := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	entries := []NodeHistoryEntry{
+		{Time: base.Add(2 * time.Minute), NodeID: 1, PositionPercent: 20},
+		{Time: base, NodeID: 1, PositionPercent: 0},
+		{Time: base.Add(time.Minute), NodeID: 2, PositionPercent: 50},
+		{Time: base.Add(time.Minute), NodeID: 1, PositionPercent: 10},
+		// Same time as the previous entry, kept apart by the sequence
+		{Time: base.Add(time.Minute), NodeID: 1, PositionPercent: 15},
+		{Time: base.Add(3 * time.Minute), NodeID: 1, PositionPercent: 30},
+		{Time: base.Add(time.Minute), NodeID: 0, PositionPercent: 70},
+	}
+	for _, entry := range entries {
+		if err := s.AppendNodeHistory(entry); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		nodeID   uint8
+		from, to time.Time
+		want     []float64
+	}{
+		{name: "all of node 1", nodeID: 1, from: base, to: base.Add(time.Hour), want: []float64{0, 10, 15, 20, 30}},
+		{name: "inclusive bounds", nodeID: 1, from: base.Add(time.Minute), to: base.Add(2 * time.Minute), want: []float64{10, 15, 20}},
+		{name: "other node", nodeID: 2, from: base, to: base.Add(time.Hour), want: []float64{50}},
+		{name: "node 0", nodeID: 0, from: base, to: base.Add(time.Hour), want: []float64{70}},
+		{name: "empty range", nodeID: 1, from: base.Add(time.Hour), to: base.Add(2 * time.Hour), want: []float64{}},
+		{name: "unknown node", nodeID: 9, from: base, to: base.Add(time.Hour), want: []float64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history, err := s.NodeHistory(tt.nodeID, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("history: %v", err)
+			}
+			got := []float64{}
+			for _, entry := range history {
+				if entry.NodeID != tt.nodeID {
+					t.Errorf("entry of node %d in the history of node %d", entry.NodeID, tt.nodeID)
+				}
+				got = append(got, entry.PositionPercent)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("positions = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("positions = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreSensorHistory(t *testing.T) {
+	s := openTestStore(t)
+
+	base := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	for _, entry := range []SensorHistoryEntry{
+		{Time: base.Add(time.Minute), RainDetected: true},
+		{Time: base, WindDetected: true},
+		{Time: base.Add(2 * time.Minute)},
+	} {
+		if err := s.AppendSensorHistory(entry); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	history, err := s.SensorHistory(base, base.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 2 || !history[0].WindDetected || !history[1].RainDetected {
+		t.Errorf("history = %+v, want the wind then the rain entry", history)
+	}
+}
+
+func TestStorePrune(t *testing.T) {
+	s := openTestStore(t)
+
+	base := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		at := base.Add(time.Duration(i) * time.Hour)
+		for _, nodeID := range []uint8{1, 2} {
+			if err := s.AppendNodeHistory(NodeHistoryEntry{Time: at, NodeID: nodeID}); err != nil {
+				t.Fatalf("append node history: %v", err)
+			}
+		}
+		if err := s.AppendSensorHistory(SensorHistoryEntry{Time: at}); err != nil {
+			t.Fatalf("append sensor history: %v", err)
+		}
+	}
+
+	// Entries at the cutoff are kept
+	removed, err := s.Prune(base.Add(2 * time.Hour))
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if removed != 6 {
+		t.Errorf("removed %d entries, want 6", removed)
+	}
+
+	end := base.Add(24 * time.Hour)
+	for _, nodeID := range []uint8{1, 2} {
+		history, _ := s.NodeHistory(nodeID, time.Time{}, end)
+		if len(history) != 2 || !history[0].Time.Equal(base.Add(2*time.Hour)) {
+			t.Errorf("node %d history = %+v, want the last two entries", nodeID, history)
+		}
+	}
+	if history, _ := s.SensorHistory(time.Time{}, end); len(history) != 2 {
+		t.Errorf("sensor history = %+v, want the last two entries", history)
+	}
+
+	if removed, _ := s.Prune(base.Add(2 * time.Hour)); removed != 0 {
+		t.Errorf("second prune removed %d entries, want 0", removed)
+	}
+}
@@ -22,6 +22,10 @@ if [ -f "$OPTIONS_FILE" ]; then
     REFRESH_INTERVAL=$(jq -r '.refresh_interval // 300' "$OPTIONS_FILE" 2>/dev/null || echo "300")
     LOG_LEVEL=$(jq -r '.log_level // "info"' "$OPTIONS_FILE" 2>/dev/null || echo "info")
     API_TOKEN=$(jq -r '.api_token // ""' "$OPTIONS_FILE" 2>/dev/null || echo "")
+    ADMIN_TOKEN=$(jq -r '.admin_token // ""' "$OPTIONS_FILE" 2>/dev/null || echo "")
+    COMMAND_QUEUE=$(jq -r '.command_queue // false' "$OPTIONS_FILE" 2>/dev/null || echo "false")
+    COMMAND_QUEUE_TTL=$(jq -r '.command_queue_ttl // 120' "$OPTIONS_FILE" 2>/dev/null || echo "120")
+    COMMAND_DEBOUNCE=$(jq -r '.command_debounce // 0' "$OPTIONS_FILE" 2>/dev/null || echo "0")
 else
     echo "WARNING: Options file not found at ${OPTIONS_FILE}, using defaults"
     KLF200_HOST=""
@@ -31,6 +35,10 @@ else
     REFRESH_INTERVAL=300
     LOG_LEVEL="info"
     API_TOKEN=""
+    ADMIN_TOKEN=""
+    COMMAND_QUEUE=false
+    COMMAND_QUEUE_TTL=120
+    COMMAND_DEBOUNCE=0
 fi
 
 echo "KLF-200 host: ${KLF200_HOST}:${KLF200_PORT}"
@@ -38,11 +46,36 @@ echo "Server listening on: 0.0.0.0:${LISTEN_PORT}"
 
 # Always (re)generate config from HA options to avoid stale/corrupt state
 mkdir -p "${CONFIG_DIR}"
+
+# A static address set through the API is kept in its own file, unless the
+# klf200_host option was changed since
+HOST_FILE="${CONFIG_DIR}/klf200_host"
+HOST_OPTION_FILE="/data/klf200_host_option"
+if [ -f "$HOST_FILE" ] && [ -f "$HOST_OPTION_FILE" ] && \
+    [ "$(cat "$HOST_OPTION_FILE")" != "$KLF200_HOST" ]; then
+    echo "Option klf200_host changed, discarding the host set via the API"
+    rm -f "$HOST_FILE"
+fi
+printf '%s' "$KLF200_HOST" > "$HOST_OPTION_FILE"
+
+# A password changed through the API is kept in its own file, unless the
+# klf200_password option was changed since
+PASSWORD_FILE="${CONFIG_DIR}/klf200_password"
+PASSWORD_OPTION_FILE="/data/klf200_password_option"
+if [ -f "$PASSWORD_FILE" ] && [ -f "$PASSWORD_OPTION_FILE" ] && \
+    [ "$(cat "$PASSWORD_OPTION_FILE")" != "$KLF200_PASSWORD" ]; then
+    echo "Option klf200_password changed, discarding the password set via the API"
+    rm -f "$PASSWORD_FILE"
+fi
+printf '%s' "$KLF200_PASSWORD" > "$PASSWORD_OPTION_FILE"
+
 cat > "${CONFIG_FILE}" << EOF
 klf200:
   host: "${KLF200_HOST}"
+  host_file: "${HOST_FILE}"
   port: ${KLF200_PORT}
   password: "${KLF200_PASSWORD}"
+  password_file: "${PASSWORD_FILE}"
   reconnect_interval: ${RECONNECT_INTERVAL}s
   refresh_interval: ${REFRESH_INTERVAL}s
 
@@ -52,6 +85,21 @@ server:
   read_timeout: 15s
   write_timeout: 15s
   api_token: "${API_TOKEN}"
+  admin_token: "${ADMIN_TOKEN}"
+
+storage:
+  enabled: true
+  path: "${CONFIG_DIR}/loxone2velux.db"
+  retention: 720h
+
+command_queue:
+  enabled: ${COMMAND_QUEUE}
+  size: 50
+  ttl: ${COMMAND_QUEUE_TTL}s
+
+command_debounce:
+  window: ${COMMAND_DEBOUNCE}ms
+  max_delay: 2s
 
 logging:
   level: "${LOG_LEVEL}"
@@ -2,6 +2,7 @@ import { useState, useEffect, useCallback } from 'react';
 import { NodeList } from './NodeList';
 import { LoxoneGuide } from './LoxoneGuide';
 import { SensorCard } from './SensorCard';
+import { Pairing } from './Pairing';
 import * as api from '../services/api';
 import { Node, HealthResponse, SensorStatus } from '../types';
 import {
@@ -14,10 +15,11 @@ import {
   X,
   Server,
   PlugZap,
+  Link,
 } from 'lucide-react';
 
 
-type Tab = 'devices' | 'guide';
+type Tab = 'devices' | 'pairing' | 'guide';
 
 export function Dashboard() {
   const [activeTab, setActiveTab] = useState<Tab>('devices');
@@ -128,10 +130,28 @@ export function Dashboard() {
     }
   };
 
+  const handleRename = async (id: number, name: string) => {
+    try {
+      const updated = await api.updateNode(id, { name });
+      setNodes(nodes.map(n => (n.id === id ? updated : n)));
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Fehler');
+    }
+  };
+
+  const handleWink = async (id: number) => {
+    try {
+      await api.winkNode(id);
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Fehler');
+    }
+  };
+
   const isConnected = health?.connected ?? false;
 
   const tabs = [
     { id: 'devices' as Tab, label: 'Geräte', icon: Blinds, count: nodes.length },
+    { id: 'pairing' as Tab, label: 'Anlernen', icon: Link },
     { id: 'guide' as Tab, label: 'Loxone Anleitung', icon: BookOpen },
   ];
 
@@ -153,9 +173,15 @@ export function Dashboard() {
 
             <div className="flex items-center gap-4">
               {/* Gateway Status */}
-              <div className="hidden md:flex items-center gap-2 bg-gray-700/50 px-3 py-1.5 rounded-lg">
+              <div
+                className="hidden md:flex items-center gap-2 bg-gray-700/50 px-3 py-1.5 rounded-lg"
+                title={health?.klf200_state ? `${health.klf200_state}, API ${health.klf200_protocol ?? '?'}` : undefined}
+              >
                 <Server size={16} className="text-velux-blue" />
                 <span className="text-sm text-gray-300">KLF-200</span>
+                {health?.klf200_firmware && (
+                  <span className="text-xs text-gray-500 font-mono">{health.klf200_firmware}</span>
+                )}
               </div>
 
               {/* Connection Status */}
@@ -288,11 +314,15 @@ export function Dashboard() {
               onOpen={handleOpen}
               onClose={handleClose}
               onStop={handleStop}
+              onRename={handleRename}
+              onWink={handleWink}
               onRefresh={fetchData}
             />
           </div>
         )}
 
+        {activeTab === 'pairing' && <Pairing />}
+
         {activeTab === 'guide' && <LoxoneGuide />}
       </main>
 
@@ -5,6 +5,11 @@ import {
   Square,
   Maximize2,
   Minimize2,
+  BatteryMedium,
+  Plug,
+  Pencil,
+  Radio,
+  Lock,
 } from 'lucide-react';
 
 interface NodeCardProps {
@@ -13,6 +18,8 @@ interface NodeCardProps {
   onOpen: (id: number) => void;
   onClose: (id: number) => void;
   onStop: (id: number) => void;
+  onRename: (id: number, name: string) => void;
+  onWink: (id: number) => void;
 }
 
 export function NodeCard({
@@ -21,6 +28,8 @@ export function NodeCard({
   onOpen,
   onClose,
   onStop,
+  onRename,
+  onWink,
 }: NodeCardProps) {
   const [localPosition, setLocalPosition] = useState(node.position_percent);
 
@@ -37,6 +46,13 @@ export function NodeCard({
     onSetPosition(node.id, localPosition);
   };
 
+  const handleRename = () => {
+    const name = window.prompt('Neuer Name (wird im KLF-200 gespeichert)', node.name)?.trim();
+    if (name && name !== node.name) {
+      onRename(node.id, name);
+    }
+  };
+
   const isExecuting = node.state_str === 'Executing';
   // For inverted types (window openers): 0% = closed, 100% = open
   // For normal types (shutters): 0% = open, 100% = closed
@@ -61,6 +77,13 @@ export function NodeCard({
     return `${Math.round(node.position_percent)}%`;
   };
 
+  // Get lock display
+  const getLockText = () => {
+    if (!node.lock?.expires) return 'Gesperrt bis zur Freigabe';
+    const until = new Date(node.lock.expires);
+    return `Gesperrt bis ${until.toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' })}`;
+  };
+
   // Get status color
   const getStatusColor = () => {
     if (isExecuting) return 'text-yellow-500';
@@ -86,8 +109,34 @@ export function NodeCard({
             <div className="flex items-center gap-2">
               <h3 className="font-medium text-white">{node.name}</h3>
               <span className="px-1.5 py-0.5 bg-gray-700 rounded text-xs text-gray-400 font-mono">#{node.id}</span>
+              <button
+                onClick={handleRename}
+                className="text-gray-500 hover:text-gray-300 transition-colors"
+                title="Umbenennen"
+              >
+                <Pencil size={12} />
+              </button>
+              <button
+                onClick={() => onWink(node.id)}
+                className="text-gray-500 hover:text-gray-300 transition-colors"
+                title="Identifizieren (Gerät gibt ein Signal)"
+              >
+                <Radio size={12} />
+              </button>
+              {node.lock && (
+                <span title={getLockText()}>
+                  <Lock size={12} className="text-orange-400" aria-label="Gesperrt" />
+                </span>
+              )}
             </div>
-            <p className="text-xs text-gray-400">{node.node_type_str}</p>
+            <p className="text-xs text-gray-400 flex items-center gap-1">
+              {node.node_type_str}
+              {node.power_mode_str === 'Low Power' ? (
+                <BatteryMedium size={12} className="text-gray-500" aria-label="Batterie/Solar" />
+              ) : node.power_mode_str === 'Always Alive' ? (
+                <Plug size={12} className="text-gray-500" aria-label="Netzbetrieb" />
+              ) : null}
+            </p>
           </div>
         </div>
 
@@ -198,6 +247,11 @@ export function NodeCard({
         <p className="text-xs text-gray-500 font-mono truncate">
           /loxone/node/{node.id}/set/&#123;0-100&#125;
         </p>
+        {node.serial && (
+          <p className="text-xs text-gray-500 font-mono truncate mt-1" title="Seriennummer">
+            S/N {node.serial} · Raum {node.placement} · Reihenfolge {node.order}
+          </p>
+        )}
       </div>
     </div>
   );
@@ -9,6 +9,8 @@ interface NodeListProps {
   onOpen: (id: number) => void;
   onClose: (id: number) => void;
   onStop: (id: number) => void;
+  onRename: (id: number, name: string) => void;
+  onWink: (id: number) => void;
   onRefresh: () => void;
 }
 
@@ -19,6 +21,8 @@ export function NodeList({
   onOpen,
   onClose,
   onStop,
+  onRename,
+  onWink,
   onRefresh,
 }: NodeListProps) {
   if (loading && nodes.length === 0) {
@@ -50,6 +54,11 @@ export function NodeList({
     );
   }
 
+  // Sort by room placement, then by the KLF-200 product list order
+  nodes = [...nodes].sort((a, b) =>
+    (a.placement ?? 0) - (b.placement ?? 0) || (a.order ?? 0) - (b.order ?? 0) || a.id - b.id
+  );
+
   // Group nodes by type
   const windowNodes = nodes.filter(n => n.node_type_str === 'Window Opener');
   const shutterNodes = nodes.filter(n =>
@@ -78,6 +87,8 @@ export function NodeList({
               onOpen={onOpen}
               onClose={onClose}
               onStop={onStop}
+              onRename={onRename}
+              onWink={onWink}
             />
           ))}
         </div>
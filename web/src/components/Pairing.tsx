@@ -0,0 +1,154 @@
+import { useState, useEffect, useCallback } from 'react';
+import * as api from '../services/api';
+import { PairingJob, SystemTableEntry } from '../types';
+import { KeyRound, Search, Trash2, RefreshCw } from 'lucide-react';
+
+export function Pairing() {
+  const [token, setToken] = useState(api.getAdminToken());
+  const [job, setJob] = useState<PairingJob | null>(null);
+  const [entries, setEntries] = useState<SystemTableEntry[]>([]);
+  const [error, setError] = useState<string | null>(null);
+  const [loading, setLoading] = useState(false);
+
+  const load = useCallback(async () => {
+    if (!api.getAdminToken()) return;
+    setLoading(true);
+    setError(null);
+    try {
+      const [status, table] = await Promise.all([api.getPairingStatus(), api.getSystemTable()]);
+      setJob(status.job);
+      setEntries(table.entries || []);
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Fehler');
+    } finally {
+      setLoading(false);
+    }
+  }, []);
+
+  useEffect(() => {
+    load();
+  }, [load]);
+
+  // Poll while a discovery is running
+  useEffect(() => {
+    if (job?.state !== 'running') return;
+    const interval = setInterval(async () => {
+      try {
+        const status = await api.getPairingStatus();
+        setJob(status.job);
+        if (status.job?.state !== 'running') load();
+      } catch {
+        // Keep polling
+      }
+    }, 3000);
+    return () => clearInterval(interval);
+  }, [job?.state, load]);
+
+  const handleSaveToken = () => {
+    api.setAdminToken(token);
+    load();
+  };
+
+  const handleDiscover = async () => {
+    setError(null);
+    try {
+      setJob(await api.startDiscovery());
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Fehler');
+    }
+  };
+
+  const handleRemove = async (entry: SystemTableEntry) => {
+    if (!window.confirm(`Gerät #${entry.index} (${entry.node_type_str}) aus dem KLF-200 entfernen? Es muss danach neu angelernt werden.`)) {
+      return;
+    }
+    setError(null);
+    try {
+      await api.removeNodes([entry.index]);
+      load();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Fehler');
+    }
+  };
+
+  return (
+    <div className="space-y-6">
+      {/* Admin Token */}
+      <div className="bg-gray-800 rounded-xl p-4">
+        <h2 className="text-lg font-semibold text-white mb-2 flex items-center gap-2">
+          <KeyRound size={18} /> Admin-Zugang
+        </h2>
+        <div className="flex gap-2">
+          <input
+            type="password"
+            value={token}
+            onChange={(e) => setToken(e.target.value)}
+            placeholder="admin_token"
+            className="flex-1 bg-gray-700 text-white rounded-lg px-3 py-2 text-sm"
+          />
+          <button
+            onClick={handleSaveToken}
+            className="px-4 py-2 bg-velux-blue hover:bg-velux-dark text-white rounded-lg transition-colors text-sm"
+          >
+            Übernehmen
+          </button>
+        </div>
+      </div>
+
+      {error && <p className="text-red-400 text-sm">{error}</p>}
+
+      {/* Discovery */}
+      <div className="bg-gray-800 rounded-xl p-4">
+        <div className="flex items-center justify-between mb-2">
+          <h2 className="text-lg font-semibold text-white">Geräte anlernen</h2>
+          <button
+            onClick={handleDiscover}
+            disabled={job?.state === 'running'}
+            className="flex items-center gap-2 px-4 py-2 bg-velux-blue hover:bg-velux-dark text-white rounded-lg transition-colors text-sm disabled:opacity-50"
+          >
+            <Search size={16} className={job?.state === 'running' ? 'animate-pulse' : ''} />
+            Suche starten
+          </button>
+        </div>
+        <p className="text-sm text-gray-400">
+          Versetze das Gerät in den Anlernmodus (Programmiertaste), dann starte die Suche. Sie kann einige Minuten dauern.
+        </p>
+        {job && (
+          <p className={`text-sm mt-3 ${job.state === 'failed' ? 'text-red-400' : job.state === 'running' ? 'text-yellow-400' : 'text-green-400'}`}>
+            {job.message}
+          </p>
+        )}
+      </div>
+
+      {/* System Table */}
+      <div className="bg-gray-800 rounded-xl p-4">
+        <div className="flex items-center justify-between mb-2">
+          <h2 className="text-lg font-semibold text-white">Angelernte Geräte</h2>
+          <button onClick={load} className="p-2 text-gray-400 hover:text-white" title="Aktualisieren">
+            <RefreshCw size={16} className={loading ? 'animate-spin' : ''} />
+          </button>
+        </div>
+        <table className="w-full text-sm text-gray-300">
+          <tbody>
+            {entries.map((entry) => (
+              <tr key={entry.index} className="border-t border-gray-700">
+                <td className="py-2 font-mono">#{entry.index}</td>
+                <td className="py-2">{entry.node_type_str}</td>
+                <td className="py-2 font-mono text-gray-500">{entry.address.toString(16).padStart(6, '0')}</td>
+                <td className="py-2 text-right">
+                  <button
+                    onClick={() => handleRemove(entry)}
+                    className="p-1 text-gray-500 hover:text-red-400"
+                    title="Entfernen"
+                  >
+                    <Trash2 size={16} />
+                  </button>
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </div>
+    </div>
+  );
+}
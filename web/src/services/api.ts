@@ -1,11 +1,15 @@
 import {
   Node,
+  NodeUpdate,
   NodesResponse,
   HealthResponse,
   CommandResponse,
   PositionRequest,
   SensorStatus,
   GatewayConfig,
+  PairingStatus,
+  PairingJob,
+  SystemTableResponse,
 } from '../types';
 
 // Build the base path for API requests from the current page URL.
@@ -94,6 +98,21 @@ export async function stopNode(id: number): Promise<CommandResponse> {
   });
 }
 
+// Make a node give its identification signal
+export async function winkNode(id: number): Promise<CommandResponse> {
+  return fetchJSON<CommandResponse>(`api/nodes/${id}/wink`, {
+    method: 'POST',
+  });
+}
+
+// Change node settings stored in the KLF-200 (name, order, placement, velocity)
+export async function updateNode(id: number, update: NodeUpdate): Promise<Node> {
+  return fetchJSON<Node>(`api/nodes/${id}`, {
+    method: 'PATCH',
+    body: JSON.stringify(update),
+  });
+}
+
 // Get sensor status (rain, wind, etc.)
 export async function getSensorStatus(): Promise<SensorStatus> {
   return fetchJSON<SensorStatus>('api/sensors');
@@ -127,3 +146,48 @@ export async function reconnectGateway(): Promise<{ success: boolean; message: s
     method: 'POST',
   });
 }
+
+// Admin API (pairing) - requires the admin token
+
+const ADMIN_TOKEN_KEY = 'loxone2velux.adminToken';
+
+export function getAdminToken(): string {
+  return sessionStorage.getItem(ADMIN_TOKEN_KEY) || '';
+}
+
+export function setAdminToken(token: string) {
+  sessionStorage.setItem(ADMIN_TOKEN_KEY, token);
+}
+
+async function fetchAdmin<T>(url: string, options?: RequestInit): Promise<T> {
+  return fetchJSON<T>(url, {
+    ...options,
+    headers: { Authorization: `Bearer ${getAdminToken()}` },
+  });
+}
+
+// Get current or last pairing operation
+export async function getPairingStatus(): Promise<PairingStatus> {
+  return fetchAdmin<PairingStatus>('api/admin/pairing');
+}
+
+// Get products paired with the KLF-200
+export async function getSystemTable(): Promise<SystemTableResponse> {
+  return fetchAdmin<SystemTableResponse>('api/admin/pairing/system-table');
+}
+
+// Search for new products in pairing mode
+export async function startDiscovery(nodeType = 0): Promise<PairingJob> {
+  return fetchAdmin<PairingJob>('api/admin/pairing/discover', {
+    method: 'POST',
+    body: JSON.stringify({ node_type: nodeType }),
+  });
+}
+
+// Remove products from the KLF-200
+export async function removeNodes(nodeIds: number[]): Promise<{ success: boolean; removed: number[] }> {
+  return fetchAdmin<{ success: boolean; removed: number[] }>('api/admin/pairing/remove', {
+    method: 'POST',
+    body: JSON.stringify({ node_ids: nodeIds, confirm: true }),
+  });
+}
@@ -46,6 +46,40 @@ export interface Node {
   velocity: number;
   last_update: string;
   inverted: boolean; // true for window openers (0%=closed, 100%=open)
+  // Node information record
+  order: number; // Order in the KLF-200 product list
+  placement: number; // Room/group index
+  product_group: number;
+  product_type: number;
+  variation: number;
+  variation_str: string;
+  power_mode: number;
+  power_mode_str: 'Always Alive' | 'Low Power' | 'Unknown';
+  build_number: number;
+  serial: string;
+  timestamp?: string;
+  aliases?: NodeAlias[];
+  lock?: NodeLock; // Priority level lock set through the gateway
+}
+
+export interface NodeLock {
+  priority: number;
+  locked_from: number;
+  since: string;
+  expires?: string; // Missing = until unlocked
+}
+
+// Node settings stored in the KLF-200, omitted fields are unchanged
+export interface NodeUpdate {
+  name?: string;
+  order?: number;
+  placement?: number;
+  velocity?: 'default' | 'silent' | 'fast';
+}
+
+export interface NodeAlias {
+  type: number;
+  value: number;
 }
 
 // API Response types
@@ -74,6 +108,9 @@ export interface HealthResponse {
   connected: boolean;
   node_count: number;
   version: string;
+  klf200_firmware?: string;
+  klf200_protocol?: string;
+  klf200_state?: string;
 }
 
 export interface CommandResponse {
@@ -170,3 +207,41 @@ export function isShutterType(type: NodeType): boolean {
     'Vertical Exterior Awning',
   ].includes(type);
 }
+
+// Pairing (configuration service)
+export interface DiscoveryResult {
+  added: number[];
+  rf_error: number[];
+  io_key_error: number[];
+  removed: number[];
+  open: number[];
+  status: number;
+  status_str: string;
+}
+
+export interface PairingJob {
+  operation: 'discover' | 'controller_copy';
+  state: 'running' | 'completed' | 'failed';
+  message?: string;
+  started: string;
+  finished?: string;
+  discovery?: DiscoveryResult;
+}
+
+export interface PairingStatus {
+  job: PairingJob | null;
+}
+
+export interface SystemTableEntry {
+  index: number;
+  address: number;
+  node_type: number;
+  node_type_str: string;
+  power_mode: number;
+  manufacturer: number;
+}
+
+export interface SystemTableResponse {
+  entries: SystemTableEntry[];
+  count: number;
+}